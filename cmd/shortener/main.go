@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sashaaro/url-shortener/internal"
 	"github.com/sashaaro/url-shortener/internal/adapters"
@@ -10,17 +11,22 @@ import (
 	shortenerGrpc "github.com/sashaaro/url-shortener/internal/grpc"
 	"github.com/sashaaro/url-shortener/internal/handlers"
 	"github.com/sashaaro/url-shortener/internal/infra"
+	"github.com/sashaaro/url-shortener/internal/utils"
 	"github.com/sashaaro/url-shortener/internal/version"
 	"github.com/sashaaro/url-shortener/proto"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/acme/autocert"
 	"google.golang.org/grpc"
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 )
 
 var (
@@ -41,6 +47,14 @@ func main() {
 	logger := adapters.CreateLogger()
 
 	var urlRepo domain.URLRepository
+	var apiKeyRepo domain.APIKeyRepository
+	var userRepo domain.UserRepository
+	var workspaceRepo domain.WorkspaceRepository
+	var banRepo domain.BanRepository
+	var auditRepo domain.AuditRepository
+	var importJobRepo domain.ImportJobRepository
+	var domainRepo domain.DomainRepository
+	var defaultParamsRepo domain.DefaultParamsRepository
 
 	var pool *pgxpool.Pool
 	if internal.Config.DatabaseDSN != "" {
@@ -48,21 +62,144 @@ func main() {
 		//nolint:errcheck
 		defer pool.Close()
 		urlRepo = adapters.NewPgURLRepository(pool)
+		apiKeyRepo = adapters.NewPgAPIKeyRepository(pool)
+		userRepo = adapters.NewPgUserRepository(pool)
+		workspaceRepo = adapters.NewPgWorkspaceRepository(pool)
+		banRepo = adapters.NewPgBanRepository(pool)
+		auditRepo = adapters.NewPgAuditRepository(pool)
+		importJobRepo = adapters.NewPgImportJobRepository(pool)
+		domainRepo = adapters.NewPgDomainRepository(pool)
+		defaultParamsRepo = adapters.NewPgDefaultParamsRepository(pool)
 	} else {
 		urlRepo = adapters.NewMemURLRepository()
+		apiKeyRepo = adapters.NewMemAPIKeyRepository()
+		userRepo = adapters.NewMemUserRepository()
+		workspaceRepo = adapters.NewMemWorkspaceRepository()
+		banRepo = adapters.NewMemBanRepository()
+		auditRepo = adapters.NewMemAuditRepository()
+		importJobRepo = adapters.NewMemImportJobRepository()
+		domainRepo = adapters.NewMemDomainRepository()
+		defaultParamsRepo = adapters.NewMemDefaultParamsRepository()
 		if internal.Config.FileStoragePath != "" {
-			urlRepo = adapters.NewFileURLRepository(internal.Config.FileStoragePath, urlRepo, logger) // wrap with file storage
+			fileRepo := adapters.NewFileURLRepository(internal.Config.FileStoragePath, urlRepo, logger) // wrap with file storage
+			//nolint:errcheck
+			defer fileRepo.Close()
+			urlRepo = fileRepo
 		}
 	}
 
+	if internal.Config.AuditLogFile != "" {
+		fileAuditRepo, err := adapters.NewFileAuditRepository(internal.Config.AuditLogFile)
+		if err != nil {
+			log.Fatal("cannot open audit log: ", err)
+		}
+		//nolint:errcheck
+		defer fileAuditRepo.Close()
+		auditRepo = fileAuditRepo
+	}
+	auditService := domain.NewAuditService(auditRepo, adapters.AuditActorFromCtx)
+	domainService := domain.NewDomainService(domainRepo, internal.Config.Domains...)
+
 	sigint := make(chan os.Signal, 1)
 	signal.Notify(sigint, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
 
-	shrtenerService := domain.NewShortenerService(urlRepo, adapters.GenBase64ShortURLToken)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	redirectType := domain.RedirectType(internal.Config.RedirectType)
+	if !redirectType.Valid() {
+		log.Fatal("invalid redirect type: ", internal.Config.RedirectType)
+	}
+	rateLimiter, rateLimits := createRateLimiter(pool)
+	shortenerOpts := []domain.ShortenerOption{
+		domain.WithNormalizer(domain.NewURLNormalizer(domain.NormalizeOptions{
+			StripTracking:  internal.Config.NormalizeStripTracking,
+			TrackingParams: internal.Config.NormalizeTrackingParams,
+		})),
+		domain.WithURLPolicy(createURLPolicy(ctx, logger)),
+		domain.WithWorkspaceRepository(workspaceRepo),
+		domain.WithBanRepository(banRepo),
+		domain.WithAuditService(auditService),
+		domain.WithImportJobRepository(importJobRepo),
+		domain.WithImportRateLimit(rateLimiter, rateLimits.Batch),
+		domain.WithRedirectDefaults(redirectType, internal.Config.RedirectPassQuery),
+		domain.WithDomainService(domainService),
+		domain.WithDefaultParamsRepository(defaultParamsRepo),
+	}
+	if internal.Config.GeoIPDatabaseFile != "" {
+		geoIP, err := adapters.NewMaxMindGeoIP(internal.Config.GeoIPDatabaseFile)
+		if err != nil {
+			log.Fatal("cannot open geoip database: ", err)
+		}
+		//nolint:errcheck
+		defer geoIP.Close()
+		shortenerOpts = append(shortenerOpts, domain.WithGeoIP(geoIP))
+	}
+	adminOpts := []domain.AdminOption{domain.WithAdminAuditService(auditService)}
+	workspaceOpts := []domain.WorkspaceOption{domain.WithWorkspaceAuditService(auditService)}
+	apiKeyOpts := []domain.APIKeyOption{domain.WithAPIKeyAuditService(auditService)}
+	if pool != nil {
+		// ссылки и журнал аудита в postgres меняются одной транзакцией
+		transactor := adapters.NewPgTransactor(pool)
+		shortenerOpts = append(shortenerOpts, domain.WithTransactor(transactor))
+		adminOpts = append(adminOpts, domain.WithAdminTransactor(transactor))
+		workspaceOpts = append(workspaceOpts, domain.WithWorkspaceTransactor(transactor))
+		apiKeyOpts = append(apiKeyOpts, domain.WithAPIKeyTransactor(transactor))
+	}
+
+	shrtenerService := domain.NewShortenerService(urlRepo, adapters.GenBase64ShortURLToken, shortenerOpts...)
+
+	apiKeyService := domain.NewAPIKeyService(apiKeyRepo, apiKeyOpts...)
+	userService := domain.NewUserService(userRepo, shrtenerService)
+	keyring, err := adapters.CreateKeyring(ctx, logger)
+	if err != nil {
+		log.Fatal("cannot create jwt keyring: ", err)
+	}
+	admins, err := parseUserIDs(internal.Config.AdminUserIDs)
+	if err != nil {
+		log.Fatal("invalid admin user ids: ", err)
+	}
+	authOpts := []adapters.AuthenticatorOption{adapters.WithAdmins(admins...)}
+	if pool != nil {
+		authOpts = append(authOpts, adapters.WithTokenRevocations(adapters.NewPgTokenRevocations(pool)))
+	}
+	authenticator := adapters.NewAuthenticator(keyring, apiKeyService, internal.Config.JwtRefreshTTL, authOpts...)
+	adminService := domain.NewAdminService(urlRepo, banRepo, adminOpts...)
+
+	templates, err := handlers.LoadTemplates(internal.Config.TemplatesDir)
+	if err != nil {
+		log.Fatal("cannot load templates: ", err)
+	}
+
+	muxOpts := []handlers.ServeMuxOption{
+		handlers.WithRateLimiter(rateLimiter, rateLimits),
+		handlers.WithAPIKeyService(apiKeyService),
+		handlers.WithAuthenticator(authenticator),
+		handlers.WithUserService(userService),
+		handlers.WithWorkspaceService(domain.NewWorkspaceService(workspaceRepo, workspaceOpts...)),
+		handlers.WithAdminService(adminService),
+		handlers.WithAuditService(auditService),
+		handlers.WithRedirectCacheMaxAge(internal.Config.RedirectCacheMaxAge),
+		handlers.WithTemplates(templates),
+		handlers.WithDomainService(domainService),
+	}
+	if internal.Config.OIDCIssuer != "" {
+		oidcProvider, err := adapters.NewOIDCProvider(ctx, adapters.OIDCConfig{
+			Issuer:       internal.Config.OIDCIssuer,
+			ClientID:     internal.Config.OIDCClientID,
+			ClientSecret: internal.Config.OIDCClientSecret,
+			RedirectURL:  internal.Config.OIDCRedirectURL,
+			Scopes:       internal.Config.OIDCScopes,
+		}, keyring)
+		if err != nil {
+			log.Fatal("cannot create oidc provider: ", err)
+		}
+		muxOpts = append(muxOpts, handlers.WithOIDCProvider(oidcProvider))
+	}
 
 	srv := http.Server{
 		Addr:    internal.Config.ServerAddress,
-		Handler: handlers.CreateServeMux(shrtenerService, logger, pool),
+		Handler: handlers.CreateServeMux(shrtenerService, logger, pool, muxOpts...),
 	}
 
 	signalClosed := make(chan struct{})
@@ -70,6 +207,7 @@ func main() {
 	go func() {
 		<-sigint
 		log.Println("graceful shutdown...")
+		cancel()
 
 		if err := srv.Shutdown(context.Background()); err != nil {
 			log.Printf("HTTP server Shutdown: %v", err)
@@ -87,8 +225,29 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	grpcServer := grpc.NewServer()
+	trustedProxies, err := utils.ParseCIDRs(internal.Config.TrustedProxies)
+	if err != nil {
+		log.Fatal("invalid trusted proxies: ", err)
+	}
+	clientIPResolver := adapters.NewClientIPResolver(trustedProxies, internal.Config.ClientIPHeader)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			shortenerGrpc.RequestIDInterceptor(),
+			shortenerGrpc.ClientIPInterceptor(clientIPResolver),
+			shortenerGrpc.LinkDomainInterceptor(domainService),
+			shortenerGrpc.AuthInterceptor(authenticator),
+			shortenerGrpc.RateLimitInterceptor(logger, rateLimiter, rateLimits),
+		),
+		grpc.ChainStreamInterceptor(
+			shortenerGrpc.StreamRequestIDInterceptor(),
+			shortenerGrpc.StreamClientIPInterceptor(clientIPResolver),
+			shortenerGrpc.StreamLinkDomainInterceptor(domainService),
+			shortenerGrpc.StreamAuthInterceptor(authenticator),
+			shortenerGrpc.StreamRateLimitInterceptor(logger, rateLimiter, rateLimits),
+		),
+	)
 	proto.RegisterURLShortenerServer(grpcServer, shortenerGrpc.NewGrpcService(shrtenerService, adapters.GenBase64ShortURLToken))
+	proto.RegisterURLShortenerAdminServer(grpcServer, shortenerGrpc.NewAdminService(adminService, domainService))
 
 	go func() {
 		log.Printf("Listen grpc")
@@ -100,7 +259,7 @@ func main() {
 	go func() {
 		log.Printf("Listen http")
 		if internal.Config.EnableHTTPS {
-			err = srv.Serve(autocert.NewListener(internal.Config.ServerAddress, "url-shortener.ru", "www.url-shortener.ru"))
+			err = srv.Serve(autocert.NewListener(tlsHosts(domainService)...))
 		} else {
 			err = srv.ListenAndServe()
 		}
@@ -111,3 +270,96 @@ func main() {
 
 	<-signalClosed
 }
+
+// parseUserIDs разбор списка идентификаторов пользователей
+func parseUserIDs(list []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(list))
+	for _, s := range list {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// tlsHosts домены, для которых выпускаются сертификаты: основной домен с www и брендовые домены,
+// у каждого домена свой сертификат
+func tlsHosts(domains *domain.DomainService) []string {
+	hosts := domains.Hosts()
+	if baseURL, err := url.Parse(internal.Config.BaseURL); err == nil && baseURL.Hostname() != "" {
+		hosts = append(hosts, baseURL.Hostname(), "www."+baseURL.Hostname())
+	}
+	return hosts
+}
+
+// createURLPolicy политика допустимых ссылок из конфига
+func createURLPolicy(ctx context.Context, logger zap.SugaredLogger) *domain.URLPolicy {
+	policy := domain.DefaultURLPolicy()
+	if len(internal.Config.AllowedSchemes) > 0 {
+		policy.AllowedSchemes = internal.Config.AllowedSchemes
+	}
+	if len(internal.Config.DeniedHosts) > 0 {
+		policy.DeniedHosts = internal.Config.DeniedHosts
+	}
+	if len(internal.Config.DeniedNetworks) > 0 {
+		nets, err := utils.ParseCIDRs(internal.Config.DeniedNetworks)
+		if err != nil {
+			log.Fatal("invalid denied networks: ", err)
+		}
+		policy.DeniedNetworks = nets
+	}
+	if internal.Config.MaxURLLength > 0 {
+		policy.MaxLength = internal.Config.MaxURLLength
+	}
+	if baseURL, err := url.Parse(internal.Config.BaseURL); err == nil {
+		policy.SelfHosts = append(policy.SelfHosts, baseURL.Hostname())
+	}
+	policy.SelfHosts = append(policy.SelfHosts, internal.Config.Domains...)
+	if internal.Config.DomainBlocklistFile != "" {
+		blocklist, err := adapters.NewFileDomainBlocklist(internal.Config.DomainBlocklistFile, logger)
+		if err != nil {
+			log.Fatal("cannot load domain blocklist: ", err)
+		}
+		go blocklist.Watch(ctx, 5*time.Second)
+		policy.Blocklist = blocklist
+	}
+	return policy
+}
+
+// createRateLimiter ограничение частоты запросов из конфига
+func createRateLimiter(pool *pgxpool.Pool) (domain.RateLimiter, domain.RateLimits) {
+	var limits domain.RateLimits
+	for _, l := range []struct {
+		spec  string
+		limit *domain.RateLimit
+	}{
+		{internal.Config.RateLimitCreate, &limits.Create},
+		{internal.Config.RateLimitBatch, &limits.Batch},
+		{internal.Config.RateLimitRedirect, &limits.Redirect},
+		{internal.Config.RateLimitDelete, &limits.Delete},
+	} {
+		limit, err := domain.ParseRateLimit(l.spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*l.limit = limit
+	}
+
+	switch internal.Config.RateLimitStorage {
+	case "", "memory":
+		return adapters.NewMemRateLimiter(), limits
+	case "postgres":
+		if pool == nil {
+			log.Fatal("postgres rate limit storage requires DATABASE_DSN")
+		}
+		return adapters.NewPgRateLimiter(pool), limits
+	default:
+		log.Fatalf("unknown rate limit storage %q", internal.Config.RateLimitStorage)
+		return nil, limits
+	}
+}
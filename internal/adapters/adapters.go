@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/sashaaro/url-shortener/internal"
 	"github.com/sashaaro/url-shortener/internal/domain"
+	"net/url"
 )
 
 // GenBase64ShortURLToken - генерация токена
@@ -21,7 +22,15 @@ func GenBase64ShortURLToken() domain.HashKey {
 	return base64.URLEncoding.EncodeToString(buf)[:length]
 }
 
-// CreatePublicURL - создание ссылки из ключа
-func CreatePublicURL(key domain.HashKey) string {
-	return internal.Config.BaseURL + "/" + key
+// CreatePublicURL - создание ссылки из домена и ключа, пустой домен - основной адрес BASE_URL.
+// Брендовые домены открываются по той же схеме, что и основной.
+func CreatePublicURL(host string, key domain.HashKey) string {
+	if host == "" {
+		return internal.Config.BaseURL + "/" + key
+	}
+	scheme := "https"
+	if u, err := url.Parse(internal.Config.BaseURL); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+	return scheme + "://" + host + "/" + key
 }
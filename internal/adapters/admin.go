@@ -0,0 +1,79 @@
+package adapters
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"sync"
+)
+
+var _ domain.BanRepository = &memBanRepository{}
+
+// хранение запретов в памяти
+type memBanRepository struct {
+	bans map[uuid.UUID]domain.UserBan
+	mx   sync.Mutex
+}
+
+// NewMemBanRepository - конструктор
+func NewMemBanRepository() domain.BanRepository {
+	return &memBanRepository{bans: map[uuid.UUID]domain.UserBan{}}
+}
+
+// Ban запрет
+func (m *memBanRepository) Ban(ctx context.Context, ban domain.UserBan) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.bans[ban.UserID] = ban
+	return nil
+}
+
+// Unban снятие запрета
+func (m *memBanRepository) Unban(ctx context.Context, userID uuid.UUID) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	delete(m.bans, userID)
+	return nil
+}
+
+// IsBanned есть ли запрет
+func (m *memBanRepository) IsBanned(ctx context.Context, userID uuid.UUID) (bool, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	_, ok := m.bans[userID]
+	return ok, nil
+}
+
+var _ domain.BanRepository = &PgBanRepository{}
+
+// PgBanRepository - хранение запретов в postgres
+type PgBanRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgBanRepository - конструктор
+func NewPgBanRepository(pool *pgxpool.Pool) *PgBanRepository {
+	return &PgBanRepository{pool: pool}
+}
+
+// Ban запрет
+func (r *PgBanRepository) Ban(ctx context.Context, ban domain.UserBan) error {
+	_, err := pgConnFromCtx(ctx, r.pool).Exec(ctx, `INSERT INTO user_bans (user_id, reason, banned_by, created_at) VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE SET reason = EXCLUDED.reason, banned_by = EXCLUDED.banned_by, created_at = EXCLUDED.created_at`,
+		ban.UserID, ban.Reason, ban.BannedBy, ban.CreatedAt)
+	return err
+}
+
+// Unban снятие запрета
+func (r *PgBanRepository) Unban(ctx context.Context, userID uuid.UUID) error {
+	_, err := pgConnFromCtx(ctx, r.pool).Exec(ctx, "DELETE FROM user_bans WHERE user_id = $1", userID)
+	return err
+}
+
+// IsBanned есть ли запрет
+func (r *PgBanRepository) IsBanned(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var banned bool
+	err := r.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM user_bans WHERE user_id = $1)", userID).Scan(&banned)
+	return banned, err
+}
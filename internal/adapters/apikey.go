@@ -0,0 +1,162 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"slices"
+	"sync"
+	"time"
+)
+
+var _ domain.APIKeyRepository = &memAPIKeyRepository{}
+
+// хранение api ключей в памяти
+type memAPIKeyRepository struct {
+	keys map[uuid.UUID]domain.APIKey
+	mx   sync.Mutex
+}
+
+// NewMemAPIKeyRepository - конструктор
+func NewMemAPIKeyRepository() domain.APIKeyRepository {
+	return &memAPIKeyRepository{keys: map[uuid.UUID]domain.APIKey{}}
+}
+
+// Add добавление ключа
+func (m *memAPIKeyRepository) Add(ctx context.Context, key domain.APIKey) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.keys[key.ID] = key
+	return nil
+}
+
+// GetByPrefix получение ключа по префиксу
+func (m *memAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	for _, key := range m.keys {
+		if key.Prefix == prefix {
+			return &key, nil
+		}
+	}
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+// ListByUser ключи пользователя
+func (m *memAPIKeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.APIKey, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	l := make([]domain.APIKey, 0)
+	for _, key := range m.keys {
+		if key.UserID == userID {
+			l = append(l, key)
+		}
+	}
+	slices.SortFunc(l, func(a, b domain.APIKey) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return l, nil
+}
+
+// Rename переименование ключа
+func (m *memAPIKeyRepository) Rename(ctx context.Context, id uuid.UUID, userID uuid.UUID, name string) (*domain.APIKey, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	key, ok := m.keys[id]
+	if !ok || key.UserID != userID {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	key.Name = name
+	m.keys[id] = key
+	return &key, nil
+}
+
+// Revoke отзыв ключа
+func (m *memAPIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	key, ok := m.keys[id]
+	if !ok || key.UserID != userID {
+		return domain.ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		now := time.Now().UTC()
+		key.RevokedAt = &now
+		m.keys[id] = key
+	}
+	return nil
+}
+
+var _ domain.APIKeyRepository = &PgAPIKeyRepository{}
+
+// PgAPIKeyRepository - хранение api ключей в postgres
+type PgAPIKeyRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgAPIKeyRepository - конструктор
+func NewPgAPIKeyRepository(pool *pgxpool.Pool) *PgAPIKeyRepository {
+	return &PgAPIKeyRepository{pool: pool}
+}
+
+const apiKeyColumns = "id, user_id, name, prefix, hash, scopes, created_at, revoked_at"
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	key := &domain.APIKey{}
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &key.Scopes, &key.CreatedAt, &key.RevokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// Add добавление ключа
+func (r *PgAPIKeyRepository) Add(ctx context.Context, key domain.APIKey) error {
+	_, err := pgConnFromCtx(ctx, r.pool).Exec(ctx, "INSERT INTO api_keys ("+apiKeyColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		key.ID, key.UserID, key.Name, key.Prefix, key.Hash, key.Scopes, key.CreatedAt, key.RevokedAt)
+	return err
+}
+
+// GetByPrefix получение ключа по префиксу
+func (r *PgAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	return scanAPIKey(r.pool.QueryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = $1", prefix))
+}
+
+// ListByUser ключи пользователя
+func (r *PgAPIKeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.APIKey, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = $1 ORDER BY created_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// Rename переименование ключа
+func (r *PgAPIKeyRepository) Rename(ctx context.Context, id uuid.UUID, userID uuid.UUID, name string) (*domain.APIKey, error) {
+	return scanAPIKey(pgConnFromCtx(ctx, r.pool).QueryRow(ctx, "UPDATE api_keys SET name = $3 WHERE id = $1 AND user_id = $2 RETURNING "+apiKeyColumns, id, userID, name))
+}
+
+// Revoke отзыв ключа
+func (r *PgAPIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	res, err := pgConnFromCtx(ctx, r.pool).Exec(ctx, "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
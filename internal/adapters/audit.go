@@ -0,0 +1,293 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// maxRequestIDLength - длина идентификатора запроса от клиента, длиннее заменяется новым
+const maxRequestIDLength = 128
+
+type requestIDContext struct{}
+
+// RequestIDOrNew идентификатор запроса от клиента, если он короткий и из видимых ascii символов, иначе новый
+func RequestIDOrNew(requestID string) string {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for _, c := range requestID {
+		if c <= ' ' || c > '~' {
+			return uuid.NewString()
+		}
+	}
+	return requestID
+}
+
+// RequestIDToCtx - добавление идентификатора запроса в контекст
+func RequestIDToCtx(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, &requestIDContext{}, requestID)
+}
+
+// RequestIDFromCtx - идентификатор запроса из контекста
+func RequestIDFromCtx(ctx context.Context) string {
+	requestID, _ := ctx.Value(&requestIDContext{}).(string)
+	return requestID
+}
+
+// AuditActorFromCtx - исполнитель действия: пользователь, способ входа, ip и запрос
+func AuditActorFromCtx(ctx context.Context) domain.AuditActor {
+	actor := domain.AuditActor{
+		AuthMethod: string(AuthMethodFromCtx(ctx)),
+		RequestID:  RequestIDFromCtx(ctx),
+	}
+	if identity, ok := IdentityFromCtx(ctx); ok {
+		actor.UserID = identity.UserID
+	}
+	if ip := ClientIPFromCtx(ctx); ip != nil {
+		actor.ClientIP = ip.String()
+	}
+	return actor
+}
+
+var _ domain.AuditRepository = &memAuditRepository{}
+
+// журнал аудита в памяти
+type memAuditRepository struct {
+	events []domain.AuditEvent
+	mx     sync.Mutex
+}
+
+// NewMemAuditRepository - конструктор
+func NewMemAuditRepository() domain.AuditRepository {
+	return &memAuditRepository{}
+}
+
+// Append добавление записей
+func (m *memAuditRepository) Append(ctx context.Context, events []domain.AuditEvent) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	for _, e := range events {
+		e.ID = int64(len(m.events)) + 1
+		m.events = append(m.events, e)
+	}
+	return nil
+}
+
+// Query записи по фильтру от новых к старым
+func (m *memAuditRepository) Query(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	res := make([]domain.AuditEvent, 0)
+	for i := len(m.events) - 1; i >= 0 && len(res) < filter.Limit; i-- {
+		if filter.Match(m.events[i]) {
+			res = append(res, m.events[i])
+		}
+	}
+	return res, nil
+}
+
+var _ domain.AuditRepository = &FileAuditRepository{}
+
+// FileAuditRepository - журнал аудита в файле, одна запись json на строку, файл только дописывается
+type FileAuditRepository struct {
+	path   string
+	file   *os.File
+	lastID int64
+	mx     sync.Mutex
+}
+
+// NewFileAuditRepository - конструктор, продолжает нумерацию существующего файла
+func NewFileAuditRepository(path string) (*FileAuditRepository, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	r := &FileAuditRepository{path: path, file: file}
+	size, err := r.scan(func(e domain.AuditEvent) bool {
+		r.lastID = e.ID
+		return true
+	})
+	if err == nil {
+		// недописанная после сбоя строка отрезается, чтобы не склеиться со следующей записью
+		err = file.Truncate(size)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Append добавление записей
+func (r *FileAuditRepository) Append(ctx context.Context, events []domain.AuditEvent) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	lastID := r.lastID
+	for _, e := range events {
+		lastID++
+		e.ID = lastID
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+	if _, err := r.file.WriteString(b.String()); err != nil {
+		return err
+	}
+	r.lastID = lastID
+	return r.file.Sync()
+}
+
+// Query записи по фильтру от новых к старым
+func (r *FileAuditRepository) Query(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	var matched []domain.AuditEvent
+	_, err := r.scan(func(e domain.AuditEvent) bool {
+		if filter.Cursor > 0 && e.ID >= filter.Cursor {
+			// дальше записи только новее курсора
+			return false
+		}
+		if filter.Match(e) {
+			matched = append(matched, e)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.AuditEvent, 0, min(len(matched), filter.Limit))
+	for i := len(matched) - 1; i >= 0 && len(res) < filter.Limit; i-- {
+		res = append(res, matched[i])
+	}
+	return res, nil
+}
+
+// Close закрытие файла
+func (r *FileAuditRepository) Close() error {
+	return r.file.Close()
+}
+
+// scan обход записей файла по порядку, пока fn возвращает true, возвращает размер прочитанных целых строк
+func (r *FileAuditRepository) scan(fn func(e domain.AuditEvent) bool) (int64, error) {
+	file, err := os.Open(r.path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var size int64
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		b, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// недописанная последняя строка после сбоя не считается записью
+			return size, nil
+		}
+		if err != nil {
+			return size, err
+		}
+		var e domain.AuditEvent
+		if err = json.Unmarshal(b, &e); err != nil {
+			return size, fmt.Errorf("%s:%d: %w", r.path, line, err)
+		}
+		size += int64(len(b))
+		if !fn(e) {
+			return size, nil
+		}
+	}
+}
+
+var _ domain.AuditRepository = &PgAuditRepository{}
+
+// PgAuditRepository - журнал аудита в postgres
+type PgAuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgAuditRepository - конструктор
+func NewPgAuditRepository(pool *pgxpool.Pool) *PgAuditRepository {
+	return &PgAuditRepository{pool: pool}
+}
+
+// Append добавление записей
+func (r *PgAuditRepository) Append(ctx context.Context, events []domain.AuditEvent) error {
+	batch := &pgx.Batch{}
+	for _, e := range events {
+		batch.Queue(`INSERT INTO audit_log (action, link_key, link_domain, subject, actor_id, auth_method, client_ip, request_id, before, after, details, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			e.Action, e.Key, e.Domain, e.Subject, nullUUID(e.UserID), e.AuthMethod, e.ClientIP, e.RequestID, e.Before, e.After, e.Details, e.CreatedAt)
+	}
+	// внутри транзакции PgTransactor запись откатится вместе с изменением
+	return pgConnFromCtx(ctx, r.pool).SendBatch(ctx, batch).Close()
+}
+
+// Query записи по фильтру от новых к старым
+func (r *PgAuditRepository) Query(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Cursor > 0 {
+		add("id < $%d", filter.Cursor)
+	}
+	if filter.ActorID != uuid.Nil {
+		add("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.Key != "" {
+		add("link_key = $%d", filter.Key)
+	}
+	if filter.Key != "" || filter.Domain != "" {
+		add("link_domain = $%d", filter.Domain)
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("created_at < $%d", filter.Until)
+	}
+
+	query := "SELECT id, action, link_key, link_domain, subject, actor_id, auth_method, client_ip, request_id, before, after, details, created_at FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.AuditEvent{}
+	for rows.Next() {
+		var e domain.AuditEvent
+		var actorID uuid.NullUUID
+		err = rows.Scan(&e.ID, &e.Action, &e.Key, &e.Domain, &e.Subject, &actorID, &e.AuthMethod, &e.ClientIP, &e.RequestID, &e.Before, &e.After, &e.Details, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.UserID = actorID.UUID
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,84 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileAuditRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	actorID := uuid.New()
+	event := func(action string, key domain.HashKey) domain.AuditEvent {
+		return domain.AuditEvent{
+			Action:     action,
+			Key:        key,
+			AuditActor: domain.AuditActor{UserID: actorID, AuthMethod: string(AuthMethodJWT)},
+			After:      &domain.Link{Key: key, OriginalURL: "https://example.com/" + key},
+			CreatedAt:  time.Now().UTC(),
+		}
+	}
+
+	repo, err := NewFileAuditRepository(path)
+	require.NoError(t, err)
+	require.NoError(t, repo.Append(context.Background(), []domain.AuditEvent{
+		event(domain.AuditLinkCreate, "a"),
+		event(domain.AuditLinkCreate, "b"),
+	}))
+	require.NoError(t, repo.Close())
+
+	// повторное открытие продолжает нумерацию
+	repo, err = NewFileAuditRepository(path)
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Append(context.Background(), []domain.AuditEvent{event(domain.AuditLinkDelete, "a")}))
+
+	events, err := repo.Query(context.Background(), domain.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.Equal(t, []int64{3, 2, 1}, []int64{events[0].ID, events[1].ID, events[2].ID})
+	require.Equal(t, "https://example.com/a", events[2].After.OriginalURL)
+
+	events, err = repo.Query(context.Background(), domain.AuditFilter{Key: "a", Cursor: 3, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, domain.AuditLinkCreate, events[0].Action)
+
+	// недописанная строка после сбоя не ломает журнал
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"id": 4, "act`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	events, err = repo.Query(context.Background(), domain.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	require.NoError(t, repo.Close())
+	repo, err = NewFileAuditRepository(path)
+	require.NoError(t, err)
+	require.NoError(t, repo.Append(context.Background(), []domain.AuditEvent{event(domain.AuditLinkDelete, "b")}))
+	events, err = repo.Query(context.Background(), domain.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	require.Equal(t, int64(4), events[0].ID)
+
+	// тот же ключ на другом домене - другая ссылка
+	branded := event(domain.AuditLinkCreate, "a")
+	branded.Domain = "go.example.com"
+	require.NoError(t, repo.Append(context.Background(), []domain.AuditEvent{branded}))
+	events, err = repo.Query(context.Background(), domain.AuditFilter{Key: "a", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	events, err = repo.Query(context.Background(), domain.AuditFilter{Key: "a", Domain: "go.example.com", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, int64(5), events[0].ID)
+	require.Equal(t, "go.example.com", events[0].Domain)
+}
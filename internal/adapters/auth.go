@@ -4,14 +4,55 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/domain"
 	"github.com/sashaaro/url-shortener/internal/utils"
 	"net/http"
+	"slices"
+	"time"
 )
 
-type userContext struct{}
+// AuthMethod - способ аутентификации пользователя
+type AuthMethod string
+
+const (
+	// AuthMethodAnonymous - новый анонимный пользователь, токена не было
+	AuthMethodAnonymous AuthMethod = "anonymous"
+	// AuthMethodJWT - пользователь из jwt токена
+	AuthMethodJWT AuthMethod = "jwt"
+	// AuthMethodAPIKey - пользователь из api ключа
+	AuthMethodAPIKey AuthMethod = "api_key"
+)
+
+// Identity - аутентифицированный пользователь
+type Identity struct {
+	UserID uuid.UUID
+	Method AuthMethod
+	// Scopes - права api ключа, nil - без ограничений
+	Scopes []string
+	// APIKeyID - ключ, которым аутентифицирован пользователь
+	APIKeyID uuid.UUID
+}
+
+// HasScope - пользователь имеет право
+func (i Identity) HasScope(scope string) bool {
+	return i.Scopes == nil || slices.Contains(i.Scopes, scope)
+}
+
+type identityContext struct{}
+
+// IdentityToCtx - добавление пользователя в контекст
+func IdentityToCtx(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, &identityContext{}, identity)
+}
+
+// IdentityFromCtx - получение пользователя из контекста
+func IdentityFromCtx(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(&identityContext{}).(Identity)
+	return identity, ok
+}
 
 func userIDFromReq(req *http.Request) (uuid.UUID, error) {
-	return userIDFromCtx(req.Context())
+	return UserIDFromCtx(req.Context())
 }
 
 // MustUserIDFromReq - получение пользователя из http запроса
@@ -19,15 +60,153 @@ func MustUserIDFromReq(req *http.Request) uuid.UUID {
 	return utils.Must(userIDFromReq(req))
 }
 
-func userIDFromCtx(ctx context.Context) (uuid.UUID, error) {
-	userID, ok := ctx.Value(&userContext{}).(uuid.UUID)
+// UserIDFromCtx - получение пользователя из контекста
+func UserIDFromCtx(ctx context.Context) (uuid.UUID, error) {
+	identity, ok := IdentityFromCtx(ctx)
 	if !ok {
 		return uuid.Nil, errors.New("user id not found")
 	}
-	return userID, nil
+	return identity.UserID, nil
 }
 
-// UserIDToCxt - получение пользователя из контекста
+// UserIDToCxt - добавление пользователя в контекст
 func UserIDToCxt(ctx context.Context, userID uuid.UUID) context.Context {
-	return context.WithValue(ctx, &userContext{}, userID)
+	return IdentityToCtx(ctx, Identity{UserID: userID, Method: AuthMethodJWT})
+}
+
+// AuthMethodFromCtx - способ аутентификации из контекста
+func AuthMethodFromCtx(ctx context.Context) AuthMethod {
+	identity, ok := IdentityFromCtx(ctx)
+	if !ok {
+		return AuthMethodAnonymous
+	}
+	return identity.Method
+}
+
+// Authenticator - проверка jwt токенов и api ключей
+type Authenticator struct {
+	keyring    *Keyring
+	apiKeys    *domain.APIKeyService
+	refreshTTL time.Duration
+	admins     map[uuid.UUID]struct{}
+	// revocations - refresh токены, отозванные при выходе
+	revocations TokenRevocations
+}
+
+// AuthenticatorOption - опция аутентификации
+type AuthenticatorOption func(a *Authenticator)
+
+// WithAdmins - администраторы сервиса
+func WithAdmins(userIDs ...uuid.UUID) AuthenticatorOption {
+	return func(a *Authenticator) {
+		for _, id := range userIDs {
+			a.admins[id] = struct{}{}
+		}
+	}
+}
+
+// WithTokenRevocations - хранилище отозванных refresh токенов, по умолчанию - в памяти процесса
+func WithTokenRevocations(revocations TokenRevocations) AuthenticatorOption {
+	return func(a *Authenticator) {
+		a.revocations = revocations
+	}
+}
+
+// NewAuthenticator конструктор, при нулевом refreshTTL используется JwtRefreshTTL
+func NewAuthenticator(keyring *Keyring, apiKeys *domain.APIKeyService, refreshTTL time.Duration, opts ...AuthenticatorOption) *Authenticator {
+	if refreshTTL <= 0 {
+		refreshTTL = JwtRefreshTTL
+	}
+	a := &Authenticator{
+		keyring:     keyring,
+		apiKeys:     apiKeys,
+		refreshTTL:  refreshTTL,
+		admins:      map[uuid.UUID]struct{}{},
+		revocations: NewMemTokenRevocations(),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// IsAdmin пользователь администратор, api ключу нужно право admin
+func (a *Authenticator) IsAdmin(identity Identity) bool {
+	if identity.Method == AuthMethodAnonymous {
+		return false
+	}
+	if identity.Method == AuthMethodAPIKey && !slices.Contains(identity.Scopes, domain.ScopeAdmin) {
+		return false
+	}
+	_, ok := a.admins[identity.UserID]
+	return ok
+}
+
+// RefreshTTL время жизни refresh токенов
+func (a *Authenticator) RefreshTTL() time.Duration {
+	return a.refreshTTL
+}
+
+// Authenticate проверка токена: api ключа или access jwt
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (Identity, error) {
+	if domain.IsAPIKeyToken(token) && a.apiKeys != nil {
+		key, err := a.apiKeys.Authenticate(ctx, token)
+		if err != nil {
+			return Identity{}, err
+		}
+		return Identity{UserID: key.UserID, Method: AuthMethodAPIKey, Scopes: key.Scopes, APIKeyID: key.ID}, nil
+	}
+
+	userID, err := FetchUserIDFromToken(a.keyring, token, "")
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{UserID: userID, Method: AuthMethodJWT}, nil
+}
+
+// Refresh проверка refresh токена, возвращает пользователя для новых токенов
+func (a *Authenticator) Refresh(ctx context.Context, token string) (uuid.UUID, error) {
+	claims, err := a.parseRefresh(token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	revoked, err := a.revocations.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if revoked {
+		return uuid.Nil, ErrTokenRevoked
+	}
+	return claims.UserID, nil
+}
+
+// Revoke отзыв refresh токена до истечения его срока, недействительный токен отзывать не нужно и ошибкой не считается
+func (a *Authenticator) Revoke(ctx context.Context, token string) error {
+	claims, err := a.parseRefresh(token)
+	if err != nil {
+		return nil
+	}
+	return a.revocations.Revoke(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+// parseRefresh проверка refresh токена, без jti и срока его нельзя отозвать, такой токен не принимается
+func (a *Authenticator) parseRefresh(token string) (*Claims, error) {
+	claims, err := parseToken(a.keyring, token, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, errors.New("refresh token without id or expiration")
+	}
+	return claims, nil
+}
+
+// BuildJWTString - создание access токена
+func (a *Authenticator) BuildJWTString(userID uuid.UUID) (string, error) {
+	return BuildJWTString(a.keyring, userID)
+}
+
+// BuildRefreshString - создание refresh токена
+func (a *Authenticator) BuildRefreshString(userID uuid.UUID) (string, error) {
+	return BuildRefreshString(a.keyring, userID, a.refreshTTL)
 }
@@ -0,0 +1,112 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"golang.org/x/net/idna"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var _ domain.DomainBlocklist = &FileDomainBlocklist{}
+
+// FileDomainBlocklist - список запрещённых доменов из файла, по одному домену на строку.
+// Строки начинающиеся с # игнорируются. Файл перечитывается при изменении.
+type FileDomainBlocklist struct {
+	path    string
+	logger  zap.SugaredLogger
+	domains atomic.Pointer[map[string]struct{}]
+	modTime time.Time
+	size    int64
+}
+
+// NewFileDomainBlocklist конструктор
+func NewFileDomainBlocklist(path string, logger zap.SugaredLogger) (*FileDomainBlocklist, error) {
+	b := &FileDomainBlocklist{path: path, logger: logger}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Contains - домен или один из его родительских доменов в списке
+func (b *FileDomainBlocklist) Contains(host string) bool {
+	domains := *b.domains.Load()
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for host != "" {
+		if _, ok := domains[host]; ok {
+			return true
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return false
+}
+
+// Watch перечитывание файла при изменении, блокируется до отмены ctx
+func (b *FileDomainBlocklist) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(b.path)
+			if err != nil {
+				b.logger.Warnw("cannot stat domain blocklist", "path", b.path, "error", err)
+				continue
+			}
+			if info.ModTime().Equal(b.modTime) && info.Size() == b.size {
+				continue
+			}
+			if err = b.load(); err != nil {
+				b.logger.Warnw("cannot reload domain blocklist", "path", b.path, "error", err)
+				continue
+			}
+			b.logger.Infow("domain blocklist reloaded", "path", b.path, "domains", len(*b.domains.Load()))
+		}
+	}
+}
+
+func (b *FileDomainBlocklist) load() error {
+	file, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	domains := map[string]struct{}{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(line), "."), "*.")
+		if ascii, err := idna.Lookup.ToASCII(line); err == nil {
+			line = ascii
+		}
+		domains[line] = struct{}{}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	b.domains.Store(&domains)
+	b.modTime = info.ModTime()
+	b.size = info.Size()
+	return nil
+}
@@ -0,0 +1,34 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileDomainBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# comment\nEvil.example\n\n*.bad.example\n"), 0600))
+
+	blocklist, err := NewFileDomainBlocklist(path, CreateLogger())
+	require.NoError(t, err)
+
+	require.True(t, blocklist.Contains("evil.example"))
+	require.True(t, blocklist.Contains("www.evil.example"))
+	require.True(t, blocklist.Contains("a.bad.example"))
+	require.False(t, blocklist.Contains("example"))
+	require.False(t, blocklist.Contains("good.example"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go blocklist.Watch(ctx, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(path, []byte("good.example\n"), 0600))
+	require.Eventually(t, func() bool {
+		return blocklist.Contains("good.example") && !blocklist.Contains("evil.example")
+	}, time.Second, 10*time.Millisecond)
+}
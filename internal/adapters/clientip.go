@@ -0,0 +1,117 @@
+package adapters
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	headerForwarded = http.CanonicalHeaderKey("Forwarded")
+	// DefaultClientIPHeader - заголовок прокси с ip клиента по умолчанию
+	DefaultClientIPHeader = http.CanonicalHeaderKey("X-Forwarded-For")
+)
+
+// ClientIPResolver - определение ip клиента с учётом доверенных прокси.
+// Учитывается только один заголовок, который пишет прокси, и только если запрос пришёл от доверенного прокси:
+// остальные заголовки клиент может подставить сам.
+type ClientIPResolver struct {
+	trustedProxies []*net.IPNet
+	header         string
+}
+
+// NewClientIPResolver конструктор, header - Forwarded, X-Forwarded-For, X-Real-IP или другой заголовок прокси,
+// пустой - DefaultClientIPHeader
+func NewClientIPResolver(trustedProxies []*net.IPNet, header string) *ClientIPResolver {
+	if header == "" {
+		header = DefaultClientIPHeader
+	}
+	return &ClientIPResolver{trustedProxies: trustedProxies, header: http.CanonicalHeaderKey(header)}
+}
+
+// Resolve ip клиента по адресу соединения и заголовку прокси.
+// Цепочка адресов обходится справа налево до первого недоверенного адреса.
+func (r *ClientIPResolver) Resolve(remoteAddr string, header http.Header) net.IP {
+	peer := parseHostIP(remoteAddr)
+	if peer == nil || !r.isTrusted(peer) {
+		return peer
+	}
+
+	var hops []string
+	if r.header == headerForwarded {
+		hops = forwardedFor(header.Values(headerForwarded))
+	} else {
+		hops = splitList(header.Values(r.header))
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseHostIP(hops[i])
+		if ip == nil {
+			// неизвестный или скрытый адрес - дальше цепочке доверять нельзя
+			break
+		}
+		client = ip
+		if !r.isTrusted(ip) {
+			break
+		}
+	}
+	return client
+}
+
+func (r *ClientIPResolver) isTrusted(ip net.IP) bool {
+	for _, n := range r.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor значения for= из заголовков Forwarded
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, element := range splitList(values) {
+		for _, pair := range strings.Split(element, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(name, "for") {
+				hops = append(hops, strings.Trim(value, `"`))
+			}
+		}
+	}
+	return hops
+}
+
+func splitList(values []string) []string {
+	var res []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				res = append(res, item)
+			}
+		}
+	}
+	return res
+}
+
+// parseHostIP разбор адреса вида ip, ip:port, [ipv6] или [ipv6]:port
+func parseHostIP(s string) net.IP {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	return net.ParseIP(strings.Trim(s, "[]"))
+}
+
+type clientIPContext struct{}
+
+// ClientIPToCtx - добавление ip клиента в контекст
+func ClientIPToCtx(ctx context.Context, ip net.IP) context.Context {
+	return context.WithValue(ctx, &clientIPContext{}, ip)
+}
+
+// ClientIPFromCtx - ip клиента из контекста, nil если не определён
+func ClientIPFromCtx(ctx context.Context) net.IP {
+	ip, _ := ctx.Value(&clientIPContext{}).(net.IP)
+	return ip
+}
@@ -0,0 +1,97 @@
+package adapters
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIPResolver_Resolve(t *testing.T) {
+	trusted := utils.Must(utils.ParseCIDRs([]string{"10.0.0.0/8", "2001:db8::/32"}))
+	tests := []struct {
+		name       string
+		remoteAddr string
+		// ipHeader - заголовок прокси, пустой - DefaultClientIPHeader
+		ipHeader string
+		header   http.Header
+		want     string
+	}{
+		{
+			name:       "no proxy",
+			remoteAddr: "203.0.113.7:5555",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "untrusted peer headers are ignored",
+			remoteAddr: "203.0.113.7:5555",
+			header:     http.Header{"X-Forwarded-For": {"1.1.1.1"}, "X-Real-Ip": {"1.1.1.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "x-forwarded-for from trusted proxy",
+			remoteAddr: "10.0.0.1:5555",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1, 203.0.113.9, 10.0.0.2"}},
+			want:       "203.0.113.9",
+		},
+		{
+			name:       "spoofed left part of x-forwarded-for is skipped",
+			remoteAddr: "10.0.0.1:5555",
+			header:     http.Header{"X-Forwarded-For": {"192.168.146.2", "203.0.113.9"}},
+			want:       "203.0.113.9",
+		},
+		{
+			name:       "all hops trusted",
+			remoteAddr: "10.0.0.1:5555",
+			header:     http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "forwarded header with ipv6",
+			remoteAddr: "[2001:db8::1]:443",
+			ipHeader:   "Forwarded",
+			header:     http.Header{"Forwarded": {`for=198.51.100.4;proto=https, for="[2001:db8::5]:8080"`}},
+			want:       "198.51.100.4",
+		},
+		{
+			name:       "forwarded obfuscated hop stops the walk",
+			remoteAddr: "10.0.0.1:5555",
+			ipHeader:   "Forwarded",
+			header:     http.Header{"Forwarded": {"for=198.51.100.4, for=_hidden, for=10.0.0.2"}},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "x-real-ip from trusted proxy",
+			remoteAddr: "10.0.0.1:5555",
+			ipHeader:   "X-Real-IP",
+			header:     http.Header{"X-Real-Ip": {"198.51.100.8"}},
+			want:       "198.51.100.8",
+		},
+		{
+			name:       "other headers are not mixed in",
+			remoteAddr: "10.0.0.1:5555",
+			header:     http.Header{"Forwarded": {"for=198.51.100.4"}, "X-Real-Ip": {"198.51.100.8"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "spoofed x-forwarded-for is ignored when proxy writes x-real-ip",
+			remoteAddr: "10.0.0.1:5555",
+			ipHeader:   "X-Real-IP",
+			header:     http.Header{"X-Forwarded-For": {"192.168.146.2"}, "X-Real-Ip": {"198.51.100.8"}},
+			want:       "198.51.100.8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.header {
+				for _, i := range v {
+					header.Add(k, i)
+				}
+			}
+			resolver := NewClientIPResolver(trusted, tt.ipHeader)
+			require.Equal(t, tt.want, resolver.Resolve(tt.remoteAddr, header).String())
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package adapters
+
+import (
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"net"
+)
+
+var _ domain.GeoIP = &MaxMindGeoIP{}
+
+// MaxMindGeoIP - определение страны по локальной базе MaxMind или совместимой в формате mmdb
+type MaxMindGeoIP struct {
+	reader *maxminddb.Reader
+}
+
+// NewMaxMindGeoIP конструктор, path - файл базы GeoLite2-Country, GeoIP2-Country или GeoLite2-City
+func NewMaxMindGeoIP(path string) (*MaxMindGeoIP, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &MaxMindGeoIP{reader: reader}, nil
+}
+
+// Country код страны ISO 3166-1 alpha-2, ip вне базы - пустая строка
+func (g *MaxMindGeoIP) Country(ip net.IP) (string, error) {
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+	if err := g.reader.Lookup(ip, &record); err != nil {
+		return "", err
+	}
+	return record.Country.ISOCode, nil
+}
+
+// Close закрыть файл базы
+func (g *MaxMindGeoIP) Close() error {
+	return g.reader.Close()
+}
@@ -0,0 +1,105 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"sync"
+)
+
+var _ domain.ImportJobRepository = &memImportJobRepository{}
+
+// хранение задач импорта в памяти
+type memImportJobRepository struct {
+	jobs map[uuid.UUID]domain.ImportJob
+	mx   sync.Mutex
+}
+
+// NewMemImportJobRepository - конструктор
+func NewMemImportJobRepository() domain.ImportJobRepository {
+	return &memImportJobRepository{jobs: map[uuid.UUID]domain.ImportJob{}}
+}
+
+// Create сохранение новой задачи
+func (m *memImportJobRepository) Create(ctx context.Context, job domain.ImportJob) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.jobs[job.ID] = job
+	return nil
+}
+
+// Get задача по идентификатору
+func (m *memImportJobRepository) Get(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, domain.ErrImportJobNotFound
+	}
+	return &job, nil
+}
+
+// Update сохранение состояния задачи
+func (m *memImportJobRepository) Update(ctx context.Context, job domain.ImportJob) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if _, ok := m.jobs[job.ID]; !ok {
+		return domain.ErrImportJobNotFound
+	}
+	m.jobs[job.ID] = job
+	return nil
+}
+
+var _ domain.ImportJobRepository = &PgImportJobRepository{}
+
+// PgImportJobRepository - хранение задач импорта в postgres
+type PgImportJobRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgImportJobRepository - конструктор
+func NewPgImportJobRepository(pool *pgxpool.Pool) *PgImportJobRepository {
+	return &PgImportJobRepository{pool: pool}
+}
+
+// Create сохранение новой задачи
+func (r *PgImportJobRepository) Create(ctx context.Context, job domain.ImportJob) error {
+	_, err := r.pool.Exec(ctx, `INSERT INTO import_jobs (id, user_id, workspace_id, status, processed, created, conflicts, invalid, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		job.ID, job.UserID, nullUUID(job.WorkspaceID), job.Status, job.Processed, job.Created, job.Conflicts, job.Invalid, job.CreatedAt, job.UpdatedAt)
+	return err
+}
+
+// Get задача по идентификатору
+func (r *PgImportJobRepository) Get(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error) {
+	job := &domain.ImportJob{}
+	var workspaceID uuid.NullUUID
+	err := r.pool.QueryRow(ctx, `SELECT id, user_id, workspace_id, status, processed, created, conflicts, invalid, created_at, updated_at
+FROM import_jobs WHERE id = $1`, id).
+		Scan(&job.ID, &job.UserID, &workspaceID, &job.Status, &job.Processed, &job.Created, &job.Conflicts, &job.Invalid, &job.CreatedAt, &job.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrImportJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.WorkspaceID = workspaceID.UUID
+	return job, nil
+}
+
+// Update сохранение состояния задачи
+func (r *PgImportJobRepository) Update(ctx context.Context, job domain.ImportJob) error {
+	// внутри транзакции PgTransactor задача обновляется вместе с сохранением порции
+	res, err := pgConnFromCtx(ctx, r.pool).Exec(ctx, `UPDATE import_jobs SET status = $2, processed = $3, created = $4, conflicts = $5, invalid = $6, updated_at = $7
+WHERE id = $1`, job.ID, job.Status, job.Processed, job.Created, job.Conflicts, job.Invalid, job.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrImportJobNotFound
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package adapters
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"time"
+)
+
+// TokenTypeRefresh - тип refresh токена, у access токена тип пустой
+const TokenTypeRefresh = "refresh"
+
+// ErrWrongTokenType - ошибка токен другого типа
+var ErrWrongTokenType = errors.New("wrong token type")
+
+// ErrTokenRevoked - ошибка refresh токен отозван при выходе
+var ErrTokenRevoked = errors.New("token revoked")
+
+// Claims - payload jwt токена
+type Claims struct {
+	jwt.RegisteredClaims
+	UserID uuid.UUID `json:"user_id,omitempty"`
+	Type   string    `json:"token_type,omitempty"`
+}
+
+// JwtTTL - время жизни токена по умолчанию
+const JwtTTL = 15 * time.Minute
+
+// JwtRefreshTTL - время жизни refresh токена по умолчанию
+const JwtRefreshTTL = 30 * 24 * time.Hour
+
+// BuildJWTString - создание access токена
+func BuildJWTString(keyring *Keyring, userID uuid.UUID) (string, error) {
+	return keyring.Sign(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			// когда истекает токен
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(JwtTTL)),
+		},
+		// собственное утверждение
+		UserID: userID,
+	})
+}
+
+// BuildRefreshString - создание refresh токена, по нему выдаются новые access токены для того же пользователя
+func BuildRefreshString(keyring *Keyring, userID uuid.UUID, ttl time.Duration) (string, error) {
+	return keyring.Sign(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+		},
+		UserID: userID,
+		Type:   TokenTypeRefresh,
+	})
+}
+
+// FetchUserIDFromToken - проверка токена нужного типа и получение пользователя
+func FetchUserIDFromToken(keyring *Keyring, tokenStr string, tokenType string) (uuid.UUID, error) {
+	claims, err := parseToken(keyring, tokenStr, tokenType)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return claims.UserID, nil
+}
+
+// parseToken проверка токена нужного типа
+func parseToken(keyring *Keyring, tokenStr string, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	if err := keyring.Parse(tokenStr, claims); err != nil {
+		return nil, err
+	}
+	if claims.Type != tokenType {
+		return nil, ErrWrongTokenType
+	}
+	return claims, nil
+}
@@ -0,0 +1,261 @@
+package adapters
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sashaaro/url-shortener/internal"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultKeyID - идентификатор ключа из JWT_SECRET, им же проверяются старые токены без kid
+const DefaultKeyID = "default"
+
+// SigningKey - ключ подписи jwt
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	SignKey   any
+	VerifyKey any
+	// NotAfter - после этого момента токены с ключом не принимаются, нулевое значение - бессрочно
+	NotAfter time.Time
+}
+
+// NewHMACKey ключ HS256
+func NewHMACKey(id string, secret []byte) *SigningKey {
+	return &SigningKey{ID: id, Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret}
+}
+
+// GenerateHMACKey ключ HS256 со случайным секретом.
+// kid - начало хеша секрета, сам секрет в заголовке токена не раскрывается.
+func GenerateHMACKey() (*SigningKey, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(secret)
+	return NewHMACKey(hex.EncodeToString(sum[:8]), secret), nil
+}
+
+// ParsePEMKey разбор приватного ключа RSA (RS256) или Ed25519 (EdDSA) в формате PEM
+func ParsePEMKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported pem block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, SignKey: k, VerifyKey: &k.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, SignKey: k, VerifyKey: k.Public()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+// Keyring - набор ключей подписи jwt, выбираемых по kid.
+// Новые токены подписываются активным ключом, старые ключи остаются для проверки до NotAfter.
+type Keyring struct {
+	keys   map[string]*SigningKey
+	active string
+	mx     sync.RWMutex
+	now    func() time.Time
+}
+
+// NewKeyring конструктор, первый ключ становится активным
+func NewKeyring(active *SigningKey) *Keyring {
+	return &Keyring{
+		keys:   map[string]*SigningKey{active.ID: active},
+		active: active.ID,
+		now:    time.Now,
+	}
+}
+
+// Rotate замена активного ключа, предыдущий принимается ещё grace
+func (k *Keyring) Rotate(key *SigningKey, grace time.Duration) {
+	k.mx.Lock()
+	defer k.mx.Unlock()
+
+	now := k.now()
+	if prev, ok := k.keys[k.active]; ok && prev.ID != key.ID {
+		prev.NotAfter = now.Add(grace)
+	}
+	k.keys[key.ID] = key
+	k.active = key.ID
+
+	for id, old := range k.keys {
+		if !old.NotAfter.IsZero() && now.After(old.NotAfter) {
+			delete(k.keys, id)
+		}
+	}
+}
+
+// Sign подпись токена активным ключом
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	k.mx.RLock()
+	key := k.keys[k.active]
+	k.mx.RUnlock()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.SignKey)
+}
+
+// Parse проверка подписи токена ключом из kid
+func (k *Keyring) Parse(tokenStr string, claims jwt.Claims) error {
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			kid = DefaultKeyID
+		}
+
+		k.mx.RLock()
+		key, ok := k.keys[kid]
+		var notAfter time.Time
+		if ok {
+			notAfter = key.NotAfter
+		}
+		k.mx.RUnlock()
+
+		if !ok || (!notAfter.IsZero() && k.now().After(notAfter)) {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+		}
+		return key.VerifyKey, nil
+	})
+	return err
+}
+
+// RotateEvery периодическая смена активного ключа, блокируется до отмены ctx
+func (k *Keyring) RotateEvery(ctx context.Context, interval time.Duration, grace time.Duration, next func() (*SigningKey, error), logger zap.SugaredLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			key, err := next()
+			if err != nil {
+				logger.Errorw("cannot rotate jwt signing key", "error", err)
+				continue
+			}
+			if key == nil {
+				continue
+			}
+			k.Rotate(key, grace)
+			logger.Infow("jwt signing key rotated", "kid", key.ID)
+		}
+	}
+}
+
+// activeID идентификатор активного ключа
+func (k *Keyring) activeID() string {
+	k.mx.RLock()
+	defer k.mx.RUnlock()
+	return k.active
+}
+
+// loadNewestPEMKey самый новый ключ *.pem из каталога, имя файла без расширения становится kid
+func loadNewestPEMKey(dir string) (*SigningKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var newest os.DirEntry
+	var newestTime time.Time
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".pem" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		if newest == nil || info.ModTime().After(newestTime) {
+			newest, newestTime = e, info.ModTime()
+		}
+	}
+	if newest == nil {
+		return nil, fmt.Errorf("no pem keys in %s", dir)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, newest.Name()))
+	if err != nil {
+		return nil, err
+	}
+	return ParsePEMKey(strings.TrimSuffix(newest.Name(), ".pem"), data)
+}
+
+// CreateKeyring создание набора ключей из конфига.
+// Если задан JWT_KEYS_DIR - активным становится самый новый PEM ключ каталога, каталог перечитывается раз в JWT_ROTATION_INTERVAL.
+// Иначе используется HS256 с JWT_SECRET, а при заданном JWT_ROTATION_INTERVAL ключи генерируются случайно
+// в памяти процесса. Токены такого ключа не проверяются другими репликами, поэтому в production
+// ротация без JWT_KEYS_DIR запрещена.
+func CreateKeyring(ctx context.Context, logger zap.SugaredLogger) (*Keyring, error) {
+	cfg := internal.Config
+	if cfg.JwtKeysDir == "" {
+		keyring := NewKeyring(NewHMACKey(DefaultKeyID, []byte(cfg.JwtSecret)))
+		if cfg.JwtRotationInterval > 0 {
+			if cfg.IsProduction() {
+				return nil, errors.New("JWT_ROTATION_INTERVAL requires JWT_KEYS_DIR in production: random keys are not shared between replicas")
+			}
+			logger.Warnw("jwt keys rotate in memory without JWT_KEYS_DIR, tokens are valid only on this instance")
+			go keyring.RotateEvery(ctx, cfg.JwtRotationInterval, cfg.JwtKeyGrace, GenerateHMACKey, logger)
+		}
+		return keyring, nil
+	}
+
+	key, err := loadNewestPEMKey(cfg.JwtKeysDir)
+	if err != nil {
+		return nil, err
+	}
+	keyring := NewKeyring(key)
+	// старые токены без kid, подписанные JWT_SECRET, принимаются до истечения grace.
+	// Секретом по умолчанию любой может подписать токен сам, с ним старые токены не принимаются.
+	if cfg.JwtSecret != "" && cfg.JwtSecret != internal.DefaultJwtSecret {
+		legacy := NewHMACKey(DefaultKeyID, []byte(cfg.JwtSecret))
+		legacy.NotAfter = time.Now().Add(cfg.JwtKeyGrace)
+		keyring.keys[legacy.ID] = legacy
+	}
+	if cfg.JwtRotationInterval > 0 {
+		go keyring.RotateEvery(ctx, cfg.JwtRotationInterval, cfg.JwtKeyGrace, func() (*SigningKey, error) {
+			key, err := loadNewestPEMKey(cfg.JwtKeysDir)
+			if err != nil || key.ID == keyring.activeID() {
+				return nil, err
+			}
+			return key, nil
+		}, logger)
+	}
+	return keyring, nil
+}
@@ -0,0 +1,143 @@
+package adapters
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("legacy token without kid is verified by default key", func(t *testing.T) {
+		keyring := NewKeyring(NewHMACKey(DefaultKeyID, []byte("secret")))
+		legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{UserID: userID}).SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		got, err := FetchUserIDFromToken(keyring, legacy, "")
+		require.NoError(t, err)
+		require.Equal(t, userID, got)
+	})
+
+	t.Run("rotation keeps old keys valid during grace", func(t *testing.T) {
+		now := time.Now()
+		keyring := NewKeyring(NewHMACKey("old", []byte("old secret")))
+		keyring.now = func() time.Time { return now }
+
+		oldToken, err := BuildJWTString(keyring, userID)
+		require.NoError(t, err)
+
+		keyring.Rotate(NewHMACKey("new", []byte("new secret")), time.Hour)
+		newToken, err := BuildJWTString(keyring, userID)
+		require.NoError(t, err)
+
+		token, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+		require.NoError(t, err)
+		require.Equal(t, "new", token.Header["kid"])
+
+		_, err = FetchUserIDFromToken(keyring, oldToken, "")
+		require.NoError(t, err)
+
+		now = now.Add(2 * time.Hour)
+		_, err = FetchUserIDFromToken(keyring, oldToken, "")
+		require.Error(t, err)
+		_, err = FetchUserIDFromToken(keyring, newToken, "")
+		require.NoError(t, err)
+	})
+
+	t.Run("generated key id does not reveal the secret", func(t *testing.T) {
+		key, err := GenerateHMACKey()
+		require.NoError(t, err)
+		require.NotContains(t, key.ID, hex.EncodeToString(key.SignKey.([]byte)[:4]))
+	})
+
+	t.Run("in-memory rotation is refused in production", func(t *testing.T) {
+		cfg := internal.Config
+		defer func() { internal.Config = cfg }()
+		internal.Config.JwtKeysDir = ""
+		internal.Config.JwtRotationInterval = time.Hour
+		internal.Config.AppEnv = "production"
+
+		_, err := CreateKeyring(context.Background(), CreateLogger())
+		require.Error(t, err)
+	})
+
+	t.Run("refresh token is not an access token", func(t *testing.T) {
+		keyring := NewKeyring(NewHMACKey(DefaultKeyID, []byte("secret")))
+		refresh, err := BuildRefreshString(keyring, userID, time.Hour)
+		require.NoError(t, err)
+
+		_, err = FetchUserIDFromToken(keyring, refresh, "")
+		require.ErrorIs(t, err, ErrWrongTokenType)
+
+		got, err := FetchUserIDFromToken(keyring, refresh, TokenTypeRefresh)
+		require.NoError(t, err)
+		require.Equal(t, userID, got)
+	})
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	for name, block := range map[string]*pem.Block{
+		"RS256": {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)},
+		"EdDSA": {Type: "PRIVATE KEY", Bytes: edDER},
+	} {
+		t.Run(name+" pem key", func(t *testing.T) {
+			key, err := ParsePEMKey("k1", pem.EncodeToMemory(block))
+			require.NoError(t, err)
+			require.Equal(t, name, key.Method.Alg())
+
+			keyring := NewKeyring(key)
+			token, err := BuildJWTString(keyring, userID)
+			require.NoError(t, err)
+
+			got, err := FetchUserIDFromToken(keyring, token, "")
+			require.NoError(t, err)
+			require.Equal(t, userID, got)
+
+			// токен с подменённым алгоритмом не принимается
+			forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{UserID: userID}).SignedString([]byte("k1"))
+			require.NoError(t, err)
+			_, err = FetchUserIDFromToken(keyring, forged, "")
+			require.Error(t, err)
+		})
+	}
+	t.Run("keys dir accepts legacy tokens only for an explicit secret", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "k1.pem"), pem.EncodeToMemory(&pem.Block{
+			Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+		}), 0o600))
+		cfg := internal.Config
+		defer func() { internal.Config = cfg }()
+		internal.Config.JwtKeysDir = dir
+		internal.Config.JwtKeyGrace = time.Hour
+
+		for secret, accepted := range map[string]bool{internal.DefaultJwtSecret: false, "": false, "old secret": true} {
+			internal.Config.JwtSecret = secret
+			keyring, err := CreateKeyring(context.Background(), CreateLogger())
+			require.NoError(t, err)
+
+			legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{UserID: userID}).SignedString([]byte(secret))
+			require.NoError(t, err)
+			_, err = FetchUserIDFromToken(keyring, legacy, "")
+			require.Equal(t, accepted, err == nil, "secret %q", secret)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package adapters
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"slices"
+	"sync"
+)
+
+var _ domain.DomainRepository = &memDomainRepository{}
+
+// хранение разрешений на брендовые домены в памяти
+type memDomainRepository struct {
+	grants map[uuid.UUID]map[string]domain.DomainGrant
+	mx     sync.Mutex
+}
+
+// NewMemDomainRepository - конструктор
+func NewMemDomainRepository() domain.DomainRepository {
+	return &memDomainRepository{grants: map[uuid.UUID]map[string]domain.DomainGrant{}}
+}
+
+// Grant разрешение
+func (m *memDomainRepository) Grant(ctx context.Context, grant domain.DomainGrant) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if m.grants[grant.UserID] == nil {
+		m.grants[grant.UserID] = map[string]domain.DomainGrant{}
+	}
+	m.grants[grant.UserID][grant.Domain] = grant
+	return nil
+}
+
+// Revoke отзыв разрешения
+func (m *memDomainRepository) Revoke(ctx context.Context, host string, userID uuid.UUID) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	delete(m.grants[userID], host)
+	return nil
+}
+
+// IsAllowed есть ли разрешение
+func (m *memDomainRepository) IsAllowed(ctx context.Context, host string, userID uuid.UUID) (bool, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	_, ok := m.grants[userID][host]
+	return ok, nil
+}
+
+// ListByUser домены пользователя по алфавиту
+func (m *memDomainRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	hosts := make([]string, 0, len(m.grants[userID]))
+	for host := range m.grants[userID] {
+		hosts = append(hosts, host)
+	}
+	slices.Sort(hosts)
+	return hosts, nil
+}
+
+var _ domain.DomainRepository = &PgDomainRepository{}
+
+// PgDomainRepository - хранение разрешений на брендовые домены в postgres
+type PgDomainRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgDomainRepository - конструктор
+func NewPgDomainRepository(pool *pgxpool.Pool) *PgDomainRepository {
+	return &PgDomainRepository{pool: pool}
+}
+
+// Grant разрешение
+func (r *PgDomainRepository) Grant(ctx context.Context, grant domain.DomainGrant) error {
+	_, err := r.pool.Exec(ctx, `INSERT INTO domain_users (domain, user_id, granted_by, created_at) VALUES ($1, $2, $3, $4)
+ON CONFLICT (domain, user_id) DO UPDATE SET granted_by = EXCLUDED.granted_by, created_at = EXCLUDED.created_at`,
+		grant.Domain, grant.UserID, grant.GrantedBy, grant.CreatedAt)
+	return err
+}
+
+// Revoke отзыв разрешения
+func (r *PgDomainRepository) Revoke(ctx context.Context, host string, userID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, "DELETE FROM domain_users WHERE domain = $1 AND user_id = $2", host, userID)
+	return err
+}
+
+// IsAllowed есть ли разрешение
+func (r *PgDomainRepository) IsAllowed(ctx context.Context, host string, userID uuid.UUID) (bool, error) {
+	var allowed bool
+	err := r.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM domain_users WHERE domain = $1 AND user_id = $2)", host, userID).Scan(&allowed)
+	return allowed, err
+}
+
+// ListByUser домены пользователя по алфавиту
+func (r *PgDomainRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	rows, err := r.pool.Query(ctx, "SELECT domain FROM domain_users WHERE user_id = $1 ORDER BY domain", userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
@@ -0,0 +1,408 @@
+package adapters
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenTypeOIDCState - тип токена с состоянием входа через OIDC
+const TokenTypeOIDCState = "oidc_state"
+
+// OIDCStateTTL - сколько ждём возврата пользователя от провайдера
+const OIDCStateTTL = 10 * time.Minute
+
+// jwksMinRefresh - не чаще этого перезапрашиваем ключи провайдера при неизвестном kid
+const jwksMinRefresh = 10 * time.Second
+
+// ErrInvalidOIDCState - ошибка состояние входа не совпадает или истекло
+var ErrInvalidOIDCState = errors.New("invalid oidc state")
+
+// OIDCConfig - настройки OpenID Connect провайдера
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes - запрашиваемые scope, openid добавляется всегда
+	Scopes     []string
+	HTTPClient *http.Client
+}
+
+// OIDCLogin - результат успешного входа через провайдер
+type OIDCLogin struct {
+	User domain.ExternalUser
+	// Redirect - куда вернуть пользователя после входа
+	Redirect string
+	// ClaimLinks - перенести ссылки анонимной сессии
+	ClaimLinks bool
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// oidcStateClaims - состояние входа, хранится в подписанной cookie до возврата от провайдера
+type oidcStateClaims struct {
+	jwt.RegisteredClaims
+	Type       string `json:"token_type"`
+	State      string `json:"state"`
+	Nonce      string `json:"nonce"`
+	Verifier   string `json:"verifier"`
+	Redirect   string `json:"redirect,omitempty"`
+	ClaimLinks bool   `json:"claim_links,omitempty"`
+}
+
+// idTokenClaims - payload id токена
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string   `json:"nonce"`
+	AuthorizedBy  string   `json:"azp"`
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+}
+
+// flexBool - некоторые провайдеры отдают email_verified строкой
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	*b = s == "true"
+	return nil
+}
+
+// OIDCProvider - вход по authorization code с PKCE
+type OIDCProvider struct {
+	cfg       OIDCConfig
+	discovery oidcDiscovery
+	keyring   *Keyring
+
+	mx        sync.Mutex
+	keys      map[string]any
+	keysFetch time.Time
+}
+
+// NewOIDCProvider конструктор, загружает discovery документ провайдера
+func NewOIDCProvider(ctx context.Context, cfg OIDCConfig, keyring *Keyring) (*OIDCProvider, error) {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if !slices.Contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+
+	p := &OIDCProvider{cfg: cfg, keyring: keyring}
+	wellKnown := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &p.discovery); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if p.discovery.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", p.discovery.Issuer, cfg.Issuer)
+	}
+	if p.discovery.AuthorizationEndpoint == "" || p.discovery.TokenEndpoint == "" || p.discovery.JwksURI == "" {
+		return nil, errors.New("oidc discovery: incomplete provider metadata")
+	}
+	return p, nil
+}
+
+// AuthCodeURL начало входа: адрес провайдера и подписанное состояние для cookie
+func (p *OIDCProvider) AuthCodeURL(redirect string, claimLinks bool) (string, string, error) {
+	state, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	stateToken, err := p.keyring.Sign(oidcStateClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(OIDCStateTTL)),
+		},
+		Type:       TokenTypeOIDCState,
+		State:      state,
+		Nonce:      nonce,
+		Verifier:   verifier,
+		Redirect:   redirect,
+		ClaimLinks: claimLinks,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+
+	authURL := p.discovery.AuthorizationEndpoint
+	if strings.Contains(authURL, "?") {
+		authURL += "&" + q.Encode()
+	} else {
+		authURL += "?" + q.Encode()
+	}
+	return authURL, stateToken, nil
+}
+
+// Exchange завершение входа: проверка состояния, обмен кода на id токен и его проверка
+func (p *OIDCProvider) Exchange(ctx context.Context, stateToken string, state string, code string) (*OIDCLogin, error) {
+	claims := &oidcStateClaims{}
+	if err := p.keyring.Parse(stateToken, claims); err != nil || claims.Type != TokenTypeOIDCState {
+		return nil, ErrInvalidOIDCState
+	}
+	if state == "" || state != claims.State {
+		return nil, ErrInvalidOIDCState
+	}
+
+	rawIDToken, err := p.exchangeCode(ctx, code, claims.Verifier)
+	if err != nil {
+		return nil, err
+	}
+	user, err := p.VerifyIDToken(ctx, rawIDToken, claims.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCLogin{User: *user, Redirect: claims.Redirect, ClaimLinks: claims.ClaimLinks}, nil
+}
+
+func (p *OIDCProvider) exchangeCode(ctx context.Context, code string, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("oidc token endpoint: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("oidc token endpoint: %d %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("oidc token endpoint: no id_token in response")
+	}
+	return body.IDToken, nil
+}
+
+// VerifyIDToken проверка подписи по JWKS провайдера, issuer, audience, срока и nonce
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*domain.ExternalUser, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.verifyKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("invalid id token: nonce mismatch")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedBy != p.cfg.ClientID {
+		return nil, errors.New("invalid id token: azp mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id token: empty subject")
+	}
+
+	return &domain.ExternalUser{
+		Issuer:        p.cfg.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+	}, nil
+}
+
+// verifyKey ключ провайдера по kid, при неизвестном kid ключи перезапрашиваются
+func (p *OIDCProvider) verifyKey(ctx context.Context, kid string) (any, error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if !p.keysFetch.IsZero() && time.Since(p.keysFetch) < jwksMinRefresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.discovery.JwksURI, &jwks); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+	p.keysFetch = time.Now()
+	p.keys = map[string]any{}
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// ключи неизвестных типов пропускаем
+			continue
+		}
+		p.keys[k.Kid] = key
+	}
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey без kid подходит только единственный ключ
+func (p *OIDCProvider) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, u)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// jsonWebKey - открытый ключ из JWKS
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
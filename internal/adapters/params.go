@@ -0,0 +1,68 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"sync"
+)
+
+var _ domain.DefaultParamsRepository = &memDefaultParamsRepository{}
+
+// хранение параметров по умолчанию в памяти
+type memDefaultParamsRepository struct {
+	params map[uuid.UUID]domain.LinkParams
+	mx     sync.Mutex
+}
+
+// NewMemDefaultParamsRepository - конструктор
+func NewMemDefaultParamsRepository() domain.DefaultParamsRepository {
+	return &memDefaultParamsRepository{params: map[uuid.UUID]domain.LinkParams{}}
+}
+
+// GetDefaultParams параметры пользователя
+func (m *memDefaultParamsRepository) GetDefaultParams(ctx context.Context, userID uuid.UUID) (domain.LinkParams, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.params[userID], nil
+}
+
+// SetDefaultParams замена параметров пользователя
+func (m *memDefaultParamsRepository) SetDefaultParams(ctx context.Context, userID uuid.UUID, params domain.LinkParams) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.params[userID] = params
+	return nil
+}
+
+var _ domain.DefaultParamsRepository = &PgDefaultParamsRepository{}
+
+// PgDefaultParamsRepository - хранение параметров по умолчанию в postgres
+type PgDefaultParamsRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgDefaultParamsRepository - конструктор
+func NewPgDefaultParamsRepository(pool *pgxpool.Pool) *PgDefaultParamsRepository {
+	return &PgDefaultParamsRepository{pool: pool}
+}
+
+// GetDefaultParams параметры пользователя
+func (r *PgDefaultParamsRepository) GetDefaultParams(ctx context.Context, userID uuid.UUID) (domain.LinkParams, error) {
+	var params domain.LinkParams
+	err := r.pool.QueryRow(ctx, "SELECT params FROM user_default_params WHERE user_id = $1", userID).Scan(&params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.LinkParams{}, nil
+	}
+	return params, err
+}
+
+// SetDefaultParams замена параметров пользователя
+func (r *PgDefaultParamsRepository) SetDefaultParams(ctx context.Context, userID uuid.UUID, params domain.LinkParams) error {
+	_, err := r.pool.Exec(ctx, `INSERT INTO user_default_params (user_id, params) VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET params = EXCLUDED.params`, userID, params)
+	return err
+}
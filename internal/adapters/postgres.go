@@ -3,6 +3,7 @@ package adapters
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
@@ -10,6 +11,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sashaaro/url-shortener/internal/domain"
 	"net/url"
+	"strings"
 )
 
 var _ domain.URLRepository = &PgURLRepository{}
@@ -19,9 +21,14 @@ type PgURLRepository struct {
 	pool *pgxpool.Pool
 }
 
+// conn транзакция из контекста или пул, см. PgTransactor
+func (r *PgURLRepository) conn(ctx context.Context) pgConn {
+	return pgConnFromCtx(ctx, r.pool)
+}
+
 // CountUrls количество ссылок
 func (r *PgURLRepository) CountUrls(ctx context.Context) (int64, error) {
-	row := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM urls")
+	row := r.conn(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM urls")
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -29,22 +36,330 @@ func (r *PgURLRepository) CountUrls(ctx context.Context) (int64, error) {
 
 // CountUsers количество пользователей
 func (r *PgURLRepository) CountUsers(ctx context.Context) (int64, error) {
-	row := r.pool.QueryRow(ctx, "SELECT COUNT(DISTINCT user_id) FROM urls")
+	row := r.conn(ctx).QueryRow(ctx, "SELECT COUNT(DISTINCT user_id) FROM urls")
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
-// DeleteByUser -удаление
-func (r *PgURLRepository) DeleteByUser(ctx context.Context, keys []domain.HashKey, userID uuid.UUID) (bool, error) {
-	res, err := r.pool.Exec(ctx, "UPDATE urls SET is_deleted = true WHERE key = ANY($1)", keys)
+// DeleteByKeys -удаление
+func (r *PgURLRepository) DeleteByKeys(ctx context.Context, keys []domain.HashKey) (bool, error) {
+	res, err := r.conn(ctx).Exec(ctx, "UPDATE urls SET is_deleted = true WHERE domain = $1 AND key = ANY($2)", domain.LinkDomainFromCtx(ctx), keys)
 
 	return res.RowsAffected() == int64(len(keys)), err
 }
 
+// urlHostSQL - хост ссылки из колонки url
+const urlHostSQL = `lower(substring(url from '^[^:]+://(?:[^@/]*@)?([^/:?#]+)'))`
+
+// DeleteByDomain удаление ссылок на домен и поддомены
+func (r *PgURLRepository) DeleteByDomain(ctx context.Context, host string) ([]domain.Link, error) {
+	rows, err := r.conn(ctx).Query(ctx, "UPDATE urls SET is_deleted = true WHERE NOT is_deleted AND ("+urlHostSQL+" = $1 OR "+urlHostSQL+" LIKE '%.' || $1) RETURNING key, domain, url, user_id, workspace_id, false, is_disabled, expires_at, link_title, note, tags, redirect_type, pass_query, title, description, interstitial, rules, variants, params, created_at", host)
+	if err != nil {
+		return nil, err
+	}
+	return collectLinks(rows)
+}
+
+const linkColumns = "key, domain, url, user_id, workspace_id, is_deleted, is_disabled, expires_at, link_title, note, tags, redirect_type, pass_query, title, description, interstitial, rules, variants, params, created_at"
+
+func collectLinks(rows pgx.Rows) ([]domain.Link, error) {
+	defer rows.Close()
+	links := []domain.Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+	return links, rows.Err()
+}
+
+func scanLink(row pgx.Row) (*domain.Link, error) {
+	link := &domain.Link{}
+	var workspaceID uuid.NullUUID
+	err := row.Scan(
+		&link.Key, &link.Domain, &link.OriginalURL, &link.UserID, &workspaceID, &link.IsDeleted, &link.IsDisabled, &link.ExpiresAt, &link.Title, &link.Note, &link.Tags,
+		&link.Redirect.Type, &link.Redirect.PassQuery, &link.Preview.Title, &link.Preview.Description, &link.Preview.Interstitial, &link.Rules, &link.Variants, &link.Params, &link.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrURLNotFound
+	}
+	link.WorkspaceID = workspaceID.UUID
+	link.ShortURL = CreatePublicURL(link.Domain, link.Key)
+	return link, err
+}
+
+// GetLink ссылка с владельцем
+func (r *PgURLRepository) GetLink(ctx context.Context, key domain.HashKey) (*domain.Link, error) {
+	return scanLink(r.conn(ctx).QueryRow(ctx, "SELECT "+linkColumns+" FROM urls WHERE domain = $1 AND key = $2", domain.LinkDomainFromCtx(ctx), key))
+}
+
+// GetLinks ссылки по ключам
+func (r *PgURLRepository) GetLinks(ctx context.Context, keys []domain.HashKey) ([]domain.Link, error) {
+	rows, err := r.conn(ctx).Query(ctx, "SELECT "+linkColumns+" FROM urls WHERE domain = $1 AND key = ANY($2)", domain.LinkDomainFromCtx(ctx), keys)
+	if err != nil {
+		return nil, err
+	}
+	return collectLinks(rows)
+}
+
+// GetLinksByUser все ссылки пользователя
+func (r *PgURLRepository) GetLinksByUser(ctx context.Context, userID uuid.UUID) ([]domain.Link, error) {
+	rows, err := r.conn(ctx).Query(ctx, "SELECT "+linkColumns+" FROM urls WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+	return collectLinks(rows)
+}
+
+// SetDisabled отключение ссылки
+func (r *PgURLRepository) SetDisabled(ctx context.Context, key domain.HashKey, disabled bool) error {
+	res, err := r.conn(ctx).Exec(ctx, "UPDATE urls SET is_disabled = $3 WHERE domain = $1 AND key = $2", domain.LinkDomainFromCtx(ctx), key, disabled)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrURLNotFound
+	}
+	return nil
+}
+
+// SetRedirect замена настроек редиректа
+func (r *PgURLRepository) SetRedirect(ctx context.Context, key domain.HashKey, settings domain.RedirectSettings) error {
+	res, err := r.conn(ctx).Exec(ctx, "UPDATE urls SET redirect_type = $3, pass_query = $4 WHERE domain = $1 AND key = $2", domain.LinkDomainFromCtx(ctx), key, settings.Type, settings.PassQuery)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrURLNotFound
+	}
+	return nil
+}
+
+// SetPreview замена данных предпросмотра
+func (r *PgURLRepository) SetPreview(ctx context.Context, key domain.HashKey, preview domain.LinkPreview) error {
+	res, err := r.conn(ctx).Exec(ctx, "UPDATE urls SET title = $3, description = $4, interstitial = $5 WHERE domain = $1 AND key = $2", domain.LinkDomainFromCtx(ctx), key, preview.Title, preview.Description, preview.Interstitial)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrURLNotFound
+	}
+	return nil
+}
+
+// SetRules замена правил маршрутизации
+func (r *PgURLRepository) SetRules(ctx context.Context, key domain.HashKey, rules []domain.RouteRule) error {
+	if rules == nil {
+		rules = []domain.RouteRule{}
+	}
+	res, err := r.conn(ctx).Exec(ctx, "UPDATE urls SET rules = $3 WHERE domain = $1 AND key = $2", domain.LinkDomainFromCtx(ctx), key, rules)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrURLNotFound
+	}
+	return nil
+}
+
+// SetVariants замена вариантов A/B теста, счётчики переходов хранятся отдельно и не сбрасываются
+func (r *PgURLRepository) SetVariants(ctx context.Context, key domain.HashKey, variants []domain.Variant) error {
+	if variants == nil {
+		variants = []domain.Variant{}
+	}
+	res, err := r.conn(ctx).Exec(ctx, "UPDATE urls SET variants = $3 WHERE domain = $1 AND key = $2", domain.LinkDomainFromCtx(ctx), key, variants)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrURLNotFound
+	}
+	return nil
+}
+
+// SetParams замена параметров запроса
+func (r *PgURLRepository) SetParams(ctx context.Context, key domain.HashKey, params domain.LinkParams) error {
+	res, err := r.conn(ctx).Exec(ctx, "UPDATE urls SET params = $3 WHERE domain = $1 AND key = $2", domain.LinkDomainFromCtx(ctx), key, params)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrURLNotFound
+	}
+	return nil
+}
+
+// SetMeta замена названия, заметки и тегов
+func (r *PgURLRepository) SetMeta(ctx context.Context, key domain.HashKey, meta domain.LinkMeta) error {
+	res, err := r.conn(ctx).Exec(ctx, "UPDATE urls SET link_title = $3, note = $4, tags = $5 WHERE domain = $1 AND key = $2",
+		domain.LinkDomainFromCtx(ctx), key, meta.Title, meta.Note, meta.Tags)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrURLNotFound
+	}
+	return nil
+}
+
+// RenameTag переименование тега в ссылках владельца, повторы после переименования убираются с сохранением порядка
+func (r *PgURLRepository) RenameTag(ctx context.Context, owner domain.Owner, rename domain.TagRename) ([]domain.Link, error) {
+	owned, id := "user_id = $1 AND workspace_id IS NULL", owner.UserID
+	if owner.WorkspaceID != uuid.Nil {
+		owned, id = "workspace_id = $1", owner.WorkspaceID
+	}
+	// основной запрос не видит изменений из with и возвращает ссылки до переименования
+	rows, err := r.conn(ctx).Query(ctx, `WITH renamed AS (
+	UPDATE urls SET tags = ARRAY(
+		SELECT t FROM unnest(array_replace(tags, $2, $3)) WITH ORDINALITY AS x(t, i) GROUP BY t ORDER BY min(i)
+	) WHERE `+owned+` AND $2 = ANY(tags) RETURNING domain, key
+) SELECT `+linkColumns+` FROM urls WHERE (domain, key) IN (SELECT domain, key FROM renamed)`, id, rename.From, rename.To)
+	if err != nil {
+		return nil, err
+	}
+	return collectLinks(rows)
+}
+
+// AddVariantClick учёт перехода на вариант
+func (r *PgURLRepository) AddVariantClick(ctx context.Context, key domain.HashKey, variantID string) error {
+	_, err := r.conn(ctx).Exec(ctx, `INSERT INTO variant_clicks (domain, key, variant_id, clicks) VALUES ($1, $2, $3, 1)
+		ON CONFLICT (domain, key, variant_id) DO UPDATE SET clicks = variant_clicks.clicks + 1`, domain.LinkDomainFromCtx(ctx), key, variantID)
+	return err
+}
+
+// GetVariantClicks переходы на варианты
+func (r *PgURLRepository) GetVariantClicks(ctx context.Context, key domain.HashKey) (map[string]int64, error) {
+	rows, err := r.conn(ctx).Query(ctx, "SELECT variant_id, clicks FROM variant_clicks WHERE domain = $1 AND key = $2", domain.LinkDomainFromCtx(ctx), key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clicks := map[string]int64{}
+	for rows.Next() {
+		var id string
+		var n int64
+		if err = rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		clicks[id] = n
+	}
+	return clicks, rows.Err()
+}
+
+// GetOwners владельцы ссылок
+func (r *PgURLRepository) GetOwners(ctx context.Context, keys []domain.HashKey) (map[domain.HashKey]domain.Owner, error) {
+	rows, err := r.conn(ctx).Query(ctx, "SELECT key, user_id, workspace_id FROM urls WHERE domain = $1 AND key = ANY($2)", domain.LinkDomainFromCtx(ctx), keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	owners := make(map[domain.HashKey]domain.Owner, len(keys))
+	for rows.Next() {
+		var key string
+		var userID uuid.UUID
+		var workspaceID uuid.NullUUID
+		if err = rows.Scan(&key, &userID, &workspaceID); err != nil {
+			return nil, err
+		}
+		owners[key] = domain.Owner{UserID: userID, WorkspaceID: workspaceID.UUID}
+	}
+	return owners, rows.Err()
+}
+
+// ReassignUser передача не больше limit ссылок другому пользователю.
+// Личные ссылки на оригинал, на который у to уже есть личная ссылка, остаются у from.
+func (r *PgURLRepository) ReassignUser(ctx context.Context, from uuid.UUID, to uuid.UUID, limit int) ([]domain.Link, error) {
+	rows, err := r.conn(ctx).Query(ctx, `UPDATE urls SET user_id = $2 WHERE (domain, key) IN (
+SELECT domain, key FROM urls u WHERE user_id = $1 AND NOT (workspace_id IS NULL AND EXISTS (
+	SELECT 1 FROM urls o WHERE o.domain = u.domain AND o.url = u.url AND o.user_id = $2 AND o.workspace_id IS NULL
+)) LIMIT $3 FOR UPDATE
+) RETURNING `+linkColumns, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectLinks(rows)
+}
+
 // GetByUser получение
-func (r *PgURLRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.URLEntry, error) {
-	rows, err := r.pool.Query(ctx, "SELECT key, url FROM urls WHERE user_id = $1", userID.String())
+func (r *PgURLRepository) GetByUser(ctx context.Context, userID uuid.UUID, q domain.LinkQuery) ([]domain.URLEntry, error) {
+	return r.queryEntries(ctx, q, "user_id = $1 AND workspace_id IS NULL", userID.String())
+}
+
+// GetByWorkspace получение ссылок рабочего пространства
+func (r *PgURLRepository) GetByWorkspace(ctx context.Context, workspaceID uuid.UUID, q domain.LinkQuery) ([]domain.URLEntry, error) {
+	return r.queryEntries(ctx, q, "workspace_id = $1", workspaceID.String())
+}
+
+// Export выгрузка ссылок владельца, строки читаются из результата запроса по мере обхода
+func (r *PgURLRepository) Export(ctx context.Context, owner domain.Owner, fn func(e domain.ExportEntry) error) error {
+	owned, id := "user_id = $1 AND workspace_id IS NULL", owner.UserID
+	if owner.WorkspaceID != uuid.Nil {
+		owned, id = "workspace_id = $1", owner.WorkspaceID
+	}
+	rows, err := r.conn(ctx).Query(ctx, "SELECT key, domain, url, created_at, expires_at, is_deleted FROM urls WHERE "+owned+" ORDER BY created_at, key", id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e domain.ExportEntry
+		if err = rows.Scan(&e.Key, &e.Domain, &e.OriginalURL, &e.CreatedAt, &e.ExpiresAt, &e.IsDeleted); err != nil {
+			return err
+		}
+		e.ShortURL = CreatePublicURL(e.Domain, e.Key)
+		if err = fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// likeEscaper - экранирование спецсимволов LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// queryEntries ссылки владельца с фильтрами, сортировкой по времени создания и курсором
+func (r *PgURLRepository) queryEntries(ctx context.Context, q domain.LinkQuery, owned string, args ...any) ([]domain.URLEntry, error) {
+	where := []string{owned}
+	// arg добавление параметра запроса, возвращает его плейсхолдер
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	order, cmp := "DESC", "<"
+	if q.Ascending {
+		order, cmp = "ASC", ">"
+	}
+	if q.After != nil {
+		where = append(where, fmt.Sprintf("(created_at, key) %s (%s, %s)", cmp, arg(q.After.CreatedAt), arg(q.After.Key)))
+	}
+	if q.Domain != "" {
+		host := arg(q.Domain)
+		where = append(where, "("+urlHostSQL+" = "+host+" OR "+urlHostSQL+" LIKE '%.' || "+host+")")
+	}
+	if q.Search != "" {
+		pattern := arg("%" + likeEscaper.Replace(q.Search) + "%")
+		where = append(where, "(url ILIKE "+pattern+" OR key ILIKE "+pattern+")")
+	}
+	if len(q.Tags) > 0 {
+		where = append(where, "tags @> "+arg(q.Tags))
+	}
+	if q.Text != "" {
+		where = append(where, "search @@ to_tsquery('simple', "+arg(strings.Join(domain.SearchTerms(q.Text), " & "))+")")
+	}
+
+	sql := "SELECT key, domain, url, link_title, note, tags, created_at FROM urls WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY created_at " + order + ", key " + order
+	if q.Limit > 0 {
+		sql += " LIMIT " + arg(q.Limit)
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, sql, args...)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -54,36 +369,40 @@ func (r *PgURLRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]do
 	defer rows.Close()
 
 	urls := []domain.URLEntry{}
-	var key, u string
 	for rows.Next() {
-		if err = rows.Scan(&key, &u); err != nil {
+		var e domain.URLEntry
+		if err = rows.Scan(&e.Key, &e.Domain, &e.OriginalURL, &e.Title, &e.Note, &e.Tags, &e.CreatedAt); err != nil {
 			return nil, err
 		}
-		urls = append(urls, domain.URLEntry{
-			ShortURL:    CreatePublicURL(key),
-			OriginalURL: u,
-		})
+		e.ShortURL = CreatePublicURL(e.Domain, e.Key)
+		urls = append(urls, e)
 	}
-	return urls, nil
+	return urls, rows.Err()
 }
 
 // BatchAdd - добавление нескольких ссылок
-func (r *PgURLRepository) BatchAdd(ctx context.Context, batch []domain.BatchItem, userID uuid.UUID) error {
-	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+func (r *PgURLRepository) BatchAdd(ctx context.Context, batch []domain.BatchItem, owner domain.Owner, params domain.LinkParams) error {
+	tx, err := r.conn(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
 	// nolint:errcheck
 	defer tx.Rollback(ctx)
 
+	host := domain.LinkDomainFromCtx(ctx)
 	for _, item := range batch {
-		_, err = tx.Exec(ctx, "INSERT INTO urls (key, url, user_id) VALUES ($1, $2, $3)", item.HashKey, item.URL.String(), userID.String())
+		_, err = tx.Exec(ctx, "INSERT INTO urls (key, domain, url, user_id, workspace_id, params) VALUES ($1, $2, $3, $4, $5, $6)", item.HashKey, host, item.URL.String(), owner.UserID.String(), nullUUID(owner.WorkspaceID), params)
 		if err != nil {
 			pgErr := &pgconn.PgError{}
 			ok := errors.As(err, &pgErr)
 			if ok && pgErr.Code == pgerrcode.UniqueViolation {
+				// после ошибки транзакция не принимает запросов, внешняя транзакция - до отката точки сохранения
+				if err = tx.Rollback(ctx); err != nil {
+					return err
+				}
+				owned, id := ownedBy(owner, 3)
 				var existKey string
-				err = r.pool.QueryRow(ctx, "SELECT key FROM urls WHERE url = $1 LIMIT 1", item.URL.String()).Scan(&existKey)
+				err = r.conn(ctx).QueryRow(ctx, "SELECT key FROM urls WHERE domain = $1 AND url = $2 AND "+owned+" LIMIT 1", host, item.URL.String(), id).Scan(&existKey)
 				if err != nil {
 					return err
 				}
@@ -100,30 +419,65 @@ func (r *PgURLRepository) BatchAdd(ctx context.Context, batch []domain.BatchItem
 	return nil
 }
 
-// Add добавление ссылки
-func (r *PgURLRepository) Add(ctx context.Context, key domain.HashKey, u url.URL, userID uuid.UUID) error {
-	_, err := r.pool.Exec(ctx, "INSERT INTO urls (key, url, user_id) VALUES ($1, $2, $3)", key, u.String(), userID.String())
-	if err != nil {
-		pgErr := &pgconn.PgError{}
-		ok := errors.As(err, &pgErr)
-		if ok && pgErr.Code == pgerrcode.UniqueViolation {
-			var existKey string
-			err = r.pool.QueryRow(ctx, "SELECT key FROM urls WHERE url = $1 LIMIT 1", u.String()).Scan(&existKey)
-			if err != nil {
-				return err
-			}
-			return &domain.ErrURLAlreadyExists{HashKey: existKey}
+// Import добавление ссылок одним запросом, конфликты по ключу или ссылке пропускаются
+func (r *PgURLRepository) Import(ctx context.Context, items []domain.ImportItem, owner domain.Owner, params domain.LinkParams) ([]domain.HashKey, error) {
+	host := domain.LinkDomainFromCtx(ctx)
+	values := make([]string, 0, len(items))
+	// параметры общие для всех строк
+	args := make([]any, 0, len(items)*7+1)
+	args = append(args, params)
+	for _, item := range items {
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $1)", n+1, n+2, n+3, n+4, n+5, n+6, n+7))
+		tags := item.Tags
+		if tags == nil {
+			tags = []string{}
 		}
+		args = append(args, item.Key, host, item.URL.String(), owner.UserID, nullUUID(owner.WorkspaceID), item.ExpiresAt, tags)
 	}
+	rows, err := r.conn(ctx).Query(ctx, "INSERT INTO urls (key, domain, url, user_id, workspace_id, expires_at, tags, params) VALUES "+
+		strings.Join(values, ", ")+" ON CONFLICT DO NOTHING RETURNING key", args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[domain.HashKey])
+}
 
+// Add добавление ссылки, внутри транзакции - в точке сохранения, чтобы после конфликта найти существующую ссылку
+func (r *PgURLRepository) Add(ctx context.Context, key domain.HashKey, u url.URL, owner domain.Owner, params domain.LinkParams) error {
+	host := domain.LinkDomainFromCtx(ctx)
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// nolint:errcheck
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "INSERT INTO urls (key, domain, url, user_id, workspace_id, params) VALUES ($1, $2, $3, $4, $5, $6)", key, host, u.String(), owner.UserID.String(), nullUUID(owner.WorkspaceID), params)
+	if err == nil {
+		return tx.Commit(ctx)
+	}
+	pgErr := &pgconn.PgError{}
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		if err = tx.Rollback(ctx); err != nil {
+			return err
+		}
+		owned, id := ownedBy(owner, 3)
+		var existKey string
+		err = r.conn(ctx).QueryRow(ctx, "SELECT key FROM urls WHERE domain = $1 AND url = $2 AND "+owned+" LIMIT 1", host, u.String(), id).Scan(&existKey)
+		if err != nil {
+			return err
+		}
+		return &domain.ErrURLAlreadyExists{HashKey: existKey}
+	}
 	return err
 }
 
 // GetByHash - получение ссылки по ключу
 func (r *PgURLRepository) GetByHash(ctx context.Context, key domain.HashKey) (*url.URL, error) {
 	var res string
-	var isDeleted bool
-	err := r.pool.QueryRow(ctx, "SELECT url, is_deleted FROM urls WHERE key = $1", key).Scan(&res, &isDeleted)
+	var isDeleted, isDisabled, isExpired bool
+	err := r.conn(ctx).QueryRow(ctx, "SELECT url, is_deleted, is_disabled, expires_at <= now() IS TRUE FROM urls WHERE domain = $1 AND key = $2", domain.LinkDomainFromCtx(ctx), key).Scan(&res, &isDeleted, &isDisabled, &isExpired)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -133,9 +487,48 @@ func (r *PgURLRepository) GetByHash(ctx context.Context, key domain.HashKey) (*u
 	if isDeleted {
 		return nil, domain.ErrURLDeleted
 	}
+	if isDisabled {
+		return nil, domain.ErrURLDisabled
+	}
+	if isExpired {
+		return nil, domain.ErrURLExpired
+	}
 	return url.Parse(res)
 }
 
+// GetKeysByURLs ключи ссылок владельца по оригиналам
+func (r *PgURLRepository) GetKeysByURLs(ctx context.Context, urls []string, owner domain.Owner) (map[string]domain.HashKey, error) {
+	owned, id := ownedBy(owner, 3)
+	rows, err := r.conn(ctx).Query(ctx, "SELECT url, key FROM urls WHERE domain = $1 AND url = ANY($2) AND "+owned, domain.LinkDomainFromCtx(ctx), urls, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make(map[string]domain.HashKey, len(urls))
+	for rows.Next() {
+		var u, key string
+		if err = rows.Scan(&u, &key); err != nil {
+			return nil, err
+		}
+		keys[u] = key
+	}
+	return keys, rows.Err()
+}
+
+// ownedBy условие на ссылки владельца с идентификатором в параметре $n: личные ссылки пользователя или ссылки пространства
+func ownedBy(owner domain.Owner, n int) (string, any) {
+	if owner.WorkspaceID != uuid.Nil {
+		return fmt.Sprintf("workspace_id = $%d", n), owner.WorkspaceID
+	}
+	return fmt.Sprintf("user_id = $%d AND workspace_id IS NULL", n), owner.UserID
+}
+
+// nullUUID uuid.Nil сохраняется как NULL
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
 // NewPgURLRepository - конструктор
 func NewPgURLRepository(pool *pgxpool.Pool) *PgURLRepository {
 	repo := &PgURLRepository{
@@ -0,0 +1,94 @@
+package adapters
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/skip2/go-qrcode"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// qrRecoveryLevels - уровни коррекции ошибок библиотеки
+var qrRecoveryLevels = map[domain.QRLevel]qrcode.RecoveryLevel{
+	domain.QRLevelLow:      qrcode.Low,
+	domain.QRLevelMedium:   qrcode.Medium,
+	domain.QRLevelQuartile: qrcode.High,
+	domain.QRLevelHigh:     qrcode.Highest,
+}
+
+// RenderQRCode изображение qr кода с содержимым content. Размер модуля - целое число пикселей,
+// остаток размера распределяется по краям, поэтому изображение всегда Size на Size.
+func RenderQRCode(content string, opts domain.QROptions) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	code, err := qrcode.New(content, qrRecoveryLevels[opts.Level])
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+	bitmap := code.Bitmap()
+
+	modules := len(bitmap) + 2*opts.Margin
+	scale := opts.Size / modules
+	if scale < 1 {
+		return nil, &domain.ValidationError{Field: "size", Message: fmt.Sprintf("must be at least %d for this code and margin", modules)}
+	}
+	if opts.Format == domain.QRFormatSVG {
+		return renderQRSVG(bitmap, opts, modules), nil
+	}
+	return renderQRPNG(bitmap, opts, scale)
+}
+
+func renderQRPNG(bitmap [][]bool, opts domain.QROptions, scale int) ([]byte, error) {
+	img := image.NewPaletted(image.Rect(0, 0, opts.Size, opts.Size), color.Palette{opts.Background, opts.Foreground})
+	// код по центру, рамка не меньше Margin модулей
+	offset := (opts.Size - scale*len(bitmap)) / 2
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderQRSVG векторный код, единица координат - модуль, тёмные модули строки объединяются в прямоугольники
+func renderQRSVG(bitmap [][]bool, opts domain.QROptions, modules int) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, modules, modules, svgColor(opts.Background))
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, svgColor(opts.Foreground))
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start+opts.Margin, y+opts.Margin, x-start, x-start)
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
+
+// svgColor цвет с прозрачностью в виде rgba()
+func svgColor(c color.NRGBA) string {
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3g)", c.R, c.G, c.B, float64(c.A)/255)
+}
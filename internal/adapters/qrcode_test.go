@@ -0,0 +1,58 @@
+package adapters
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderQRCode(t *testing.T) {
+	t.Run("png", func(t *testing.T) {
+		opts := domain.DefaultQROptions()
+		opts.Size = 300
+		opts.Foreground = color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}
+		b, err := RenderQRCode("https://example.com/abc", opts)
+		require.NoError(t, err)
+
+		img, err := png.Decode(bytes.NewReader(b))
+		require.NoError(t, err)
+		require.Equal(t, 300, img.Bounds().Dx())
+		require.Equal(t, 300, img.Bounds().Dy())
+		require.Equal(t, opts.Background, color.NRGBAModel.Convert(img.At(0, 0)), "margin is background")
+		dark := 0
+		for y := 0; y < 300; y++ {
+			for x := 0; x < 300; x++ {
+				if color.NRGBAModel.Convert(img.At(x, y)) == opts.Foreground {
+					dark++
+				}
+			}
+		}
+		require.Greater(t, dark, 0, "has dark modules")
+	})
+
+	t.Run("svg", func(t *testing.T) {
+		opts := domain.DefaultQROptions()
+		opts.Format = domain.QRFormatSVG
+		opts.Margin = 0
+		b, err := RenderQRCode("https://example.com/abc", opts)
+		require.NoError(t, err)
+		svg := string(b)
+		require.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256" viewBox="0 0 25 25"`), svg[:100])
+		require.Contains(t, svg, `M0 0h7v1h-7z`, "finder pattern starts at the corner without margin")
+	})
+
+	t.Run("too small", func(t *testing.T) {
+		opts := domain.DefaultQROptions()
+		opts.Size = domain.MinQRSize
+		opts.Margin = domain.MaxQRMargin
+		_, err := RenderQRCode("https://example.com/"+strings.Repeat("a", 200), opts)
+		var validationErr *domain.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, "size", validationErr.Field)
+	})
+}
@@ -0,0 +1,136 @@
+package adapters
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"sync"
+	"time"
+)
+
+// rateLimitSweepInterval - как часто удалять полные вёдра из памяти и из postgres
+const rateLimitSweepInterval = time.Minute
+
+type memBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     domain.RateLimit
+}
+
+var _ domain.RateLimiter = &MemRateLimiter{}
+
+// MemRateLimiter - ограничение частоты запросов в памяти процесса
+type MemRateLimiter struct {
+	buckets   map[string]*memBucket
+	lastSweep time.Time
+	mx        sync.Mutex
+	now       func() time.Time
+}
+
+// NewMemRateLimiter конструктор
+func NewMemRateLimiter() *MemRateLimiter {
+	return &MemRateLimiter{
+		buckets:   map[string]*memBucket{},
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow списание токенов
+func (l *MemRateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit, cost int) (time.Duration, error) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &memBucket{tokens: float64(limit.Burst), updatedAt: now}
+		l.buckets[key] = b
+	}
+	var retryAfter time.Duration
+	b.tokens, retryAfter = limit.Take(b.tokens, now.Sub(b.updatedAt), cost)
+	b.updatedAt = now
+	b.limit = limit
+	return retryAfter, nil
+}
+
+// sweep удаление вёдер, которые успели наполниться - они ничем не отличаются от новых
+func (l *MemRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updatedAt).Seconds()*b.limit.Rate >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+var _ domain.RateLimiter = &PgRateLimiter{}
+
+// PgRateLimiter - ограничение частоты запросов в postgres, общее для нескольких реплик
+type PgRateLimiter struct {
+	pool *pgxpool.Pool
+	// refill - наибольшее время наполнения пустого ведра среди встреченных лимитов
+	refill    time.Duration
+	lastSweep time.Time
+	mx        sync.Mutex
+}
+
+// NewPgRateLimiter конструктор
+func NewPgRateLimiter(pool *pgxpool.Pool) *PgRateLimiter {
+	return &PgRateLimiter{pool: pool, lastSweep: time.Now()}
+}
+
+// Allow списание токенов
+func (l *PgRateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit, cost int) (time.Duration, error) {
+	tx, err := l.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	// nolint:errcheck
+	defer tx.Rollback(ctx)
+
+	// ON CONFLICT DO UPDATE блокирует строку до конца транзакции и возвращает текущее состояние ведра
+	var tokens, elapsed float64
+	err = tx.QueryRow(ctx, `INSERT INTO rate_limits (key, tokens, updated_at) VALUES ($1, $2, now())
+ON CONFLICT (key) DO UPDATE SET key = EXCLUDED.key
+RETURNING tokens, EXTRACT(EPOCH FROM now() - rate_limits.updated_at)::float8`, key, float64(limit.Burst)).Scan(&tokens, &elapsed)
+	if err != nil {
+		return 0, err
+	}
+
+	tokens, retryAfter := limit.Take(tokens, time.Duration(elapsed*float64(time.Second)), cost)
+	_, err = tx.Exec(ctx, "UPDATE rate_limits SET tokens = $2, updated_at = now() WHERE key = $1", key, tokens)
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	// не удалённые из-за ошибки строки удалятся при следующей уборке
+	_ = l.sweep(ctx, limit)
+	return retryAfter, nil
+}
+
+// sweep удаление строк, которые не менялись дольше времени наполнения ведра любого из лимитов -
+// такие вёдра полные и ничем не отличаются от новых
+func (l *PgRateLimiter) sweep(ctx context.Context, limit domain.RateLimit) error {
+	l.mx.Lock()
+	l.refill = max(l.refill, time.Duration(float64(limit.Burst)/limit.Rate*float64(time.Second)))
+	now := time.Now()
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		l.mx.Unlock()
+		return nil
+	}
+	l.lastSweep = now
+	refill := l.refill
+	l.mx.Unlock()
+
+	_, err := l.pool.Exec(ctx, "DELETE FROM rate_limits WHERE updated_at < now() - make_interval(secs => $1)", refill.Seconds())
+	return err
+}
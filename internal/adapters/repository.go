@@ -3,11 +3,16 @@ package adapters
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
+	"maps"
 	"net/url"
 	"os"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sashaaro/url-shortener/internal/domain"
@@ -15,19 +20,114 @@ import (
 )
 
 var _ domain.URLRepository = &memURLRepository{
-	urlStore: map[string]memEntry{},
+	urlStore: map[memKey]memEntry{},
+}
+
+// memKey - ключ ссылки, уникальный в пределах домена
+type memKey struct {
+	host string
+	hash domain.HashKey
+}
+
+// memKeyFromCtx ключ ссылки на домене запроса
+func memKeyFromCtx(ctx context.Context, key domain.HashKey) memKey {
+	return memKey{host: domain.LinkDomainFromCtx(ctx), hash: key}
 }
 
 type memEntry struct {
-	url    url.URL
-	hash   domain.HashKey
-	userID uuid.UUID
+	url         url.URL
+	host        string
+	hash        domain.HashKey
+	userID      uuid.UUID
+	workspaceID uuid.UUID
+	disabled    bool
+	createdAt   time.Time
+	expiresAt   *time.Time
+	title       string
+	note        string
+	tags        []string
+	redirect    domain.RedirectSettings
+	preview     domain.LinkPreview
+	rules       []domain.RouteRule
+	variants    []domain.Variant
+	params      domain.LinkParams
+	// clicks - переходы по идентификаторам вариантов, общие у всех копий записи
+	clicks map[string]int64
+}
+
+func (e memEntry) link() domain.Link {
+	return domain.Link{
+		Key:         e.hash,
+		Domain:      e.host,
+		ShortURL:    CreatePublicURL(e.host, e.hash),
+		OriginalURL: e.url.String(),
+		UserID:      e.userID,
+		WorkspaceID: e.workspaceID,
+		IsDisabled:  e.disabled,
+		ExpiresAt:   e.expiresAt,
+		Title:       e.title,
+		Note:        e.note,
+		Tags:        e.tags,
+		Redirect:    e.redirect,
+		Preview:     e.preview,
+		Rules:       e.rules,
+		Variants:    e.variants,
+		Params:      e.params,
+		CreatedAt:   e.createdAt,
+	}
+}
+
+// searchTerms слова ссылки для полнотекстового поиска
+func (e memEntry) searchTerms() []string {
+	return domain.SearchTerms(e.title + " " + e.note + " " + e.url.String())
 }
 
 // хранение ссылок в памяти
 type memURLRepository struct {
-	urlStore map[domain.HashKey]memEntry
-	mx       sync.Mutex
+	urlStore map[memKey]memEntry
+	// terms - обратный индекс полнотекстового поиска: слово - ссылки, в названии, заметке или оригинале которых оно есть
+	terms map[string]map[memKey]struct{}
+	mx    sync.Mutex
+}
+
+// indexEntry добавление ссылки в обратный индекс, вызывается под блокировкой
+func (m *memURLRepository) indexEntry(k memKey, v memEntry) {
+	for _, term := range v.searchTerms() {
+		keys, ok := m.terms[term]
+		if !ok {
+			keys = map[memKey]struct{}{}
+			m.terms[term] = keys
+		}
+		keys[k] = struct{}{}
+	}
+}
+
+// unindexEntry удаление ссылки из обратного индекса, вызывается под блокировкой
+func (m *memURLRepository) unindexEntry(k memKey, v memEntry) {
+	for _, term := range v.searchTerms() {
+		delete(m.terms[term], k)
+		if len(m.terms[term]) == 0 {
+			delete(m.terms, term)
+		}
+	}
+}
+
+// searchText ссылки, в которых есть все слова, вызывается под блокировкой
+func (m *memURLRepository) searchText(terms []string) map[memKey]struct{} {
+	found := map[memKey]struct{}{}
+	for i, term := range terms {
+		keys := m.terms[term]
+		if i == 0 {
+			maps.Copy(found, keys)
+			continue
+		}
+		for k := range found {
+			if _, ok := keys[k]; !ok {
+				delete(found, k)
+			}
+		}
+	}
+	return found
 }
 
 // CountUrls количество ссылок
@@ -44,38 +144,371 @@ func (m *memURLRepository) CountUsers(ctx context.Context) (int64, error) {
 	return int64(len(m.urlStore)), nil
 }
 
-// DeleteByUser удаление ссылок пользователя
-func (m *memURLRepository) DeleteByUser(ctx context.Context, keys []domain.HashKey, userID uuid.UUID) (bool, error) {
+// DeleteByKeys удаление ссылок
+func (m *memURLRepository) DeleteByKeys(ctx context.Context, keys []domain.HashKey) (bool, error) {
 	m.mx.Lock()
 	defer m.mx.Unlock()
 	for _, key := range keys {
-		if m.urlStore[key].userID == userID {
-			delete(m.urlStore, key)
+		k := memKeyFromCtx(ctx, key)
+		if v, ok := m.urlStore[k]; ok {
+			m.unindexEntry(k, v)
+			delete(m.urlStore, k)
 		}
 	}
 	return true, nil
 }
 
-// GetByUser получение ссылко пользователя
-func (m *memURLRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.URLEntry, error) {
-	l := make([]domain.URLEntry, 0)
+// DeleteByDomain удаление ссылок на домен и поддомены
+func (m *memURLRepository) DeleteByDomain(ctx context.Context, host string) ([]domain.Link, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	links := make([]domain.Link, 0)
+	for key, v := range m.urlStore {
+		if matchDomain(v.url.Hostname(), host) {
+			links = append(links, v.link())
+			m.unindexEntry(key, v)
+			delete(m.urlStore, key)
+		}
+	}
+	return links, nil
+}
+
+// GetLink ссылка с владельцем
+func (m *memURLRepository) GetLink(ctx context.Context, key domain.HashKey) (*domain.Link, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	v, ok := m.urlStore[memKeyFromCtx(ctx, key)]
+	if !ok {
+		return nil, domain.ErrURLNotFound
+	}
+	link := v.link()
+	return &link, nil
+}
+
+// GetLinks ссылки по ключам
+func (m *memURLRepository) GetLinks(ctx context.Context, keys []domain.HashKey) ([]domain.Link, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	links := make([]domain.Link, 0, len(keys))
+	for _, key := range keys {
+		if v, ok := m.urlStore[memKeyFromCtx(ctx, key)]; ok {
+			links = append(links, v.link())
+		}
+	}
+	return links, nil
+}
+
+// GetLinksByUser все ссылки пользователя
+func (m *memURLRepository) GetLinksByUser(ctx context.Context, userID uuid.UUID) ([]domain.Link, error) {
 	m.mx.Lock()
 	defer m.mx.Unlock()
+	l := make([]domain.Link, 0)
 	for _, v := range m.urlStore {
 		if v.userID == userID {
-			l = append(l, domain.URLEntry{
-				ShortURL:    CreatePublicURL(v.hash),
-				OriginalURL: v.url.String(),
-			})
+			l = append(l, v.link())
 		}
 	}
 	return l, nil
 }
 
+// SetDisabled отключение ссылки
+func (m *memURLRepository) SetDisabled(ctx context.Context, key domain.HashKey, disabled bool) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	k := memKeyFromCtx(ctx, key)
+	v, ok := m.urlStore[k]
+	if !ok {
+		return domain.ErrURLNotFound
+	}
+	v.disabled = disabled
+	m.urlStore[k] = v
+	return nil
+}
+
+// SetRedirect замена настроек редиректа
+func (m *memURLRepository) SetRedirect(ctx context.Context, key domain.HashKey, settings domain.RedirectSettings) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	k := memKeyFromCtx(ctx, key)
+	v, ok := m.urlStore[k]
+	if !ok {
+		return domain.ErrURLNotFound
+	}
+	v.redirect = settings
+	m.urlStore[k] = v
+	return nil
+}
+
+// SetPreview замена данных предпросмотра
+func (m *memURLRepository) SetPreview(ctx context.Context, key domain.HashKey, preview domain.LinkPreview) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	k := memKeyFromCtx(ctx, key)
+	v, ok := m.urlStore[k]
+	if !ok {
+		return domain.ErrURLNotFound
+	}
+	v.preview = preview
+	m.urlStore[k] = v
+	return nil
+}
+
+// SetRules замена правил маршрутизации
+func (m *memURLRepository) SetRules(ctx context.Context, key domain.HashKey, rules []domain.RouteRule) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	k := memKeyFromCtx(ctx, key)
+	v, ok := m.urlStore[k]
+	if !ok {
+		return domain.ErrURLNotFound
+	}
+	v.rules = rules
+	m.urlStore[k] = v
+	return nil
+}
+
+// SetVariants замена вариантов A/B теста
+func (m *memURLRepository) SetVariants(ctx context.Context, key domain.HashKey, variants []domain.Variant) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	k := memKeyFromCtx(ctx, key)
+	v, ok := m.urlStore[k]
+	if !ok {
+		return domain.ErrURLNotFound
+	}
+	v.variants = variants
+	m.urlStore[k] = v
+	return nil
+}
+
+// SetParams замена параметров запроса
+func (m *memURLRepository) SetParams(ctx context.Context, key domain.HashKey, params domain.LinkParams) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	k := memKeyFromCtx(ctx, key)
+	v, ok := m.urlStore[k]
+	if !ok {
+		return domain.ErrURLNotFound
+	}
+	v.params = params
+	m.urlStore[k] = v
+	return nil
+}
+
+// SetMeta замена названия, заметки и тегов
+func (m *memURLRepository) SetMeta(ctx context.Context, key domain.HashKey, meta domain.LinkMeta) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	k := memKeyFromCtx(ctx, key)
+	v, ok := m.urlStore[k]
+	if !ok {
+		return domain.ErrURLNotFound
+	}
+	m.unindexEntry(k, v)
+	v.title, v.note, v.tags = meta.Title, meta.Note, meta.Tags
+	m.urlStore[k] = v
+	m.indexEntry(k, v)
+	return nil
+}
+
+// RenameTag переименование тега в ссылках владельца
+func (m *memURLRepository) RenameTag(ctx context.Context, owner domain.Owner, rename domain.TagRename) ([]domain.Link, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	links := make([]domain.Link, 0)
+	for k, v := range m.urlStore {
+		if v.workspaceID != owner.WorkspaceID || owner.WorkspaceID == uuid.Nil && v.userID != owner.UserID {
+			continue
+		}
+		tags, ok := rename.Apply(v.tags)
+		if !ok {
+			continue
+		}
+		links = append(links, v.link())
+		v.tags = tags
+		m.urlStore[k] = v
+	}
+	return links, nil
+}
+
+// AddVariantClick учёт перехода на вариант
+func (m *memURLRepository) AddVariantClick(ctx context.Context, key domain.HashKey, variantID string) error {
+	return m.addVariantClicks(ctx, key, variantID, 1)
+}
+
+// addVariantClicks добавление n переходов на вариант
+func (m *memURLRepository) addVariantClicks(ctx context.Context, key domain.HashKey, variantID string, n int64) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	k := memKeyFromCtx(ctx, key)
+	v, ok := m.urlStore[k]
+	if !ok {
+		return domain.ErrURLNotFound
+	}
+	if v.clicks == nil {
+		v.clicks = map[string]int64{}
+		m.urlStore[k] = v
+	}
+	v.clicks[variantID] += n
+	return nil
+}
+
+// GetVariantClicks переходы на варианты
+func (m *memURLRepository) GetVariantClicks(ctx context.Context, key domain.HashKey) (map[string]int64, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	v, ok := m.urlStore[memKeyFromCtx(ctx, key)]
+	if !ok {
+		return nil, domain.ErrURLNotFound
+	}
+	return maps.Clone(v.clicks), nil
+}
+
+// GetOwners владельцы ссылок
+func (m *memURLRepository) GetOwners(ctx context.Context, keys []domain.HashKey) (map[domain.HashKey]domain.Owner, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	owners := make(map[domain.HashKey]domain.Owner, len(keys))
+	for _, key := range keys {
+		if v, ok := m.urlStore[memKeyFromCtx(ctx, key)]; ok {
+			owners[key] = domain.Owner{UserID: v.userID, WorkspaceID: v.workspaceID}
+		}
+	}
+	return owners, nil
+}
+
+// ReassignUser передача не больше limit ссылок другому пользователю
+func (m *memURLRepository) ReassignUser(ctx context.Context, from uuid.UUID, to uuid.UUID, limit int) ([]domain.Link, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	links := make([]domain.Link, 0)
+	for key, v := range m.urlStore {
+		if len(links) == limit {
+			break
+		}
+		if v.userID == from {
+			v.userID = to
+			m.urlStore[key] = v
+			links = append(links, v.link())
+		}
+	}
+	return links, nil
+}
+
+// GetByUser получение ссылко пользователя
+func (m *memURLRepository) GetByUser(ctx context.Context, userID uuid.UUID, q domain.LinkQuery) ([]domain.URLEntry, error) {
+	return m.list(q, func(v memEntry) bool {
+		return v.userID == userID && v.workspaceID == uuid.Nil
+	}), nil
+}
+
+// GetByWorkspace получение ссылок рабочего пространства
+func (m *memURLRepository) GetByWorkspace(ctx context.Context, workspaceID uuid.UUID, q domain.LinkQuery) ([]domain.URLEntry, error) {
+	return m.list(q, func(v memEntry) bool {
+		return v.workspaceID == workspaceID
+	}), nil
+}
+
+// Export ссылки владельца от старых к новым, fn вызывается без блокировки хранилища
+func (m *memURLRepository) Export(ctx context.Context, owner domain.Owner, fn func(e domain.ExportEntry) error) error {
+	m.mx.Lock()
+	entries := make([]memEntry, 0)
+	for _, v := range m.urlStore {
+		if v.workspaceID == owner.WorkspaceID && (owner.WorkspaceID != uuid.Nil || v.userID == owner.UserID) {
+			entries = append(entries, v)
+		}
+	}
+	m.mx.Unlock()
+
+	slices.SortFunc(entries, func(a, b memEntry) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.hash, b.hash)
+	})
+	for _, v := range entries {
+		err := fn(domain.ExportEntry{
+			Key:         v.hash,
+			Domain:      v.host,
+			ShortURL:    CreatePublicURL(v.host, v.hash),
+			OriginalURL: v.url.String(),
+			CreatedAt:   v.createdAt,
+			ExpiresAt:   v.expiresAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list ссылки владельца с фильтрами, сортировкой и курсором
+func (m *memURLRepository) list(q domain.LinkQuery, owned func(v memEntry) bool) []domain.URLEntry {
+	search := strings.ToLower(q.Search)
+	m.mx.Lock()
+	var found map[memKey]struct{}
+	if q.Text != "" {
+		found = m.searchText(domain.SearchTerms(q.Text))
+	}
+	entries := make([]memEntry, 0)
+	for k, v := range m.urlStore {
+		_, textFound := found[k]
+		switch {
+		case !owned(v):
+		case found != nil && !textFound:
+		case !hasTags(v.tags, q.Tags):
+		case q.After != nil && !q.After.Follows(v.createdAt, v.hash, q.Ascending):
+		case q.Domain != "" && !matchDomain(v.url.Hostname(), q.Domain):
+		case search != "" && !strings.Contains(strings.ToLower(v.url.String()), search) && !strings.Contains(strings.ToLower(v.hash), search):
+		default:
+			entries = append(entries, v)
+		}
+	}
+	m.mx.Unlock()
+
+	slices.SortFunc(entries, func(a, b memEntry) int {
+		c := a.createdAt.Compare(b.createdAt)
+		if c == 0 {
+			c = strings.Compare(a.hash, b.hash)
+		}
+		if !q.Ascending {
+			c = -c
+		}
+		return c
+	})
+	l := make([]domain.URLEntry, 0)
+	for _, v := range entries {
+		if q.Limit > 0 && len(l) == q.Limit {
+			break
+		}
+		l = append(l, domain.URLEntry{
+			Key:         v.hash,
+			Domain:      v.host,
+			ShortURL:    CreatePublicURL(v.host, v.hash),
+			OriginalURL: v.url.String(),
+			Title:       v.title,
+			Note:        v.note,
+			Tags:        v.tags,
+			CreatedAt:   v.createdAt,
+		})
+	}
+	return l
+}
+
+// hasTags у ссылки есть все теги
+func hasTags(tags []string, wanted []string) bool {
+	for _, tag := range wanted {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
 // BatchAdd добавление нескольких ссылок
-func (m *memURLRepository) BatchAdd(ctx context.Context, batch []domain.BatchItem, userID uuid.UUID) error {
+func (m *memURLRepository) BatchAdd(ctx context.Context, batch []domain.BatchItem, owner domain.Owner, params domain.LinkParams) error {
 	for _, item := range batch {
-		err := m.Add(ctx, item.HashKey, item.URL, userID)
+		err := m.Add(ctx, item.HashKey, item.URL, owner, params)
 		if err != nil {
 			return err
 		}
@@ -83,37 +516,121 @@ func (m *memURLRepository) BatchAdd(ctx context.Context, batch []domain.BatchIte
 	return nil
 }
 
+// Import добавление ссылок, занятые ключи пропускаются, повтор оригинала, как и в Add, допускается
+func (m *memURLRepository) Import(ctx context.Context, items []domain.ImportItem, owner domain.Owner, params domain.LinkParams) ([]domain.HashKey, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	now := time.Now().UTC()
+	created := make([]domain.HashKey, 0, len(items))
+	for _, item := range items {
+		k := memKeyFromCtx(ctx, item.Key)
+		if _, ok := m.urlStore[k]; ok {
+			continue
+		}
+		v := memEntry{
+			url:         item.URL,
+			host:        k.host,
+			hash:        item.Key,
+			userID:      owner.UserID,
+			workspaceID: owner.WorkspaceID,
+			createdAt:   now,
+			expiresAt:   item.ExpiresAt,
+			tags:        item.Tags,
+			params:      params,
+		}
+		m.urlStore[k] = v
+		m.indexEntry(k, v)
+		created = append(created, item.Key)
+	}
+	return created, nil
+}
+
 // NewMemURLRepository - конструктор
 func NewMemURLRepository() domain.URLRepository {
 	return &memURLRepository{
-		urlStore: map[domain.HashKey]memEntry{},
+		urlStore: map[memKey]memEntry{},
+		terms:    map[string]map[memKey]struct{}{},
 	}
 }
 
 // Add добавление ссылки
-func (m *memURLRepository) Add(ctx context.Context, key domain.HashKey, u url.URL, userID uuid.UUID) error {
+func (m *memURLRepository) Add(ctx context.Context, key domain.HashKey, u url.URL, owner domain.Owner, params domain.LinkParams) error {
 	m.mx.Lock()
 	defer m.mx.Unlock()
-	m.urlStore[key] = memEntry{
-		url:    u,
-		hash:   key,
-		userID: userID,
+	k := memKeyFromCtx(ctx, key)
+	if v, ok := m.urlStore[k]; ok {
+		m.unindexEntry(k, v)
+	}
+	v := memEntry{
+		url:         u,
+		host:        k.host,
+		hash:        key,
+		userID:      owner.UserID,
+		workspaceID: owner.WorkspaceID,
+		createdAt:   time.Now().UTC(),
+		params:      params,
 	}
+	m.urlStore[k] = v
+	m.indexEntry(k, v)
 	return nil
 }
 
+// restoreCreatedAt время создания ссылки, восстановленной из файла
+func (m *memURLRepository) restoreCreatedAt(ctx context.Context, key domain.HashKey, createdAt time.Time) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	k := memKeyFromCtx(ctx, key)
+	if v, ok := m.urlStore[k]; ok {
+		v.createdAt = createdAt
+		m.urlStore[k] = v
+	}
+}
+
 // GetByHash получение ссылки по ключу
 func (m *memURLRepository) GetByHash(ctx context.Context, key domain.HashKey) (*url.URL, error) {
 	m.mx.Lock()
 	defer m.mx.Unlock()
-	u, ok := m.urlStore[key]
+	u, ok := m.urlStore[memKeyFromCtx(ctx, key)]
 	if ok {
+		if u.disabled {
+			return nil, domain.ErrURLDisabled
+		}
+		if u.expiresAt != nil && !time.Now().Before(*u.expiresAt) {
+			return nil, domain.ErrURLExpired
+		}
 		return &u.url, nil
 	} else {
 		return nil, nil
 	}
 }
 
+// GetKeysByURLs ключи ссылок владельца по оригиналам
+func (m *memURLRepository) GetKeysByURLs(ctx context.Context, urls []string, owner domain.Owner) (map[string]domain.HashKey, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	wanted := make(map[string]struct{}, len(urls))
+	for _, u := range urls {
+		wanted[u] = struct{}{}
+	}
+	host := domain.LinkDomainFromCtx(ctx)
+	keys := map[string]domain.HashKey{}
+	for _, v := range m.urlStore {
+		if v.workspaceID != owner.WorkspaceID || owner.WorkspaceID == uuid.Nil && v.userID != owner.UserID {
+			continue
+		}
+		if _, ok := wanted[v.url.String()]; ok && v.host == host {
+			keys[v.url.String()] = v.hash
+		}
+	}
+	return keys, nil
+}
+
+// matchDomain хост совпадает с доменом или является его поддоменом
+func matchDomain(host string, target string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return host == target || strings.HasSuffix(host, "."+target)
+}
+
 var _ domain.URLRepository = &FileURLRepository{}
 
 // NewFileURLRepository конструктор
@@ -142,10 +659,58 @@ func NewFileURLRepository(
 }
 
 type fileEntry struct {
-	ShortURL    string    `json:"short_url"`
+	ShortURL string `json:"short_url"`
+	// Domain - брендовый домен ссылки, пусто - основной домен
+	Domain      string    `json:"domain,omitempty"`
 	OriginalURL string    `json:"original_url"`
 	ID          uuid.UUID `json:"id"`
 	UserID      uuid.UUID `json:"user_id"`
+	WorkspaceID uuid.UUID `json:"workspace_id,omitempty"`
+	// IsDeleted - запись об удалении ссылки
+	IsDeleted bool `json:"is_deleted,omitempty"`
+	// Disabled - запись об отключении или включении ссылки
+	Disabled *bool `json:"disabled,omitempty"`
+	// Redirect - запись об изменении настроек редиректа
+	Redirect *domain.RedirectSettings `json:"redirect,omitempty"`
+	// Preview - запись об изменении данных предпросмотра
+	Preview *domain.LinkPreview `json:"preview,omitempty"`
+	// Rules - запись о замене правил маршрутизации, пустой список - правила удалены
+	Rules *[]domain.RouteRule `json:"rules,omitempty"`
+	// Variants - запись о замене вариантов A/B теста
+	Variants *[]domain.Variant `json:"variants,omitempty"`
+	// Params - запись о замене параметров запроса, вместе с OriginalURL - параметры новой ссылки
+	Params *domain.LinkParams `json:"params,omitempty"`
+	// Meta - запись о замене названия, заметки и тегов
+	Meta *domain.LinkMeta `json:"meta,omitempty"`
+	// TagRename - запись о переименовании тега в ссылках владельца из UserID и WorkspaceID
+	TagRename *domain.TagRename `json:"tag_rename,omitempty"`
+	// VariantClick - запись о переходах на вариант
+	VariantClick string `json:"variant_click,omitempty"`
+	// VariantClicks - число переходов записи VariantClick, 0 - один переход
+	VariantClicks int64      `json:"variant_clicks,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
+}
+
+// createdAtRestorer - хранилище, в котором можно восстановить время создания ссылки
+type createdAtRestorer interface {
+	restoreCreatedAt(ctx context.Context, key domain.HashKey, createdAt time.Time)
+}
+
+// variantClicksRestorer - хранилище, в которое можно добавить сразу несколько переходов на вариант
+type variantClicksRestorer interface {
+	addVariantClicks(ctx context.Context, key domain.HashKey, variantID string, n int64) error
+}
+
+// fileVariantClickFlushInterval - как часто накопленные переходы на варианты дописываются в файл
+const fileVariantClickFlushInterval = 10 * time.Second
+
+// fileVariantClick - вариант ссылки, переходы на который ещё не записаны в файл
+type fileVariantClick struct {
+	domain  string
+	key     domain.HashKey
+	variant string
 }
 
 // FileURLRepository - сохранение ссылок в файл
@@ -154,6 +719,17 @@ type FileURLRepository struct {
 	encoder *json.Encoder
 	wrapped domain.URLRepository
 	logger  zap.SugaredLogger
+	// clicks - переходы на варианты с последней записи в файл, записываются одной строкой на вариант
+	clicks    map[fileVariantClick]int64
+	flushedAt time.Time
+	mx        sync.Mutex
+}
+
+// encode запись в файл, строки разных запросов не перемешиваются
+func (f *FileURLRepository) encode(entry fileEntry) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.encoder.Encode(entry)
 }
 
 // CountUrls количество ссылок
@@ -168,20 +744,185 @@ func (f *FileURLRepository) CountUsers(ctx context.Context) (int64, error) {
 	panic("implement me")
 }
 
-// DeleteByUser удаление
-func (f *FileURLRepository) DeleteByUser(ctx context.Context, keys []domain.HashKey, userID uuid.UUID) (bool, error) {
-	return f.wrapped.DeleteByUser(ctx, keys, userID)
+// DeleteByKeys удаление
+func (f *FileURLRepository) DeleteByKeys(ctx context.Context, keys []domain.HashKey) (bool, error) {
+	deleted, err := f.wrapped.DeleteByKeys(ctx, keys)
+	if err != nil {
+		return false, err
+	}
+	for _, key := range keys {
+		if err = f.encode(fileEntry{ID: uuid.New(), ShortURL: key, Domain: domain.LinkDomainFromCtx(ctx), IsDeleted: true}); err != nil {
+			return false, err
+		}
+	}
+	return deleted, nil
+}
+
+// DeleteByDomain удаление ссылок на домен и поддомены
+func (f *FileURLRepository) DeleteByDomain(ctx context.Context, host string) ([]domain.Link, error) {
+	links, err := f.wrapped.DeleteByDomain(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if err = f.encode(fileEntry{ID: uuid.New(), ShortURL: link.Key, Domain: link.Domain, IsDeleted: true}); err != nil {
+			return nil, err
+		}
+	}
+	return links, nil
+}
+
+// GetLink ссылка с владельцем
+func (f *FileURLRepository) GetLink(ctx context.Context, key domain.HashKey) (*domain.Link, error) {
+	return f.wrapped.GetLink(ctx, key)
+}
+
+// GetLinks ссылки по ключам
+func (f *FileURLRepository) GetLinks(ctx context.Context, keys []domain.HashKey) ([]domain.Link, error) {
+	return f.wrapped.GetLinks(ctx, keys)
+}
+
+// GetLinksByUser все ссылки пользователя
+func (f *FileURLRepository) GetLinksByUser(ctx context.Context, userID uuid.UUID) ([]domain.Link, error) {
+	return f.wrapped.GetLinksByUser(ctx, userID)
+}
+
+// SetDisabled отключение ссылки
+func (f *FileURLRepository) SetDisabled(ctx context.Context, key domain.HashKey, disabled bool) error {
+	if err := f.wrapped.SetDisabled(ctx, key, disabled); err != nil {
+		return err
+	}
+	return f.encode(fileEntry{ID: uuid.New(), ShortURL: key, Domain: domain.LinkDomainFromCtx(ctx), Disabled: &disabled})
+}
+
+// SetRedirect замена настроек редиректа
+func (f *FileURLRepository) SetRedirect(ctx context.Context, key domain.HashKey, settings domain.RedirectSettings) error {
+	if err := f.wrapped.SetRedirect(ctx, key, settings); err != nil {
+		return err
+	}
+	return f.encode(fileEntry{ID: uuid.New(), ShortURL: key, Domain: domain.LinkDomainFromCtx(ctx), Redirect: &settings})
+}
+
+// SetPreview замена данных предпросмотра
+func (f *FileURLRepository) SetPreview(ctx context.Context, key domain.HashKey, preview domain.LinkPreview) error {
+	if err := f.wrapped.SetPreview(ctx, key, preview); err != nil {
+		return err
+	}
+	return f.encode(fileEntry{ID: uuid.New(), ShortURL: key, Domain: domain.LinkDomainFromCtx(ctx), Preview: &preview})
+}
+
+// SetRules замена правил маршрутизации
+func (f *FileURLRepository) SetRules(ctx context.Context, key domain.HashKey, rules []domain.RouteRule) error {
+	if err := f.wrapped.SetRules(ctx, key, rules); err != nil {
+		return err
+	}
+	if rules == nil {
+		rules = []domain.RouteRule{}
+	}
+	return f.encode(fileEntry{ID: uuid.New(), ShortURL: key, Domain: domain.LinkDomainFromCtx(ctx), Rules: &rules})
+}
+
+// SetVariants замена вариантов A/B теста
+func (f *FileURLRepository) SetVariants(ctx context.Context, key domain.HashKey, variants []domain.Variant) error {
+	if err := f.wrapped.SetVariants(ctx, key, variants); err != nil {
+		return err
+	}
+	if variants == nil {
+		variants = []domain.Variant{}
+	}
+	return f.encode(fileEntry{ID: uuid.New(), ShortURL: key, Domain: domain.LinkDomainFromCtx(ctx), Variants: &variants})
+}
+
+// SetParams замена параметров запроса
+func (f *FileURLRepository) SetParams(ctx context.Context, key domain.HashKey, params domain.LinkParams) error {
+	if err := f.wrapped.SetParams(ctx, key, params); err != nil {
+		return err
+	}
+	return f.encode(fileEntry{ID: uuid.New(), ShortURL: key, Domain: domain.LinkDomainFromCtx(ctx), Params: &params})
+}
+
+// SetMeta замена названия, заметки и тегов
+func (f *FileURLRepository) SetMeta(ctx context.Context, key domain.HashKey, meta domain.LinkMeta) error {
+	if err := f.wrapped.SetMeta(ctx, key, meta); err != nil {
+		return err
+	}
+	return f.encode(fileEntry{ID: uuid.New(), ShortURL: key, Domain: domain.LinkDomainFromCtx(ctx), Meta: &meta})
+}
+
+// RenameTag переименование тега в ссылках владельца
+func (f *FileURLRepository) RenameTag(ctx context.Context, owner domain.Owner, rename domain.TagRename) ([]domain.Link, error) {
+	links, err := f.wrapped.RenameTag(ctx, owner, rename)
+	if err != nil || len(links) == 0 {
+		return links, err
+	}
+	return links, f.encode(fileEntry{ID: uuid.New(), UserID: owner.UserID, WorkspaceID: owner.WorkspaceID, TagRename: &rename})
+}
+
+// AddVariantClick учёт перехода на вариант, переходы копятся в памяти и записываются в файл
+// не чаще раза в fileVariantClickFlushInterval и при закрытии
+func (f *FileURLRepository) AddVariantClick(ctx context.Context, key domain.HashKey, variantID string) error {
+	if err := f.wrapped.AddVariantClick(ctx, key, variantID); err != nil {
+		return err
+	}
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if f.clicks == nil {
+		f.clicks = map[fileVariantClick]int64{}
+	}
+	f.clicks[fileVariantClick{domain: domain.LinkDomainFromCtx(ctx), key: key, variant: variantID}]++
+	if time.Since(f.flushedAt) < fileVariantClickFlushInterval {
+		return nil
+	}
+	return f.flushClicks()
+}
+
+// flushClicks запись накопленных переходов на варианты, вызывается под f.mx
+func (f *FileURLRepository) flushClicks() error {
+	for click, n := range f.clicks {
+		err := f.encoder.Encode(fileEntry{ID: uuid.New(), ShortURL: click.key, Domain: click.domain, VariantClick: click.variant, VariantClicks: n})
+		if err != nil {
+			return err
+		}
+		delete(f.clicks, click)
+	}
+	f.flushedAt = time.Now()
+	return nil
+}
+
+// GetVariantClicks переходы на варианты
+func (f *FileURLRepository) GetVariantClicks(ctx context.Context, key domain.HashKey) (map[string]int64, error) {
+	return f.wrapped.GetVariantClicks(ctx, key)
+}
+
+// GetOwners владельцы ссылок
+func (f *FileURLRepository) GetOwners(ctx context.Context, keys []domain.HashKey) (map[domain.HashKey]domain.Owner, error) {
+	return f.wrapped.GetOwners(ctx, keys)
+}
+
+// ReassignUser передача не больше limit ссылок другому пользователю
+func (f *FileURLRepository) ReassignUser(ctx context.Context, from uuid.UUID, to uuid.UUID, limit int) ([]domain.Link, error) {
+	return f.wrapped.ReassignUser(ctx, from, to, limit)
 }
 
 // GetByUser получение
-func (f *FileURLRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.URLEntry, error) {
-	return f.wrapped.GetByUser(ctx, userID)
+func (f *FileURLRepository) GetByUser(ctx context.Context, userID uuid.UUID, q domain.LinkQuery) ([]domain.URLEntry, error) {
+	return f.wrapped.GetByUser(ctx, userID, q)
+}
+
+// GetByWorkspace получение ссылок рабочего пространства
+func (f *FileURLRepository) GetByWorkspace(ctx context.Context, workspaceID uuid.UUID, q domain.LinkQuery) ([]domain.URLEntry, error) {
+	return f.wrapped.GetByWorkspace(ctx, workspaceID, q)
+}
+
+// Export выгрузка ссылок владельца
+func (f *FileURLRepository) Export(ctx context.Context, owner domain.Owner, fn func(e domain.ExportEntry) error) error {
+	return f.wrapped.Export(ctx, owner, fn)
 }
 
 // BatchAdd добавление нескольких ссылок
-func (f *FileURLRepository) BatchAdd(ctx context.Context, batch []domain.BatchItem, userID uuid.UUID) error {
+func (f *FileURLRepository) BatchAdd(ctx context.Context, batch []domain.BatchItem, owner domain.Owner, params domain.LinkParams) error {
 	for _, item := range batch {
-		err := f.Add(ctx, item.HashKey, item.URL, userID)
+		err := f.Add(ctx, item.HashKey, item.URL, owner, params)
 		if err != nil {
 			return err
 		}
@@ -189,50 +930,202 @@ func (f *FileURLRepository) BatchAdd(ctx context.Context, batch []domain.BatchIt
 	return nil
 }
 
+// Import добавление ссылок, занятые ключи пропускаются
+func (f *FileURLRepository) Import(ctx context.Context, items []domain.ImportItem, owner domain.Owner, params domain.LinkParams) ([]domain.HashKey, error) {
+	created, err := f.wrapped.Import(ctx, items, owner, params)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[domain.HashKey]domain.ImportItem, len(items))
+	for _, item := range items {
+		byKey[item.Key] = item
+	}
+	now := time.Now().UTC()
+	for _, key := range created {
+		item := byKey[key]
+		err = f.encode(fileEntry{
+			ID:          uuid.New(),
+			ShortURL:    key,
+			Domain:      domain.LinkDomainFromCtx(ctx),
+			OriginalURL: item.URL.String(),
+			UserID:      owner.UserID,
+			WorkspaceID: owner.WorkspaceID,
+			CreatedAt:   now,
+			ExpiresAt:   item.ExpiresAt,
+			Tags:        item.Tags,
+			Params:      fileEntryParams(params),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return created, nil
+}
+
 func (f *FileURLRepository) load() error {
 	decoder := json.NewDecoder(f.file)
-	var entry fileEntry
 	for {
+		var entry fileEntry
 		if err := decoder.Decode(&entry); err != nil {
 			if err == io.EOF {
 				break
 			}
 			return err
 		}
+		ctx := domain.WithLinkDomain(context.Background(), entry.Domain)
+
+		if entry.IsDeleted {
+			if _, err := f.wrapped.DeleteByKeys(ctx, []domain.HashKey{entry.ShortURL}); err != nil {
+				return err
+			}
+			continue
+		}
+		if entry.Disabled != nil {
+			err := f.wrapped.SetDisabled(ctx, entry.ShortURL, *entry.Disabled)
+			if err != nil && !errors.Is(err, domain.ErrURLNotFound) {
+				return err
+			}
+			continue
+		}
+		if entry.Redirect != nil {
+			err := f.wrapped.SetRedirect(ctx, entry.ShortURL, *entry.Redirect)
+			if err != nil && !errors.Is(err, domain.ErrURLNotFound) {
+				return err
+			}
+			continue
+		}
+		if entry.Preview != nil {
+			err := f.wrapped.SetPreview(ctx, entry.ShortURL, *entry.Preview)
+			if err != nil && !errors.Is(err, domain.ErrURLNotFound) {
+				return err
+			}
+			continue
+		}
+		if entry.Rules != nil {
+			err := f.wrapped.SetRules(ctx, entry.ShortURL, *entry.Rules)
+			if err != nil && !errors.Is(err, domain.ErrURLNotFound) {
+				return err
+			}
+			continue
+		}
+		if entry.Variants != nil {
+			err := f.wrapped.SetVariants(ctx, entry.ShortURL, *entry.Variants)
+			if err != nil && !errors.Is(err, domain.ErrURLNotFound) {
+				return err
+			}
+			continue
+		}
+		if entry.Params != nil && entry.OriginalURL == "" {
+			err := f.wrapped.SetParams(ctx, entry.ShortURL, *entry.Params)
+			if err != nil && !errors.Is(err, domain.ErrURLNotFound) {
+				return err
+			}
+			continue
+		}
+		if entry.Meta != nil {
+			err := f.wrapped.SetMeta(ctx, entry.ShortURL, *entry.Meta)
+			if err != nil && !errors.Is(err, domain.ErrURLNotFound) {
+				return err
+			}
+			continue
+		}
+		if entry.TagRename != nil {
+			owner := domain.Owner{UserID: entry.UserID, WorkspaceID: entry.WorkspaceID}
+			if _, err := f.wrapped.RenameTag(ctx, owner, *entry.TagRename); err != nil {
+				return err
+			}
+			continue
+		}
+		if entry.VariantClick != "" {
+			err := f.restoreVariantClicks(ctx, entry.ShortURL, entry.VariantClick, max(entry.VariantClicks, 1))
+			if err != nil && !errors.Is(err, domain.ErrURLNotFound) {
+				return err
+			}
+			continue
+		}
 
 		u, err := url.Parse(entry.OriginalURL)
 		if err != nil {
 			f.logger.Warn("invalid db url entry")
 			continue
 		}
-		err = f.wrapped.Add(context.Background(), entry.ShortURL, *u, entry.UserID)
+		owner := domain.Owner{UserID: entry.UserID, WorkspaceID: entry.WorkspaceID}
+		var params domain.LinkParams
+		if entry.Params != nil {
+			params = *entry.Params
+		}
+		if entry.ExpiresAt != nil || len(entry.Tags) > 0 {
+			item := domain.ImportItem{Key: entry.ShortURL, URL: *u, ExpiresAt: entry.ExpiresAt, Tags: entry.Tags}
+			_, err = f.wrapped.Import(ctx, []domain.ImportItem{item}, owner, params)
+		} else {
+			err = f.wrapped.Add(ctx, entry.ShortURL, *u, owner, params)
+		}
 		if err != nil {
 			return err
 		}
+		if restorer, ok := f.wrapped.(createdAtRestorer); ok && !entry.CreatedAt.IsZero() {
+			restorer.restoreCreatedAt(ctx, entry.ShortURL, entry.CreatedAt)
+		}
+	}
+	return nil
+}
+
+// restoreVariantClicks переходы на вариант из файла
+func (f *FileURLRepository) restoreVariantClicks(ctx context.Context, key domain.HashKey, variantID string, n int64) error {
+	if restorer, ok := f.wrapped.(variantClicksRestorer); ok {
+		return restorer.addVariantClicks(ctx, key, variantID, n)
+	}
+	for ; n > 0; n-- {
+		if err := f.wrapped.AddVariantClick(ctx, key, variantID); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// Close закрыть файл
+// Close запись накопленных переходов и закрытие файла
 func (f *FileURLRepository) Close() error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if err := f.flushClicks(); err != nil {
+		return err
+	}
 	return f.file.Close()
 }
 
 // Add добавление ссылки
-func (f FileURLRepository) Add(ctx context.Context, key domain.HashKey, u url.URL, userID uuid.UUID) error {
-	err := f.wrapped.Add(ctx, key, u, userID)
+func (f *FileURLRepository) Add(ctx context.Context, key domain.HashKey, u url.URL, owner domain.Owner, params domain.LinkParams) error {
+	err := f.wrapped.Add(ctx, key, u, owner, params)
 	if err != nil {
 		return err
 	}
-	err = f.encoder.Encode(fileEntry{
+	err = f.encode(fileEntry{
 		ID:          uuid.New(),
 		ShortURL:    key,
+		Domain:      domain.LinkDomainFromCtx(ctx),
 		OriginalURL: u.String(),
+		UserID:      owner.UserID,
+		WorkspaceID: owner.WorkspaceID,
+		CreatedAt:   time.Now().UTC(),
+		Params:      fileEntryParams(params),
 	})
 	return err
 }
 
+// fileEntryParams параметры новой ссылки для записи о создании, пустые не записываются
+func fileEntryParams(params domain.LinkParams) *domain.LinkParams {
+	if len(params.Query) == 0 && params.Merge == "" {
+		return nil
+	}
+	return &params
+}
+
+// GetKeysByURLs ключи ссылок владельца по оригиналам
+func (f *FileURLRepository) GetKeysByURLs(ctx context.Context, urls []string, owner domain.Owner) (map[string]domain.HashKey, error) {
+	return f.wrapped.GetKeysByURLs(ctx, urls, owner)
+}
+
 // GetByHash получение ссылки по ключу
-func (f FileURLRepository) GetByHash(ctx context.Context, key domain.HashKey) (*url.URL, error) {
+func (f *FileURLRepository) GetByHash(ctx context.Context, key domain.HashKey) (*url.URL, error) {
 	return f.wrapped.GetByHash(ctx, key)
 }
@@ -1,11 +1,13 @@
 package adapters
 
 import (
+	"bytes"
 	"context"
 	"go.uber.org/zap"
 	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sashaaro/url-shortener/internal/domain"
@@ -21,7 +23,7 @@ func TestMemURLRepository(t *testing.T) {
 	userID := uuid.New()
 
 	// Add a URL
-	err := repo.Add(context.Background(), hashKey, *testURL, userID)
+	err := repo.Add(context.Background(), hashKey, *testURL, domain.Owner{UserID: userID}, domain.LinkParams{})
 	require.NoError(t, err, "should not return an error on Add")
 
 	// Fetch the URL by hash key
@@ -31,15 +33,15 @@ func TestMemURLRepository(t *testing.T) {
 	require.Equal(t, testURL.String(), storedURL.String(), "stored URL should match the original")
 
 	// Fetch URLs by user
-	urlEntries, err := repo.GetByUser(context.Background(), userID)
+	urlEntries, err := repo.GetByUser(context.Background(), userID, domain.LinkQuery{})
 	require.NoError(t, err, "should not return an error on GetByUser")
 	require.Len(t, urlEntries, 1, "there should be one URL for this user")
-	require.Equal(t, CreatePublicURL(hashKey), urlEntries[0].ShortURL, "short URL should match")
+	require.Equal(t, CreatePublicURL("", hashKey), urlEntries[0].ShortURL, "short URL should match")
 	require.Equal(t, testURL.String(), urlEntries[0].OriginalURL, "original URL should match")
 
-	// Delete by user
-	deleted, err := repo.DeleteByUser(context.Background(), []domain.HashKey{hashKey}, userID)
-	require.NoError(t, err, "should not return an error on DeleteByUser")
+	// Delete by keys
+	deleted, err := repo.DeleteByKeys(context.Background(), []domain.HashKey{hashKey})
+	require.NoError(t, err, "should not return an error on DeleteByKeys")
 	require.True(t, deleted, "should return true when URLs are deleted")
 
 	// Ensure the URL is removed
@@ -48,6 +50,28 @@ func TestMemURLRepository(t *testing.T) {
 	require.Nil(t, storedURL, "stored URL should be nil after deletion")
 }
 
+func TestMemURLRepositoryReassignUser(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemURLRepository()
+	from, to := uuid.New(), uuid.New()
+	for _, key := range []domain.HashKey{"a", "b", "c"} {
+		require.NoError(t, repo.Add(ctx, key, url.URL{Scheme: "https", Host: key + ".example.com"}, domain.Owner{UserID: from}, domain.LinkParams{}))
+	}
+
+	for _, want := range []int{2, 1, 0} {
+		links, err := repo.ReassignUser(ctx, from, to, 2)
+		require.NoError(t, err)
+		require.Len(t, links, want)
+		for _, link := range links {
+			require.Equal(t, to, link.UserID)
+		}
+	}
+
+	links, err := repo.GetLinks(ctx, []domain.HashKey{"a", "c", "missing"})
+	require.NoError(t, err)
+	require.Len(t, links, 2)
+}
+
 func TestFileURLRepository(t *testing.T) {
 	// Setup temporary file for testing
 	tempFile, err := os.CreateTemp("", "url_repo_test_*.json")
@@ -70,7 +94,7 @@ func TestFileURLRepository(t *testing.T) {
 	userID := uuid.New()
 
 	// Add a URL
-	err = fileRepo.Add(context.Background(), hashKey, *testURL, userID)
+	err = fileRepo.Add(context.Background(), hashKey, *testURL, domain.Owner{UserID: userID}, domain.LinkParams{})
 	require.NoError(t, err, "should not return an error on Add")
 
 	// Fetch the URL by hash key
@@ -80,15 +104,15 @@ func TestFileURLRepository(t *testing.T) {
 	require.Equal(t, testURL.String(), storedURL.String(), "stored URL should match the original")
 
 	// Fetch URLs by user
-	urlEntries, err := fileRepo.GetByUser(context.Background(), userID)
+	urlEntries, err := fileRepo.GetByUser(context.Background(), userID, domain.LinkQuery{})
 	require.NoError(t, err, "should not return an error on GetByUser")
 	require.Len(t, urlEntries, 1, "there should be one URL for this user")
-	require.Equal(t, CreatePublicURL(hashKey), urlEntries[0].ShortURL, "short URL should match")
+	require.Equal(t, CreatePublicURL("", hashKey), urlEntries[0].ShortURL, "short URL should match")
 	require.Equal(t, testURL.String(), urlEntries[0].OriginalURL, "original URL should match")
 
-	// Delete by user
-	deleted, err := fileRepo.DeleteByUser(context.Background(), []domain.HashKey{hashKey}, userID)
-	require.NoError(t, err, "should not return an error on DeleteByUser")
+	// Delete by keys
+	deleted, err := fileRepo.DeleteByKeys(context.Background(), []domain.HashKey{hashKey})
+	require.NoError(t, err, "should not return an error on DeleteByKeys")
 	require.True(t, deleted, "should return true when URLs are deleted")
 
 	// Ensure the URL is removed
@@ -102,7 +126,219 @@ func TestFileURLRepository(t *testing.T) {
 
 	// Reload the repository from the file to ensure persistence works
 	reloadedRepo := NewFileURLRepository(tempFile.Name(), NewMemURLRepository(), *sugarLogger)
-	urlEntries, err = reloadedRepo.GetByUser(context.Background(), userID)
+	urlEntries, err = reloadedRepo.GetByUser(context.Background(), userID, domain.LinkQuery{})
 	require.NoError(t, err, "should not return an error on GetByUser after reload")
 	require.Len(t, urlEntries, 0, "there should be no URLs after reload since deletion occurred")
 }
+
+func TestMemURLRepositoryList(t *testing.T) {
+	repo := NewMemURLRepository()
+	userID := uuid.New()
+	for _, u := range []string{"https://example.com/a", "https://docs.example.com/b", "https://github.com/c"} {
+		parsed, _ := url.Parse(u)
+		require.NoError(t, repo.Add(context.Background(), u[len(u)-1:], *parsed, domain.Owner{UserID: userID}, domain.LinkParams{}))
+		time.Sleep(time.Millisecond)
+	}
+	keys := func(entries []domain.URLEntry) []string {
+		res := make([]string, 0, len(entries))
+		for _, e := range entries {
+			res = append(res, e.Key)
+		}
+		return res
+	}
+
+	entries, err := repo.GetByUser(context.Background(), userID, domain.LinkQuery{Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []string{"c", "b"}, keys(entries), "newest first")
+
+	last := entries[1]
+	entries, err = repo.GetByUser(context.Background(), userID, domain.LinkQuery{After: &domain.LinkCursor{CreatedAt: last.CreatedAt, Key: last.Key}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, keys(entries))
+
+	entries, err = repo.GetByUser(context.Background(), userID, domain.LinkQuery{Ascending: true, Domain: "example.com"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, keys(entries))
+
+	entries, err = repo.GetByUser(context.Background(), userID, domain.LinkQuery{Search: "GITHUB"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"c"}, keys(entries))
+}
+
+func TestFileURLRepositoryRedirect(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_redirect_*.json")
+	require.NoError(t, err)
+	defer os.Remove(tempFile.Name())
+	logger := zap.NewNop().Sugar()
+
+	fileRepo := NewFileURLRepository(tempFile.Name(), NewMemURLRepository(), *logger)
+	testURL, _ := url.Parse("https://example.com")
+	require.NoError(t, fileRepo.Add(context.Background(), "short123", *testURL, domain.Owner{UserID: uuid.New()}, domain.LinkParams{}))
+	passQuery := true
+	settings := domain.RedirectSettings{Type: domain.RedirectPermanent, PassQuery: &passQuery}
+	require.NoError(t, fileRepo.SetRedirect(context.Background(), "short123", settings))
+	require.ErrorIs(t, fileRepo.SetRedirect(context.Background(), "missing", settings), domain.ErrURLNotFound)
+	require.NoError(t, fileRepo.Close())
+
+	reloadedRepo := NewFileURLRepository(tempFile.Name(), NewMemURLRepository(), *logger)
+	link, err := reloadedRepo.GetLink(context.Background(), "short123")
+	require.NoError(t, err)
+	require.Equal(t, settings, link.Redirect)
+}
+
+func TestFileURLRepositoryLinkDomains(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_domains_*.json")
+	require.NoError(t, err)
+	defer os.Remove(tempFile.Name())
+	logger := zap.NewNop().Sugar()
+
+	mainCtx := context.Background()
+	brandCtx := domain.WithLinkDomain(context.Background(), "go.brand.com")
+	userID := uuid.New()
+	mainURL, _ := url.Parse("https://example.com/main")
+	brandURL, _ := url.Parse("https://example.com/brand")
+
+	fileRepo := NewFileURLRepository(tempFile.Name(), NewMemURLRepository(), *logger)
+	require.NoError(t, fileRepo.Add(mainCtx, "same", *mainURL, domain.Owner{UserID: userID}, domain.LinkParams{}))
+	require.NoError(t, fileRepo.Add(brandCtx, "same", *brandURL, domain.Owner{UserID: userID}, domain.LinkParams{}), "keys are unique per domain")
+	require.NoError(t, fileRepo.SetDisabled(brandCtx, "same", true))
+	require.NoError(t, fileRepo.Close())
+
+	reloadedRepo := NewFileURLRepository(tempFile.Name(), NewMemURLRepository(), *logger)
+	link, err := reloadedRepo.GetLink(mainCtx, "same")
+	require.NoError(t, err)
+	require.Equal(t, mainURL.String(), link.OriginalURL)
+	require.False(t, link.IsDisabled)
+	require.Equal(t, CreatePublicURL("", "same"), link.ShortURL)
+
+	link, err = reloadedRepo.GetLink(brandCtx, "same")
+	require.NoError(t, err)
+	require.Equal(t, brandURL.String(), link.OriginalURL)
+	require.Equal(t, "go.brand.com", link.Domain)
+	require.True(t, link.IsDisabled)
+	require.Equal(t, "https://go.brand.com/same", link.ShortURL)
+
+	keys, err := reloadedRepo.GetKeysByURLs(brandCtx, []string{mainURL.String(), brandURL.String()}, domain.Owner{UserID: userID})
+	require.NoError(t, err)
+	require.Equal(t, map[string]domain.HashKey{brandURL.String(): "same"}, keys, "only links of the domain")
+	keys, err = reloadedRepo.GetKeysByURLs(brandCtx, []string{brandURL.String()}, domain.Owner{UserID: uuid.New()})
+	require.NoError(t, err)
+	require.Empty(t, keys, "only links of the owner")
+
+	entries, err := reloadedRepo.GetByUser(mainCtx, userID, domain.LinkQuery{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "user links of all domains")
+}
+
+func TestFileURLRepositoryVariants(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_variants_*.json")
+	require.NoError(t, err)
+	defer os.Remove(tempFile.Name())
+	logger := zap.NewNop().Sugar()
+
+	ctx := context.Background()
+	u, _ := url.Parse("https://example.com")
+	variants := []domain.Variant{{ID: "a", URL: "https://example.com/a", Weight: 70}, {ID: "b", URL: "https://example.com/b", Weight: 30}}
+
+	fileRepo := NewFileURLRepository(tempFile.Name(), NewMemURLRepository(), *logger)
+	require.NoError(t, fileRepo.Add(ctx, "ab", *u, domain.Owner{UserID: uuid.New()}, domain.LinkParams{}))
+	require.NoError(t, fileRepo.SetVariants(ctx, "ab", variants))
+	errs := make(chan error, 100)
+	for i := 0; i < cap(errs); i++ {
+		go func(variant string) {
+			errs <- fileRepo.AddVariantClick(ctx, "ab", variant)
+		}([]string{"a", "a", "b"}[i%3])
+	}
+	for i := 0; i < cap(errs); i++ {
+		require.NoError(t, <-errs)
+	}
+	require.NoError(t, fileRepo.SetVariants(ctx, "ab", variants[:1]))
+	require.NoError(t, fileRepo.Close())
+	content, err := os.ReadFile(tempFile.Name())
+	require.NoError(t, err)
+	require.LessOrEqual(t, bytes.Count(content, []byte("\n")), 6, "clicks are written as counters, not a line per click")
+
+	reloadedRepo := NewFileURLRepository(tempFile.Name(), NewMemURLRepository(), *logger)
+	link, err := reloadedRepo.GetLink(ctx, "ab")
+	require.NoError(t, err)
+	require.Equal(t, variants[:1], link.Variants)
+
+	clicks, err := reloadedRepo.GetVariantClicks(ctx, "ab")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"a": 67, "b": 33}, clicks, "clicks survive variant replacement")
+}
+
+func TestFileURLRepositoryNewLinkParams(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_params_*.json")
+	require.NoError(t, err)
+	defer os.Remove(tempFile.Name())
+	logger := zap.NewNop().Sugar()
+
+	ctx := context.Background()
+	owner := domain.Owner{UserID: uuid.New()}
+	u, _ := url.Parse("https://example.com")
+	params := domain.LinkParams{Query: map[string]string{"utm_source": "short"}}
+
+	fileRepo := NewFileURLRepository(tempFile.Name(), NewMemURLRepository(), *logger)
+	require.NoError(t, fileRepo.Add(ctx, "ab", *u, owner, params))
+	_, err = fileRepo.Import(ctx, []domain.ImportItem{{Key: "cd", URL: *u, Tags: []string{"promo"}}}, owner, params)
+	require.NoError(t, err)
+	require.NoError(t, fileRepo.Close())
+	content, err := os.ReadFile(tempFile.Name())
+	require.NoError(t, err)
+	require.Equal(t, 2, bytes.Count(content, []byte("\n")), "params are written with the link")
+
+	reloadedRepo := NewFileURLRepository(tempFile.Name(), NewMemURLRepository(), *logger)
+	for _, key := range []domain.HashKey{"ab", "cd"} {
+		link, err := reloadedRepo.GetLink(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, params, link.Params)
+	}
+}
+
+func TestFileURLRepositoryMeta(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_meta_*.json")
+	require.NoError(t, err)
+	defer os.Remove(tempFile.Name())
+	logger := zap.NewNop().Sugar()
+
+	ctx := context.Background()
+	owner := domain.Owner{UserID: uuid.New()}
+	u, _ := url.Parse("https://example.com/docs/pricing")
+
+	fileRepo := NewFileURLRepository(tempFile.Name(), NewMemURLRepository(), *logger)
+	require.NoError(t, fileRepo.Add(ctx, "ab", *u, owner, domain.LinkParams{}))
+	require.NoError(t, fileRepo.Add(ctx, "cd", *u, domain.Owner{UserID: uuid.New()}, domain.LinkParams{}))
+	require.NoError(t, fileRepo.SetMeta(ctx, "ab", domain.LinkMeta{Title: "Spring campaign", Note: "Banner in the newsletter", Tags: []string{"promo", "mail", "q2"}}))
+	require.NoError(t, fileRepo.SetMeta(ctx, "cd", domain.LinkMeta{Tags: []string{"promo"}}))
+	links, err := fileRepo.RenameTag(ctx, owner, domain.TagRename{From: "promo", To: "mail"})
+	require.NoError(t, err)
+	require.Len(t, links, 1, "links of other users are not renamed")
+	require.Equal(t, []string{"promo", "mail", "q2"}, links[0].Tags, "links are returned before the rename")
+	require.NoError(t, fileRepo.Close())
+
+	reloadedRepo := NewFileURLRepository(tempFile.Name(), NewMemURLRepository(), *logger)
+	link, err := reloadedRepo.GetLink(ctx, "ab")
+	require.NoError(t, err)
+	require.Equal(t, "Spring campaign", link.Title)
+	require.Equal(t, []string{"mail", "q2"}, link.Tags)
+
+	search := func(q domain.LinkQuery) []domain.HashKey {
+		entries, err := reloadedRepo.GetByUser(ctx, owner.UserID, q)
+		require.NoError(t, err)
+		keys := make([]domain.HashKey, 0, len(entries))
+		for _, e := range entries {
+			keys = append(keys, e.Key)
+		}
+		return keys
+	}
+	require.Equal(t, []domain.HashKey{"ab"}, search(domain.LinkQuery{Text: "newsletter spring"}))
+	require.Equal(t, []domain.HashKey{"ab"}, search(domain.LinkQuery{Text: "pricing"}), "url words are indexed")
+	require.Empty(t, search(domain.LinkQuery{Text: "spring autumn"}))
+	require.Equal(t, []domain.HashKey{"ab"}, search(domain.LinkQuery{Tags: []string{"mail", "q2"}}))
+	require.Empty(t, search(domain.LinkQuery{Tags: []string{"promo"}}))
+
+	require.NoError(t, reloadedRepo.SetMeta(ctx, "ab", domain.LinkMeta{Title: "Autumn campaign"}))
+	require.Empty(t, search(domain.LinkQuery{Text: "spring"}), "old words are removed from the index")
+	require.Equal(t, []domain.HashKey{"ab"}, search(domain.LinkQuery{Text: "autumn"}))
+}
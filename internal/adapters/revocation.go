@@ -0,0 +1,84 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sync"
+	"time"
+)
+
+// TokenRevocations - отозванные refresh токены по jti, хранятся до истечения срока токена
+type TokenRevocations interface {
+	Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
+
+var _ TokenRevocations = &MemTokenRevocations{}
+
+// MemTokenRevocations - отозванные токены в памяти процесса
+type MemTokenRevocations struct {
+	revoked map[string]time.Time
+	mx      sync.Mutex
+	now     func() time.Time
+}
+
+// NewMemTokenRevocations конструктор
+func NewMemTokenRevocations() *MemTokenRevocations {
+	return &MemTokenRevocations{revoked: map[string]time.Time{}, now: time.Now}
+}
+
+// Revoke отзыв токена, истёкшие записи удаляются
+func (m *MemTokenRevocations) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	now := m.now()
+	for revokedID, exp := range m.revoked {
+		if now.After(exp) {
+			delete(m.revoked, revokedID)
+		}
+	}
+	m.revoked[id] = expiresAt
+	return nil
+}
+
+// IsRevoked токен отозван
+func (m *MemTokenRevocations) IsRevoked(ctx context.Context, id string) (bool, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	_, ok := m.revoked[id]
+	return ok, nil
+}
+
+var _ TokenRevocations = &PgTokenRevocations{}
+
+// PgTokenRevocations - отозванные токены в postgres, общие для нескольких реплик
+type PgTokenRevocations struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgTokenRevocations конструктор
+func NewPgTokenRevocations(pool *pgxpool.Pool) *PgTokenRevocations {
+	return &PgTokenRevocations{pool: pool}
+}
+
+// Revoke отзыв токена, истёкшие записи удаляются
+func (r *PgTokenRevocations) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	_, err := r.pool.Exec(ctx, "INSERT INTO revoked_tokens (id, expires_at) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING", id, expiresAt)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, "DELETE FROM revoked_tokens WHERE expires_at < now()")
+	return err
+}
+
+// IsRevoked токен отозван
+func (r *PgTokenRevocations) IsRevoked(ctx context.Context, id string) (bool, error) {
+	var found int
+	err := r.pool.QueryRow(ctx, "SELECT 1 FROM revoked_tokens WHERE id = $1", id).Scan(&found)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
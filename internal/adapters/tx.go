@@ -0,0 +1,56 @@
+package adapters
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sashaaro/url-shortener/internal/domain"
+)
+
+// pgConn - общие методы пула и транзакции postgres
+type pgConn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+type pgTxContext struct{}
+
+// pgConnFromCtx транзакция из PgTransactor.InTx, вне транзакции - пул.
+// Begin внутри транзакции создаёт точку сохранения.
+func pgConnFromCtx(ctx context.Context, pool *pgxpool.Pool) pgConn {
+	if tx, ok := ctx.Value(&pgTxContext{}).(pgx.Tx); ok {
+		return tx
+	}
+	return pool
+}
+
+var _ domain.Transactor = &PgTransactor{}
+
+// PgTransactor - транзакции postgres, общие для репозиториев postgres с тем же пулом
+type PgTransactor struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgTransactor конструктор
+func NewPgTransactor(pool *pgxpool.Pool) *PgTransactor {
+	return &PgTransactor{pool: pool}
+}
+
+// InTx выполнение fn в транзакции, вложенный вызов выполняется в точке сохранения внешней транзакции
+func (t *PgTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := pgConnFromCtx(ctx, t.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// nolint:errcheck
+	defer tx.Rollback(ctx)
+
+	if err = fn(context.WithValue(ctx, &pgTxContext{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
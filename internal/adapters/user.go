@@ -0,0 +1,151 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"sync"
+)
+
+var _ domain.UserRepository = &memUserRepository{}
+
+// хранение пользователей в памяти
+type memUserRepository struct {
+	users      map[uuid.UUID]domain.User
+	identities map[[2]string]uuid.UUID
+	mx         sync.Mutex
+}
+
+// NewMemUserRepository - конструктор
+func NewMemUserRepository() domain.UserRepository {
+	return &memUserRepository{users: map[uuid.UUID]domain.User{}, identities: map[[2]string]uuid.UUID{}}
+}
+
+// Create добавление пользователя
+func (m *memUserRepository) Create(ctx context.Context, user domain.User) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	for _, u := range m.users {
+		if user.Email != "" && u.Email == user.Email {
+			return domain.ErrUserAlreadyExists
+		}
+	}
+	m.users[user.ID] = user
+	return nil
+}
+
+// GetByEmail получение пользователя по email
+func (m *memUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	for _, u := range m.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+// GetByID получение пользователя по id
+func (m *memUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	u, ok := m.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &u, nil
+}
+
+// GetByIdentity получение пользователя по учётной записи провайдера
+func (m *memUserRepository) GetByIdentity(ctx context.Context, issuer string, subject string) (*domain.User, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	id, ok := m.identities[[2]string{issuer, subject}]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	u, ok := m.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &u, nil
+}
+
+// AddIdentity привязка учётной записи провайдера
+func (m *memUserRepository) AddIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	key := [2]string{identity.Issuer, identity.Subject}
+	if _, ok := m.identities[key]; ok {
+		return domain.ErrIdentityAlreadyLinked
+	}
+	m.identities[key] = identity.UserID
+	return nil
+}
+
+var _ domain.UserRepository = &PgUserRepository{}
+
+// PgUserRepository - хранение пользователей в postgres
+type PgUserRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgUserRepository - конструктор
+func NewPgUserRepository(pool *pgxpool.Pool) *PgUserRepository {
+	return &PgUserRepository{pool: pool}
+}
+
+// email у пользователей внешних провайдеров может быть NULL
+const userColumns = "id, COALESCE(email, ''), password_hash, email_verified, created_at"
+
+func scanUser(row pgx.Row) (*domain.User, error) {
+	u := &domain.User{}
+	err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.EmailVerified, &u.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	return u, err
+}
+
+// Create добавление пользователя
+func (r *PgUserRepository) Create(ctx context.Context, user domain.User) error {
+	_, err := r.pool.Exec(ctx, "INSERT INTO users (id, email, password_hash, email_verified, created_at) VALUES ($1, NULLIF($2, ''), $3, $4, $5)",
+		user.ID, user.Email, user.PasswordHash, user.EmailVerified, user.CreatedAt)
+	pgErr := &pgconn.PgError{}
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return domain.ErrUserAlreadyExists
+	}
+	return err
+}
+
+// GetByEmail получение пользователя по email
+func (r *PgUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return scanUser(r.pool.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE email = $1", email))
+}
+
+// GetByID получение пользователя по id
+func (r *PgUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return scanUser(r.pool.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id))
+}
+
+// GetByIdentity получение пользователя по учётной записи провайдера
+func (r *PgUserRepository) GetByIdentity(ctx context.Context, issuer string, subject string) (*domain.User, error) {
+	return scanUser(r.pool.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE id = (SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2)", issuer, subject))
+}
+
+// AddIdentity привязка учётной записи провайдера
+func (r *PgUserRepository) AddIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	_, err := r.pool.Exec(ctx, "INSERT INTO user_identities (issuer, subject, user_id, created_at) VALUES ($1, $2, $3, $4)",
+		identity.Issuer, identity.Subject, identity.UserID, identity.CreatedAt)
+	pgErr := &pgconn.PgError{}
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return domain.ErrIdentityAlreadyLinked
+	}
+	return err
+}
@@ -0,0 +1,210 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"slices"
+	"sync"
+)
+
+var _ domain.WorkspaceRepository = &memWorkspaceRepository{}
+
+// хранение рабочих пространств в памяти
+type memWorkspaceRepository struct {
+	workspaces map[uuid.UUID]domain.Workspace
+	members    map[uuid.UUID]map[uuid.UUID]domain.WorkspaceMember
+	mx         sync.Mutex
+}
+
+// NewMemWorkspaceRepository - конструктор
+func NewMemWorkspaceRepository() domain.WorkspaceRepository {
+	return &memWorkspaceRepository{
+		workspaces: map[uuid.UUID]domain.Workspace{},
+		members:    map[uuid.UUID]map[uuid.UUID]domain.WorkspaceMember{},
+	}
+}
+
+// Create создание пространства с владельцем
+func (m *memWorkspaceRepository) Create(ctx context.Context, ws domain.Workspace, owner domain.WorkspaceMember) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.workspaces[ws.ID] = ws
+	m.members[ws.ID] = map[uuid.UUID]domain.WorkspaceMember{owner.UserID: owner}
+	return nil
+}
+
+// ListByUser пространства пользователя
+func (m *memWorkspaceRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.WorkspaceMembership, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	l := make([]domain.WorkspaceMembership, 0)
+	for id, members := range m.members {
+		if member, ok := members[userID]; ok {
+			l = append(l, domain.WorkspaceMembership{Workspace: m.workspaces[id], Role: member.Role})
+		}
+	}
+	slices.SortFunc(l, func(a, b domain.WorkspaceMembership) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return l, nil
+}
+
+// GetMember участник пространства
+func (m *memWorkspaceRepository) GetMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) (*domain.WorkspaceMember, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	member, ok := m.members[workspaceID][userID]
+	if !ok {
+		return nil, domain.ErrMemberNotFound
+	}
+	return &member, nil
+}
+
+// ListMembers участники пространства
+func (m *memWorkspaceRepository) ListMembers(ctx context.Context, workspaceID uuid.UUID) ([]domain.WorkspaceMember, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	l := make([]domain.WorkspaceMember, 0, len(m.members[workspaceID]))
+	for _, member := range m.members[workspaceID] {
+		l = append(l, member)
+	}
+	slices.SortFunc(l, func(a, b domain.WorkspaceMember) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return l, nil
+}
+
+// SetMember добавление участника или смена роли
+func (m *memWorkspaceRepository) SetMember(ctx context.Context, member domain.WorkspaceMember) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	members, ok := m.members[member.WorkspaceID]
+	if !ok {
+		return domain.ErrWorkspaceNotFound
+	}
+	members[member.UserID] = member
+	return nil
+}
+
+// RemoveMember исключение участника
+func (m *memWorkspaceRepository) RemoveMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if _, ok := m.members[workspaceID][userID]; !ok {
+		return domain.ErrMemberNotFound
+	}
+	delete(m.members[workspaceID], userID)
+	return nil
+}
+
+var _ domain.WorkspaceRepository = &PgWorkspaceRepository{}
+
+// PgWorkspaceRepository - хранение рабочих пространств в postgres
+type PgWorkspaceRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgWorkspaceRepository - конструктор
+func NewPgWorkspaceRepository(pool *pgxpool.Pool) *PgWorkspaceRepository {
+	return &PgWorkspaceRepository{pool: pool}
+}
+
+const workspaceMemberColumns = "workspace_id, user_id, role, created_at"
+
+func scanWorkspaceMember(row pgx.Row) (*domain.WorkspaceMember, error) {
+	member := &domain.WorkspaceMember{}
+	err := row.Scan(&member.WorkspaceID, &member.UserID, &member.Role, &member.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrMemberNotFound
+	}
+	return member, err
+}
+
+// Create создание пространства с владельцем
+func (r *PgWorkspaceRepository) Create(ctx context.Context, ws domain.Workspace, owner domain.WorkspaceMember) error {
+	tx, err := pgConnFromCtx(ctx, r.pool).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// nolint:errcheck
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "INSERT INTO workspaces (id, name, created_at) VALUES ($1, $2, $3)", ws.ID, ws.Name, ws.CreatedAt)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO workspace_members ("+workspaceMemberColumns+") VALUES ($1, $2, $3, $4)",
+		owner.WorkspaceID, owner.UserID, owner.Role, owner.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListByUser пространства пользователя
+func (r *PgWorkspaceRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.WorkspaceMembership, error) {
+	rows, err := r.pool.Query(ctx, `SELECT w.id, w.name, w.created_at, m.role
+FROM workspaces w JOIN workspace_members m ON m.workspace_id = w.id
+WHERE m.user_id = $1 ORDER BY w.created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	l := []domain.WorkspaceMembership{}
+	for rows.Next() {
+		var ws domain.WorkspaceMembership
+		if err = rows.Scan(&ws.ID, &ws.Name, &ws.CreatedAt, &ws.Role); err != nil {
+			return nil, err
+		}
+		l = append(l, ws)
+	}
+	return l, rows.Err()
+}
+
+// GetMember участник пространства
+func (r *PgWorkspaceRepository) GetMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) (*domain.WorkspaceMember, error) {
+	return scanWorkspaceMember(r.pool.QueryRow(ctx, "SELECT "+workspaceMemberColumns+" FROM workspace_members WHERE workspace_id = $1 AND user_id = $2", workspaceID, userID))
+}
+
+// ListMembers участники пространства
+func (r *PgWorkspaceRepository) ListMembers(ctx context.Context, workspaceID uuid.UUID) ([]domain.WorkspaceMember, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+workspaceMemberColumns+" FROM workspace_members WHERE workspace_id = $1 ORDER BY created_at", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	l := []domain.WorkspaceMember{}
+	for rows.Next() {
+		member, err := scanWorkspaceMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, *member)
+	}
+	return l, rows.Err()
+}
+
+// SetMember добавление участника или смена роли
+func (r *PgWorkspaceRepository) SetMember(ctx context.Context, member domain.WorkspaceMember) error {
+	_, err := pgConnFromCtx(ctx, r.pool).Exec(ctx, "INSERT INTO workspace_members ("+workspaceMemberColumns+") VALUES ($1, $2, $3, $4) ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role",
+		member.WorkspaceID, member.UserID, member.Role, member.CreatedAt)
+	return err
+}
+
+// RemoveMember исключение участника
+func (r *PgWorkspaceRepository) RemoveMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) error {
+	res, err := pgConnFromCtx(ctx, r.pool).Exec(ctx, "DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2", workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrMemberNotFound
+	}
+	return nil
+}
@@ -1,6 +1,11 @@
 // Package internal - кишки
 package internal
 
+import "time"
+
+// DefaultJwtSecret - секрет jwt по умолчанию, только для разработки
+const DefaultJwtSecret = "secret"
+
 // Config основной экземляр конфига приложения
 var Config = config{}
 
@@ -14,4 +19,66 @@ type config struct {
 	JwtSecret       string `env:"JWT_SECRET"`
 	EnableHTTPS     bool   `env:"ENABLE_HTTPS"`
 	TrustedSubnet   string `env:"TRUSTED_SUBNET"`
+	// TrustedProxies - сети доверенных прокси, только от них принимается заголовок ClientIPHeader
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+	// ClientIPHeader - заголовок с ip клиента, который пишет прокси: X-Forwarded-For, Forwarded или X-Real-IP
+	ClientIPHeader string `env:"CLIENT_IP_HEADER" envDefault:"X-Forwarded-For"`
+	// AppEnv - окружение, в production запрещён секрет jwt по умолчанию
+	AppEnv string `env:"APP_ENV"`
+
+	// Domains - брендовые домены коротких ссылок в дополнение к домену BASE_URL, ключи уникальны в пределах домена
+	Domains []string `env:"DOMAINS" envSeparator:","`
+
+	// JwtKeysDir - каталог PEM ключей RS256/EdDSA, имя файла - kid
+	JwtKeysDir          string        `env:"JWT_KEYS_DIR"`
+	JwtRotationInterval time.Duration `env:"JWT_ROTATION_INTERVAL"`
+	// JwtKeyGrace - сколько старый ключ принимается после смены, по умолчанию время жизни refresh токена
+	JwtKeyGrace   time.Duration `env:"JWT_KEY_GRACE"`
+	JwtRefreshTTL time.Duration `env:"JWT_REFRESH_TTL"`
+
+	// OIDCIssuer - провайдер OpenID Connect, без него вход через провайдер выключен
+	OIDCIssuer       string `env:"OIDC_ISSUER"`
+	OIDCClientID     string `env:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string `env:"OIDC_CLIENT_SECRET"`
+	// OIDCRedirectURL - по умолчанию BASE_URL/api/auth/oidc/callback
+	OIDCRedirectURL string   `env:"OIDC_REDIRECT_URL"`
+	OIDCScopes      []string `env:"OIDC_SCOPES" envSeparator:","`
+
+	// AdminUserIDs - пользователи с доступом к модерации
+	AdminUserIDs []string `env:"ADMIN_USER_IDS" envSeparator:","`
+	// AuditLogFile - журнал аудита в файле вместо таблицы audit_log
+	AuditLogFile string `env:"AUDIT_LOG_FILE"`
+
+	NormalizeStripTracking  bool     `env:"NORMALIZE_STRIP_TRACKING"`
+	NormalizeTrackingParams []string `env:"NORMALIZE_TRACKING_PARAMS" envSeparator:","`
+
+	AllowedSchemes      []string `env:"ALLOWED_SCHEMES" envSeparator:","`
+	DeniedHosts         []string `env:"DENIED_HOSTS" envSeparator:","`
+	DeniedNetworks      []string `env:"DENIED_NETWORKS" envSeparator:","`
+	MaxURLLength        int      `env:"MAX_URL_LENGTH"`
+	DomainBlocklistFile string   `env:"DOMAIN_BLOCKLIST_FILE"`
+
+	RateLimitStorage  string `env:"RATE_LIMIT_STORAGE"`
+	RateLimitCreate   string `env:"RATE_LIMIT_CREATE"`
+	RateLimitBatch    string `env:"RATE_LIMIT_BATCH"`
+	RateLimitRedirect string `env:"RATE_LIMIT_REDIRECT"`
+	RateLimitDelete   string `env:"RATE_LIMIT_DELETE"`
+
+	// RedirectType - код редиректа ссылок без своих настроек: 301, 302, 307 или 308
+	RedirectType int `env:"REDIRECT_TYPE" envDefault:"307"`
+	// RedirectPassQuery - дописывать параметры запроса короткой ссылки к оригиналу для ссылок без своих настроек
+	RedirectPassQuery bool `env:"REDIRECT_PASS_QUERY"`
+	// RedirectCacheMaxAge - срок кеширования постоянных редиректов браузерами и CDN, 0 - не кешировать
+	RedirectCacheMaxAge time.Duration `env:"REDIRECT_CACHE_MAX_AGE" envDefault:"1h"`
+
+	// TemplatesDir - каталог html шаблонов, файлы с именами встроенных шаблонов заменяют их
+	TemplatesDir string `env:"TEMPLATES_DIR"`
+
+	// GeoIPDatabaseFile - база стран в формате mmdb для правил маршрутизации по стране, без неё такие правила не срабатывают
+	GeoIPDatabaseFile string `env:"GEOIP_DATABASE_FILE"`
+}
+
+// IsProduction - приложение запущено в production окружении
+func (c config) IsProduction() bool {
+	return c.AppEnv == "production" || c.AppEnv == "prod"
 }
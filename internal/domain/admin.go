@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// ErrUserBanned - ошибка пользователю запрещено создавать ссылки
+var ErrUserBanned = errors.New("user is banned from creating links")
+
+// UserBan - запрет пользователю создавать ссылки
+type UserBan struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Reason    string    `json:"reason"`
+	BannedBy  uuid.UUID `json:"banned_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BanRepository - хранение запретов
+type BanRepository interface {
+	Ban(ctx context.Context, ban UserBan) error
+	Unban(ctx context.Context, userID uuid.UUID) error
+	IsBanned(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// AdminService - модерация ссылок и пользователей
+type AdminService struct {
+	urlRepo URLRepository
+	bans    BanRepository
+	audit   *AuditService
+	tx      Transactor
+}
+
+// AdminOption - опция модерации
+type AdminOption func(s *AdminService)
+
+// WithAdminAuditService - журнал изменений ссылок и запретов
+func WithAdminAuditService(audit *AuditService) AdminOption {
+	return func(s *AdminService) {
+		s.audit = audit
+	}
+}
+
+// WithAdminTransactor - транзакции хранилища, в них изменения ссылок и запретов записываются вместе с журналом аудита
+func WithAdminTransactor(tx Transactor) AdminOption {
+	return func(s *AdminService) {
+		s.tx = tx
+	}
+}
+
+// NewAdminService конструктор
+func NewAdminService(urlRepo URLRepository, bans BanRepository, opts ...AdminOption) *AdminService {
+	s := &AdminService{urlRepo: urlRepo, bans: bans}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// GetLink любая ссылка с владельцем
+func (s *AdminService) GetLink(ctx context.Context, key HashKey) (*Link, error) {
+	return s.urlRepo.GetLink(ctx, key)
+}
+
+// SetLinkDisabled отключение или включение ссылки
+func (s *AdminService) SetLinkDisabled(ctx context.Context, key HashKey, disabled bool) (*Link, error) {
+	before, err := s.urlRepo.GetLink(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	action := AuditLinkEnable
+	if disabled {
+		action = AuditLinkDisable
+	}
+	var after *Link
+	err = inTx(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.urlRepo.SetDisabled(ctx, key, disabled); err != nil {
+			return err
+		}
+		var err error
+		if after, err = s.urlRepo.GetLink(ctx, key); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, action, uuid.Nil, AuditChange{Key: key, Before: before, After: after})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// DeleteByDomain удаление всех ссылок на домен и его поддомены
+func (s *AdminService) DeleteByDomain(ctx context.Context, host string) ([]HashKey, error) {
+	host, err := normalizeHost(strings.TrimSpace(host))
+	if err != nil || host == "" {
+		return nil, &ValidationError{Field: "domain", Message: "invalid domain"}
+	}
+	var keys []HashKey
+	err = inTx(ctx, s.tx, func(ctx context.Context) error {
+		links, err := s.urlRepo.DeleteByDomain(ctx, host)
+		if err != nil {
+			return err
+		}
+		keys = make([]HashKey, 0, len(links))
+		changes := make([]AuditChange, 0, len(links))
+		for _, before := range links {
+			after := before
+			after.IsDeleted = true
+			keys = append(keys, before.Key)
+			changes = append(changes, AuditChange{Key: before.Key, Before: &before, After: &after})
+		}
+		return s.audit.Record(ctx, AuditLinkDelete, uuid.Nil, changes...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ListUserLinks все ссылки пользователя
+func (s *AdminService) ListUserLinks(ctx context.Context, userID uuid.UUID) ([]Link, error) {
+	return s.urlRepo.GetLinksByUser(ctx, userID)
+}
+
+// BanUser запрет создавать ссылки, запрет и запись журнала сохраняются одной транзакцией
+func (s *AdminService) BanUser(ctx context.Context, userID uuid.UUID, reason string, adminID uuid.UUID) error {
+	return inTx(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.bans.Ban(ctx, UserBan{UserID: userID, Reason: reason, BannedBy: adminID, CreatedAt: time.Now().UTC()}); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditUserBan, adminID, AuditChange{Subject: userID.String(), Details: map[string]string{"reason": reason}})
+	})
+}
+
+// UnbanUser снятие запрета
+func (s *AdminService) UnbanUser(ctx context.Context, userID uuid.UUID) error {
+	return inTx(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.bans.Unban(ctx, userID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditUserUnban, uuid.Nil, AuditChange{Subject: userID.String()})
+	})
+}
@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Права api ключа
+const (
+	ScopeCreate = "create"
+	ScopeRead   = "read"
+	ScopeDelete = "delete"
+	ScopeStats  = "stats"
+	// ScopeAdmin - административный api, действует только для ключей администраторов
+	ScopeAdmin = "admin"
+)
+
+// APIKeyScopes - права api ключей по умолчанию, admin выдаётся только явно
+var APIKeyScopes = []string{ScopeCreate, ScopeRead, ScopeDelete, ScopeStats}
+
+// apiKeyTokenPrefix - начало каждого api ключа, по нему ключ отличается от jwt
+const apiKeyTokenPrefix = "sk_"
+
+// ErrAPIKeyNotFound - ошибка ключ не найден
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// ErrInvalidAPIKey - ошибка ключ неверный или отозван
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKey - долгоживущий ключ для интеграций, сам ключ не хранится, только его хэш
+type APIKey struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"-"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Hash      string     `json:"-"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope - ключ имеет право
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// APIKeyRepository - хранение api ключей
+type APIKeyRepository interface {
+	Add(ctx context.Context, key APIKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]APIKey, error)
+	Rename(ctx context.Context, id uuid.UUID, userID uuid.UUID, name string) (*APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+}
+
+// IsAPIKeyToken - строка похожа на api ключ
+func IsAPIKeyToken(token string) bool {
+	return strings.HasPrefix(token, apiKeyTokenPrefix)
+}
+
+// APIKeyService - управление api ключами
+type APIKeyService struct {
+	repo  APIKeyRepository
+	audit *AuditService
+	tx    Transactor
+}
+
+// APIKeyOption - опция api ключей
+type APIKeyOption func(s *APIKeyService)
+
+// WithAPIKeyAuditService - журнал выпуска и отзыва ключей
+func WithAPIKeyAuditService(audit *AuditService) APIKeyOption {
+	return func(s *APIKeyService) {
+		s.audit = audit
+	}
+}
+
+// WithAPIKeyTransactor - транзакции хранилища, в них изменения ключей записываются вместе с журналом аудита
+func WithAPIKeyTransactor(tx Transactor) APIKeyOption {
+	return func(s *APIKeyService) {
+		s.tx = tx
+	}
+}
+
+// NewAPIKeyService конструктор
+func NewAPIKeyService(repo APIKeyRepository, opts ...APIKeyOption) *APIKeyService {
+	s := &APIKeyService{repo: repo}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Create выпуск ключа, возвращает сам ключ - он показывается пользователю один раз
+func (s *APIKeyService) Create(ctx context.Context, userID uuid.UUID, name string, scopes []string) (string, *APIKey, error) {
+	if len(scopes) == 0 {
+		scopes = APIKeyScopes
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(APIKeyScopes, scope) && scope != ScopeAdmin {
+			return "", nil, &ValidationError{Field: "scopes", Message: fmt.Sprintf("unknown scope %q", scope)}
+		}
+	}
+
+	prefix, err := randomString(6, hex.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+	token := apiKeyTokenPrefix + prefix + "_" + secret
+
+	key := APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hashAPIKey(token),
+		Scopes:    slices.Clone(scopes),
+		CreatedAt: time.Now().UTC(),
+	}
+	err = inTx(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.Add(ctx, key); err != nil {
+			return err
+		}
+		details := map[string]string{"user_id": userID.String(), "name": key.Name, "prefix": key.Prefix, "scopes": strings.Join(key.Scopes, " ")}
+		return s.audit.Record(ctx, AuditAPIKeyCreate, userID, AuditChange{Subject: key.ID.String(), Details: details})
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &key, nil
+}
+
+// List ключи пользователя
+func (s *APIKeyService) List(ctx context.Context, userID uuid.UUID) ([]APIKey, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Rename переименование ключа
+func (s *APIKeyService) Rename(ctx context.Context, id uuid.UUID, userID uuid.UUID, name string) (*APIKey, error) {
+	var key *APIKey
+	err := inTx(ctx, s.tx, func(ctx context.Context) error {
+		var err error
+		if key, err = s.repo.Rename(ctx, id, userID, name); err != nil {
+			return err
+		}
+		details := map[string]string{"user_id": userID.String(), "name": name}
+		return s.audit.Record(ctx, AuditAPIKeyRename, userID, AuditChange{Subject: id.String(), Details: details})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Revoke отзыв ключа
+func (s *APIKeyService) Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	return inTx(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.Revoke(ctx, id, userID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditAPIKeyRevoke, userID, AuditChange{Subject: id.String(), Details: map[string]string{"user_id": userID.String()}})
+	})
+}
+
+// Authenticate проверка ключа
+func (s *APIKeyService) Authenticate(ctx context.Context, token string) (*APIKey, error) {
+	rest, ok := strings.CutPrefix(token, apiKeyTokenPrefix)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	prefix, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.repo.GetByPrefix(ctx, prefix)
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil || subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashAPIKey(token))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// hashAPIKey - ключ содержит 256 бит случайных данных, поэтому достаточно sha256 без соли
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return encode(buf), nil
+}
@@ -0,0 +1,196 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Действия журнала аудита
+const (
+	AuditLinkCreate      = "link.create"
+	AuditLinkBatchCreate = "link.batch_create"
+	AuditLinkImport      = "link.import"
+	AuditLinkDelete      = "link.delete"
+	AuditLinkReassign    = "link.reassign"
+	AuditLinkDisable     = "link.disable"
+	AuditLinkEnable      = "link.enable"
+	AuditLinkUpdate      = "link.update"
+
+	AuditUserBan               = "user.ban"
+	AuditUserUnban             = "user.unban"
+	AuditWorkspaceCreate       = "workspace.create"
+	AuditWorkspaceMemberSet    = "workspace.member_set"
+	AuditWorkspaceMemberRemove = "workspace.member_remove"
+	AuditAPIKeyCreate          = "api_key.create"
+	AuditAPIKeyRename          = "api_key.rename"
+	AuditAPIKeyRevoke          = "api_key.revoke"
+)
+
+// Размер страницы журнала аудита
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 500
+)
+
+// AuditActor - кто и откуда выполнил действие
+type AuditActor struct {
+	UserID     uuid.UUID `json:"actor_id"`
+	AuthMethod string    `json:"auth_method"`
+	ClientIP   string    `json:"client_ip,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+}
+
+// AuditActorFunc - определение исполнителя по контексту запроса
+type AuditActorFunc func(ctx context.Context) AuditActor
+
+// AuditEvent - неизменяемая запись журнала аудита, ID возрастает с каждой записью
+type AuditEvent struct {
+	ID     int64   `json:"id"`
+	Action string  `json:"action"`
+	Key    HashKey `json:"key"`
+	// Domain - домен ссылки, пусто - основной домен
+	Domain string `json:"domain,omitempty"`
+	// Subject - идентификатор изменённого объекта, если это не ссылка: пользователя, пространства или api ключа
+	Subject string `json:"subject,omitempty"`
+	AuditActor
+	Before *Link `json:"before"`
+	After  *Link `json:"after"`
+	// Details - подробности изменения объекта, не ссылки
+	Details   map[string]string `json:"details,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// AuditChange - изменение одной ссылки или, с Subject, другого объекта
+type AuditChange struct {
+	Key     HashKey
+	Before  *Link
+	After   *Link
+	Subject string
+	Details map[string]string
+}
+
+// domain домен изменённой ссылки
+func (c AuditChange) domain() string {
+	if c.After != nil {
+		return c.After.Domain
+	}
+	if c.Before != nil {
+		return c.Before.Domain
+	}
+	return ""
+}
+
+// AuditFilter - отбор записей журнала, пустые поля не учитываются
+type AuditFilter struct {
+	ActorID uuid.UUID
+	Action  string
+	Key     HashKey
+	// Domain - домен ссылки, вместе с Key учитывается и пустой: ключ задаёт ссылку только в пределах домена
+	Domain string
+	Since  time.Time
+	Until  time.Time
+	// Cursor - записи с ID меньше курсора, 0 - с последней
+	Cursor int64
+	Limit  int
+}
+
+// AuditPage - страница журнала, NextCursor 0 - страница последняя
+type AuditPage struct {
+	Events     []AuditEvent `json:"events"`
+	NextCursor int64        `json:"next_cursor,omitempty"`
+}
+
+// AuditRepository - хранение журнала аудита, записи только добавляются
+type AuditRepository interface {
+	// Append добавление записей, ID назначает хранилище
+	Append(ctx context.Context, events []AuditEvent) error
+	// Query записи по фильтру от новых к старым, не больше Limit
+	Query(ctx context.Context, filter AuditFilter) ([]AuditEvent, error)
+}
+
+// AuditService - журнал изменений ссылок, запретов, участников пространств и api ключей
+type AuditService struct {
+	repo  AuditRepository
+	actor AuditActorFunc
+}
+
+// NewAuditService конструктор
+func NewAuditService(repo AuditRepository, actor AuditActorFunc) *AuditService {
+	return &AuditService{repo: repo, actor: actor}
+}
+
+// Record запись изменений от имени исполнителя из контекста, userID - если исполнитель в контексте не определён
+func (s *AuditService) Record(ctx context.Context, action string, userID uuid.UUID, changes ...AuditChange) error {
+	if s == nil || len(changes) == 0 {
+		return nil
+	}
+	actor := s.actor(ctx)
+	if actor.UserID == uuid.Nil {
+		actor.UserID = userID
+	}
+	now := time.Now().UTC()
+	events := make([]AuditEvent, 0, len(changes))
+	for _, c := range changes {
+		events = append(events, AuditEvent{
+			Action:     action,
+			Key:        c.Key,
+			Domain:     c.domain(),
+			Subject:    c.Subject,
+			AuditActor: actor,
+			Before:     c.Before,
+			After:      c.After,
+			Details:    c.Details,
+			CreatedAt:  now,
+		})
+	}
+	if err := s.repo.Append(ctx, events); err != nil {
+		return fmt.Errorf("cannot write audit log: %w", err)
+	}
+	return nil
+}
+
+// Query страница журнала
+func (s *AuditService) Query(ctx context.Context, filter AuditFilter) (*AuditPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditPageSize
+	}
+	if filter.Limit > MaxAuditPageSize {
+		filter.Limit = MaxAuditPageSize
+	}
+	limit := filter.Limit
+	// лишняя запись - признак следующей страницы
+	filter.Limit++
+	events, err := s.repo.Query(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	page := &AuditPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		page.NextCursor = page.Events[limit-1].ID
+	}
+	return page, nil
+}
+
+// Match запись подходит под фильтр, для хранилищ без запросов
+func (f AuditFilter) Match(e AuditEvent) bool {
+	switch {
+	case f.Cursor > 0 && e.ID >= f.Cursor:
+		return false
+	case f.ActorID != uuid.Nil && e.UserID != f.ActorID:
+		return false
+	case f.Action != "" && e.Action != f.Action:
+		return false
+	case f.Key != "" && e.Key != f.Key:
+		return false
+	case (f.Key != "" || f.Domain != "") && e.Domain != f.Domain:
+		return false
+	case !f.Since.IsZero() && e.CreatedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.CreatedAt.Before(f.Until):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package domain
+
+import "errors"
+
+// ErrorCode - стабильный машиночитаемый код ошибки, одинаковый в http и grpc ответах
+type ErrorCode string
+
+// Коды ошибок
+const (
+	CodeInvalidRequest        ErrorCode = "invalid_request"
+	CodeValidationFailed      ErrorCode = "validation_failed"
+	CodeInvalidURL            ErrorCode = "invalid_url"
+	CodePayloadTooLarge       ErrorCode = "payload_too_large"
+	CodeUnauthorized          ErrorCode = "unauthorized"
+	CodeInvalidCredentials    ErrorCode = "invalid_credentials"
+	CodeForbidden             ErrorCode = "forbidden"
+	CodeInsufficientScope     ErrorCode = "insufficient_scope"
+	CodeAdminRequired         ErrorCode = "admin_required"
+	CodeUserBanned            ErrorCode = "user_banned"
+	CodeDomainNotAllowed      ErrorCode = "domain_not_allowed"
+	CodeNotFound              ErrorCode = "not_found"
+	CodeMethodNotAllowed      ErrorCode = "method_not_allowed"
+	CodeLinkNotFound          ErrorCode = "link_not_found"
+	CodeLinkDeleted           ErrorCode = "link_deleted"
+	CodeLinkExpired           ErrorCode = "link_expired"
+	CodeLinkDisabled          ErrorCode = "link_disabled"
+	CodeURLAlreadyExists      ErrorCode = "url_already_exists"
+	CodeUserNotFound          ErrorCode = "user_not_found"
+	CodeUserAlreadyExists     ErrorCode = "user_already_exists"
+	CodeIdentityAlreadyLinked ErrorCode = "identity_already_linked"
+	CodeNotAnonymous          ErrorCode = "not_anonymous"
+	CodeAPIKeyNotFound        ErrorCode = "api_key_not_found"
+	CodeWorkspaceNotFound     ErrorCode = "workspace_not_found"
+	CodeMemberNotFound        ErrorCode = "member_not_found"
+	CodeLastOwner             ErrorCode = "last_owner"
+	CodeImportJobNotFound     ErrorCode = "import_job_not_found"
+	CodeImportInterrupted     ErrorCode = "import_interrupted"
+	CodeFeatureUnavailable    ErrorCode = "feature_unavailable"
+	CodeRateLimited           ErrorCode = "rate_limited"
+	CodeInternal              ErrorCode = "internal"
+)
+
+// errorCodes - коды ошибок сервисов
+var errorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrInvalidURL, CodeInvalidURL},
+	{ErrInvalidCredentials, CodeInvalidCredentials},
+	{ErrInvalidAPIKey, CodeUnauthorized},
+	{ErrForbidden, CodeForbidden},
+	{ErrUserBanned, CodeUserBanned},
+	{ErrDomainNotAllowed, CodeDomainNotAllowed},
+	{ErrURLNotFound, CodeLinkNotFound},
+	{ErrURLDeleted, CodeLinkDeleted},
+	{ErrURLExpired, CodeLinkExpired},
+	{ErrURLDisabled, CodeLinkDisabled},
+	{ErrUserNotFound, CodeUserNotFound},
+	{ErrUserAlreadyExists, CodeUserAlreadyExists},
+	{ErrIdentityAlreadyLinked, CodeIdentityAlreadyLinked},
+	{ErrNotAnonymous, CodeNotAnonymous},
+	{ErrAPIKeyNotFound, CodeAPIKeyNotFound},
+	{ErrWorkspaceNotFound, CodeWorkspaceNotFound},
+	{ErrMemberNotFound, CodeMemberNotFound},
+	{ErrLastOwner, CodeLastOwner},
+	{ErrImportJobNotFound, CodeImportJobNotFound},
+	{ErrImportUnavailable, CodeFeatureUnavailable},
+	{ErrDefaultParamsUnavailable, CodeFeatureUnavailable},
+}
+
+// FieldError - ошибка значения одного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ErrorCodeOf код ошибки сервиса, CodeInternal если ошибка не относится к клиенту
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	validationErr := &ValidationError{}
+	if errors.As(err, &validationErr) {
+		return CodeValidationFailed
+	}
+	dupErr := &ErrURLAlreadyExists{}
+	if errors.As(err, &dupErr) {
+		return CodeURLAlreadyExists
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return CodeInternal
+}
+
+// FieldErrors ошибки полей из всех ValidationError, в том числе объединённых errors.Join
+func FieldErrors(err error) []FieldError {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *ValidationError:
+		return []FieldError{{Field: e.Field, Message: e.Message}}
+	case interface{ Unwrap() []error }:
+		var res []FieldError
+		for _, err := range e.Unwrap() {
+			res = append(res, FieldErrors(err)...)
+		}
+		return res
+	}
+	return FieldErrors(errors.Unwrap(err))
+}
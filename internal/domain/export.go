@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+// ExportEntry - ссылка для выгрузки
+type ExportEntry struct {
+	Key HashKey `json:"key"`
+	// Domain - брендовый домен ссылки, пусто - основной домен
+	Domain      string     `json:"domain,omitempty"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	IsDeleted   bool       `json:"is_deleted"`
+	// Clicks - число переходов, nil - хранилище переходы не считает
+	Clicks *int64 `json:"clicks,omitempty"`
+}
+
+// Export выгрузка всех ссылок владельца от старых к новым, включая удалённые, каждая ссылка передаётся в fn по мере чтения.
+// Для рабочего пространства нужно быть его участником.
+func (r *ShortenerService) Export(ctx context.Context, owner Owner, fn func(e ExportEntry) error) error {
+	if owner.WorkspaceID != uuid.Nil {
+		if err := authorizeWorkspace(ctx, r.workspaces, owner.WorkspaceID, owner.UserID, RoleViewer); err != nil {
+			return err
+		}
+	}
+	return r.urlRepo.Export(ctx, owner, fn)
+}
@@ -0,0 +1,380 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Результаты обработки строк импорта
+const (
+	ImportCreated  ImportStatus = "created"
+	ImportConflict ImportStatus = "conflict"
+	ImportInvalid  ImportStatus = "invalid"
+)
+
+// Состояния задачи импорта
+const (
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+)
+
+// ImportChunkSize - количество строк, сохраняемых одним запросом
+const ImportChunkSize = 500
+
+// Ограничения ключей и тегов ссылок
+const (
+	MaxLinkKeyLength = 64
+	MaxTagLength     = 64
+	MaxLinkTags      = 20
+)
+
+// ErrImportJobNotFound - ошибка задача импорта не найдена или принадлежит другому пользователю
+var ErrImportJobNotFound = errors.New("import job not found")
+
+// ErrImportUnavailable - ошибка хранилище задач импорта не настроено
+var ErrImportUnavailable = errors.New("import is not available")
+
+// ImportStatus - результат обработки строки импорта
+type ImportStatus string
+
+// ImportRow - строка файла импорта, Err - строку не удалось разобрать
+type ImportRow struct {
+	Key    HashKey
+	URL    string
+	Expiry string
+	Tags   []string
+	Err    error
+}
+
+// ImportItem - проверенная строка импорта для сохранения
+type ImportItem struct {
+	Key       HashKey
+	URL       url.URL
+	ExpiresAt *time.Time
+	Tags      []string
+}
+
+// ImportResult - результат обработки строки, Row - номер строки с 1 без заголовка
+type ImportResult struct {
+	Row    int64
+	Key    HashKey
+	Status ImportStatus
+	Error  string
+}
+
+// ImportJob - задача импорта, Processed - сколько строк от начала файла уже обработано и сохранено
+type ImportJob struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	WorkspaceID uuid.UUID `json:"workspace_id,omitempty"`
+	Status      string    `json:"status"`
+	Processed   int64     `json:"processed"`
+	Created     int64     `json:"created"`
+	Conflicts   int64     `json:"conflicts"`
+	Invalid     int64     `json:"invalid"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ImportJobRepository - хранение задач импорта
+type ImportJobRepository interface {
+	Create(ctx context.Context, job ImportJob) error
+	// Get задача по идентификатору, ErrImportJobNotFound если её нет
+	Get(ctx context.Context, id uuid.UUID) (*ImportJob, error)
+	Update(ctx context.Context, job ImportJob) error
+}
+
+// ImportSource - следующая строка импорта, io.EOF - строки закончились
+type ImportSource func() (ImportRow, error)
+
+// WithImportJobRepository - задачи импорта для продолжения прерванной загрузки
+func WithImportJobRepository(jobs ImportJobRepository) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.importJobs = jobs
+	}
+}
+
+// WithImportRateLimit - лимит строк импорта на пользователя, общий с пакетным созданием ссылок.
+// Соединение с клиентом уже открыто, поэтому при превышении импорт не прерывается, а ждёт.
+func WithImportRateLimit(limiter RateLimiter, limit RateLimit) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.importLimiter = limiter
+		s.importLimit = limit
+	}
+}
+
+// StartImport новая задача импорта или продолжение задачи пользователя с идентификатором jobID
+func (r *ShortenerService) StartImport(ctx context.Context, owner Owner, jobID uuid.UUID) (*ImportJob, error) {
+	if r.importJobs == nil {
+		return nil, ErrImportUnavailable
+	}
+	if err := r.authorizeCreate(ctx, owner); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if jobID == uuid.Nil {
+		job := &ImportJob{
+			ID:          uuid.New(),
+			UserID:      owner.UserID,
+			WorkspaceID: owner.WorkspaceID,
+			Status:      ImportJobRunning,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		return job, r.importJobs.Create(ctx, *job)
+	}
+
+	job, err := r.GetImportJob(ctx, jobID, owner.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if job.WorkspaceID != owner.WorkspaceID {
+		return nil, &ValidationError{Field: "workspace_id", Message: "does not match import job"}
+	}
+	job.Status = ImportJobRunning
+	job.UpdatedAt = now
+	return job, r.importJobs.Update(ctx, *job)
+}
+
+// GetImportJob задача импорта пользователя
+func (r *ShortenerService) GetImportJob(ctx context.Context, jobID uuid.UUID, userID uuid.UUID) (*ImportJob, error) {
+	if r.importJobs == nil {
+		return nil, ErrImportUnavailable
+	}
+	job, err := r.importJobs.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, ErrImportJobNotFound
+	}
+	return job, nil
+}
+
+// Import сохранение строк порциями по ImportChunkSize, задача обновляется в одной транзакции с порцией,
+// затем результаты передаются в emit. Строки, обработанные в задаче раньше, пропускаются.
+func (r *ShortenerService) Import(ctx context.Context, job *ImportJob, next ImportSource, emit func(results []ImportResult) error) error {
+	owner := Owner{UserID: job.UserID, WorkspaceID: job.WorkspaceID}
+	params, err := r.newLinkParams(ctx, owner.UserID)
+	if err != nil {
+		return err
+	}
+	results := make([]ImportResult, 0, ImportChunkSize)
+	items := make([]ImportItem, 0, ImportChunkSize)
+	var row int64
+
+	flush := func() error {
+		if len(results) == 0 {
+			return nil
+		}
+		if err := r.waitImportLimit(ctx, owner, len(results)); err != nil {
+			return err
+		}
+		// задача меняется только после фиксации транзакции, иначе при ошибке счётчики учли бы откаченную порцию
+		updated := *job
+		err := inTx(ctx, r.tx, func(ctx context.Context) error {
+			if err := r.importChunk(ctx, owner, params, items, results); err != nil {
+				return err
+			}
+			for _, res := range results {
+				switch res.Status {
+				case ImportCreated:
+					updated.Created++
+				case ImportConflict:
+					updated.Conflicts++
+				case ImportInvalid:
+					updated.Invalid++
+				}
+			}
+			updated.Processed = row
+			updated.UpdatedAt = time.Now().UTC()
+			return r.importJobs.Update(ctx, updated)
+		})
+		if err != nil {
+			return err
+		}
+		*job = updated
+		if err := emit(results); err != nil {
+			return err
+		}
+		results, items = results[:0], items[:0]
+		return nil
+	}
+
+	for {
+		in, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		row++
+		if row <= job.Processed {
+			continue
+		}
+
+		item, err := r.prepareImportRow(in)
+		if err != nil {
+			results = append(results, ImportResult{Row: row, Key: in.Key, Status: ImportInvalid, Error: err.Error()})
+		} else {
+			results = append(results, ImportResult{Row: row, Key: item.Key})
+			items = append(items, item)
+		}
+		if len(results) == ImportChunkSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	job.Status = ImportJobCompleted
+	job.UpdatedAt = time.Now().UTC()
+	return r.importJobs.Update(ctx, *job)
+}
+
+// waitImportLimit ожидание лимита на rows строк, ключ - как у пакетного создания ссылок пользователем
+func (r *ShortenerService) waitImportLimit(ctx context.Context, owner Owner, rows int) error {
+	if r.importLimiter == nil || !r.importLimit.Enabled() {
+		return nil
+	}
+	err := WaitRateLimit(ctx, r.importLimiter, "batch:user:"+owner.UserID.String(), r.importLimit, rows)
+	if err != nil && ctx.Err() != nil {
+		return err
+	}
+	// недоступность хранилища лимитов импорт не останавливает, как и обычные запросы
+	return nil
+}
+
+// importChunk сохранение порции вместе с записью в журнал, результаты без статуса получают created или conflict
+func (r *ShortenerService) importChunk(ctx context.Context, owner Owner, params LinkParams, items []ImportItem, results []ImportResult) error {
+	// повтор ключа внутри порции - конфликт, в хранилище уходит первое вхождение
+	seen := make(map[HashKey]struct{}, len(items))
+	unique := make([]ImportItem, 0, len(items))
+	for _, item := range items {
+		if _, ok := seen[item.Key]; ok {
+			continue
+		}
+		seen[item.Key] = struct{}{}
+		unique = append(unique, item)
+	}
+
+	created := map[HashKey]struct{}{}
+	if len(unique) > 0 {
+		keys, err := r.urlRepo.Import(ctx, unique, owner, params)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			created[key] = struct{}{}
+		}
+	}
+
+	changes := make([]AuditChange, 0, len(created))
+	for _, item := range unique {
+		if _, ok := created[item.Key]; ok {
+			link := newLink(ctx, item.Key, item.URL, owner, params)
+			link.ExpiresAt = item.ExpiresAt
+			link.Tags = item.Tags
+			changes = append(changes, AuditChange{Key: item.Key, After: link})
+		}
+	}
+	for i, res := range results {
+		if res.Status != "" {
+			continue
+		}
+		if _, ok := created[res.Key]; ok {
+			results[i].Status = ImportCreated
+			// ключ создан первой строкой с ним, повторы - конфликты
+			delete(created, res.Key)
+			continue
+		}
+		results[i].Status = ImportConflict
+		results[i].Error = "key or url already exists"
+	}
+	return r.audit.Record(ctx, AuditLinkImport, owner.UserID, changes...)
+}
+
+// prepareImportRow проверка строки импорта, пустой ключ генерируется
+func (r *ShortenerService) prepareImportRow(in ImportRow) (ImportItem, error) {
+	var item ImportItem
+	if in.Err != nil {
+		return item, in.Err
+	}
+
+	item.Key = strings.TrimSpace(in.Key)
+	if item.Key == "" {
+		item.Key = r.genShortURLToken()
+	} else if err := ValidateLinkKey(item.Key); err != nil {
+		return item, err
+	}
+
+	var err error
+	if item.URL, err = r.parseURL(in.URL); err != nil {
+		return item, err
+	}
+
+	if expiry := strings.TrimSpace(in.Expiry); expiry != "" {
+		expiresAt, err := time.Parse(time.RFC3339, expiry)
+		if err != nil {
+			return item, &ValidationError{Field: "expiry", Message: "must be RFC 3339 time"}
+		}
+		if !expiresAt.After(time.Now()) {
+			return item, &ValidationError{Field: "expiry", Message: "must be in the future"}
+		}
+		expiresAt = expiresAt.UTC()
+		item.ExpiresAt = &expiresAt
+	}
+
+	item.Tags, err = NormalizeTags(in.Tags)
+	return item, err
+}
+
+// reservedLinkKeys - первые сегменты постоянных маршрутов сервиса, ссылка с таким ключом была бы недоступна
+var reservedLinkKeys = []HashKey{"api", "debug", "ping"}
+
+// ValidateLinkKey ключ, заданный пользователем: латинские буквы, цифры, - и _, кроме занятых маршрутами сервиса
+func ValidateLinkKey(key HashKey) error {
+	if len(key) > MaxLinkKeyLength {
+		return &ValidationError{Field: "key", Message: fmt.Sprintf("must be at most %d characters", MaxLinkKeyLength)}
+	}
+	if slices.Contains(reservedLinkKeys, key) {
+		return &ValidationError{Field: "key", Message: "is reserved"}
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return &ValidationError{Field: "key", Message: "must contain only letters, digits, - and _"}
+		}
+	}
+	return nil
+}
+
+// NormalizeTags теги без пробелов по краям, в нижнем регистре и без повторов
+func NormalizeTags(tags []string) ([]string, error) {
+	res := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if len(tag) > MaxTagLength {
+			return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("tag must be at most %d characters", MaxTagLength)}
+		}
+		if !slices.Contains(res, tag) {
+			res = append(res, tag)
+		}
+	}
+	if len(res) > MaxLinkTags {
+		return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("must be at most %d tags", MaxLinkTags)}
+	}
+	return res, nil
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrDomainNotAllowed - ошибка пользователю не разрешено создавать ссылки на брендовом домене
+var ErrDomainNotAllowed = errors.New("domain not allowed")
+
+// DomainGrant - разрешение пользователю создавать ссылки на брендовом домене
+type DomainGrant struct {
+	Domain    string    `json:"domain"`
+	UserID    uuid.UUID `json:"user_id"`
+	GrantedBy uuid.UUID `json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DomainRepository - хранение разрешений на брендовые домены
+type DomainRepository interface {
+	Grant(ctx context.Context, grant DomainGrant) error
+	Revoke(ctx context.Context, host string, userID uuid.UUID) error
+	IsAllowed(ctx context.Context, host string, userID uuid.UUID) (bool, error)
+	// ListByUser домены, на которых пользователь может создавать ссылки
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]string, error)
+}
+
+type linkDomainContext struct{}
+
+// WithLinkDomain домен коротких ссылок запроса, пустая строка - основной домен.
+// Ключи ссылок уникальны в пределах домена, хранилище ищет и создаёт ссылки по ключу на этом домене.
+func WithLinkDomain(ctx context.Context, host string) context.Context {
+	return context.WithValue(ctx, &linkDomainContext{}, host)
+}
+
+// LinkDomainFromCtx домен коротких ссылок запроса, пустая строка - основной домен
+func LinkDomainFromCtx(ctx context.Context) string {
+	host, _ := ctx.Value(&linkDomainContext{}).(string)
+	return host
+}
+
+// DomainService - брендовые домены коротких ссылок и доступ пользователей к ним.
+// Ссылки основного домена хранятся с пустым доменом и доступны всем.
+type DomainService struct {
+	hosts map[string]struct{}
+	repo  DomainRepository
+}
+
+// NewDomainService конструктор, hosts - обслуживаемые брендовые домены
+func NewDomainService(repo DomainRepository, hosts ...string) *DomainService {
+	s := &DomainService{hosts: make(map[string]struct{}, len(hosts)), repo: repo}
+	for _, host := range hosts {
+		if host, err := normalizeHost(strings.TrimSpace(host)); err == nil && host != "" {
+			s.hosts[host] = struct{}{}
+		}
+	}
+	return s
+}
+
+// Hosts брендовые домены по алфавиту
+func (s *DomainService) Hosts() []string {
+	if s == nil {
+		return nil
+	}
+	hosts := make([]string, 0, len(s.hosts))
+	for host := range s.hosts {
+		hosts = append(hosts, host)
+	}
+	slices.Sort(hosts)
+	return hosts
+}
+
+// Resolve брендовый домен по заголовку Host, порт отбрасывается, неизвестный хост - основной домен
+func (s *DomainService) Resolve(hostport string) string {
+	if s == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	host, err = normalizeHost(host)
+	if err != nil {
+		return ""
+	}
+	if _, ok := s.hosts[host]; ok {
+		return host
+	}
+	return ""
+}
+
+// Lookup брендовый домен по имени, пустое имя - основной домен, неизвестный домен - ошибка
+func (s *DomainService) Lookup(host string) (string, error) {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return "", nil
+	}
+	if resolved := s.Resolve(host); resolved != "" {
+		return resolved, nil
+	}
+	return "", &ValidationError{Field: "domain", Message: "unknown domain"}
+}
+
+// IsAllowed может ли пользователь создавать ссылки на домене, основной домен доступен всем
+func (s *DomainService) IsAllowed(ctx context.Context, host string, userID uuid.UUID) (bool, error) {
+	if host == "" {
+		return true, nil
+	}
+	if s == nil {
+		return false, nil
+	}
+	return s.repo.IsAllowed(ctx, host, userID)
+}
+
+// ListByUser брендовые домены, на которых пользователь может создавать ссылки
+func (s *DomainService) ListByUser(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	hosts, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// доменов, убранных из конфигурации, в списке нет
+	return slices.DeleteFunc(hosts, func(host string) bool {
+		_, ok := s.hosts[host]
+		return !ok
+	}), nil
+}
+
+// Grant разрешение пользователю создавать ссылки на брендовом домене
+func (s *DomainService) Grant(ctx context.Context, host string, userID uuid.UUID, adminID uuid.UUID) (*DomainGrant, error) {
+	host, err := s.Lookup(host)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" {
+		return nil, &ValidationError{Field: "domain", Message: "required"}
+	}
+	grant := DomainGrant{Domain: host, UserID: userID, GrantedBy: adminID, CreatedAt: time.Now().UTC()}
+	if err = s.repo.Grant(ctx, grant); err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// Revoke отзыв разрешения, уже созданные ссылки остаются на домене
+func (s *DomainService) Revoke(ctx context.Context, host string, userID uuid.UUID) error {
+	host, err := normalizeHost(strings.TrimSpace(host))
+	if err != nil || host == "" {
+		return &ValidationError{Field: "domain", Message: "invalid domain"}
+	}
+	return s.repo.Revoke(ctx, host, userID)
+}
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ограничения описания ссылки
+const (
+	MaxLinkTitleLength = 200
+	MaxLinkNoteLength  = 2000
+)
+
+// LinkMeta - описание ссылки для её владельцев: название, заметка и теги, посетителям не показывается
+type LinkMeta struct {
+	Title string   `json:"title,omitempty"`
+	Note  string   `json:"note,omitempty"`
+	Tags  []string `json:"tags"`
+}
+
+// TagRename - переименование тега, если тег To уже есть у ссылки, теги объединяются
+type TagRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// normalize название без пробелов по краям, теги как в NormalizeTags, проверка длины
+func (m LinkMeta) normalize() (LinkMeta, error) {
+	m.Title = strings.TrimSpace(m.Title)
+	if utf8.RuneCountInString(m.Title) > MaxLinkTitleLength {
+		return m, &ValidationError{Field: "title", Message: fmt.Sprintf("must be at most %d characters", MaxLinkTitleLength)}
+	}
+	if utf8.RuneCountInString(m.Note) > MaxLinkNoteLength {
+		return m, &ValidationError{Field: "note", Message: fmt.Sprintf("must be at most %d characters", MaxLinkNoteLength)}
+	}
+	var err error
+	m.Tags, err = NormalizeTags(m.Tags)
+	return m, err
+}
+
+// normalize теги в нижнем регистре, переименование тега в себя - ошибка
+func (t TagRename) normalize() (TagRename, error) {
+	from, err := NormalizeTags([]string{t.From})
+	if err != nil || len(from) == 0 {
+		return t, &ValidationError{Field: "from", Message: fmt.Sprintf("must be a tag of 1 to %d characters", MaxTagLength)}
+	}
+	to, err := NormalizeTags([]string{t.To})
+	if err != nil || len(to) == 0 {
+		return t, &ValidationError{Field: "to", Message: fmt.Sprintf("must be a tag of 1 to %d characters", MaxTagLength)}
+	}
+	if from[0] == to[0] {
+		return t, &ValidationError{Field: "to", Message: "must differ from from"}
+	}
+	return TagRename{From: from[0], To: to[0]}, nil
+}
+
+// Apply теги после переименования без повторов, порядок сохраняется, false - тега From нет
+func (t TagRename) Apply(tags []string) ([]string, bool) {
+	if !slices.Contains(tags, t.From) {
+		return tags, false
+	}
+	res := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag == t.From {
+			tag = t.To
+		}
+		if !slices.Contains(res, tag) {
+			res = append(res, tag)
+		}
+	}
+	return res, true
+}
+
+// SearchTerms слова текста для полнотекстового поиска: буквы и цифры в нижнем регистре без повторов,
+// знаки препинания, в том числе в ссылках, разделяют слова
+func SearchTerms(text string) []string {
+	var terms []string
+	for _, term := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !slices.Contains(terms, term) {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// SetMeta замена названия, заметки и тегов ссылки, доступна тем же, кто может удалить ссылку
+func (r *ShortenerService) SetMeta(ctx context.Context, key HashKey, userID uuid.UUID, meta LinkMeta) (*Link, error) {
+	meta, err := meta.normalize()
+	if err != nil {
+		return nil, err
+	}
+	before, err := r.urlRepo.GetLink(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if before.IsDeleted {
+		return nil, ErrURLNotFound
+	}
+	if err = r.authorizeManage(ctx, before, userID); err != nil {
+		return nil, err
+	}
+
+	return r.updateLink(ctx, before, userID, func(ctx context.Context) error {
+		return r.urlRepo.SetMeta(ctx, key, meta)
+	})
+}
+
+// RenameTag переименование или объединение тега во всех личных ссылках пользователя
+// или в ссылках рабочего пространства для роли editor и выше, возвращает число изменённых ссылок.
+// Каждая изменённая ссылка записывается в журнал аудита.
+func (r *ShortenerService) RenameTag(ctx context.Context, owner Owner, rename TagRename) (int64, error) {
+	rename, err := rename.normalize()
+	if err != nil {
+		return 0, err
+	}
+	if owner.WorkspaceID != uuid.Nil {
+		if err = authorizeWorkspace(ctx, r.workspaces, owner.WorkspaceID, owner.UserID, RoleEditor); err != nil {
+			return 0, err
+		}
+	}
+
+	var updated int64
+	err = inTx(ctx, r.tx, func(ctx context.Context) error {
+		links, err := r.urlRepo.RenameTag(ctx, owner, rename)
+		if err != nil {
+			return err
+		}
+		updated = int64(len(links))
+		changes := make([]AuditChange, 0, len(links))
+		for _, before := range links {
+			after := before
+			after.Tags, _ = rename.Apply(before.Tags)
+			changes = append(changes, AuditChange{Key: before.Key, Before: &before, After: &after})
+		}
+		return r.audit.Record(ctx, AuditLinkUpdate, owner.UserID, changes...)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagRename_Apply(t *testing.T) {
+	rename := TagRename{From: "promo", To: "mail"}
+	tags, ok := rename.Apply([]string{"q2", "promo", "news"})
+	require.True(t, ok)
+	require.Equal(t, []string{"q2", "mail", "news"}, tags)
+
+	tags, ok = rename.Apply([]string{"mail", "q2", "promo"})
+	require.True(t, ok)
+	require.Equal(t, []string{"mail", "q2"}, tags, "merged into the existing tag")
+
+	_, ok = rename.Apply([]string{"q2"})
+	require.False(t, ok)
+}
+
+func TestTagRename_Normalize(t *testing.T) {
+	rename, err := TagRename{From: " Promo ", To: "MAIL"}.normalize()
+	require.NoError(t, err)
+	require.Equal(t, TagRename{From: "promo", To: "mail"}, rename)
+
+	_, err = TagRename{From: "promo", To: "Promo"}.normalize()
+	require.ErrorContains(t, err, "to:")
+	_, err = TagRename{From: " ", To: "mail"}.normalize()
+	require.ErrorContains(t, err, "from:")
+}
+
+func TestLinkMeta_Normalize(t *testing.T) {
+	meta, err := LinkMeta{Title: "  Spring  ", Tags: []string{"Promo", "promo ", ""}}.normalize()
+	require.NoError(t, err)
+	require.Equal(t, LinkMeta{Title: "Spring", Tags: []string{"promo"}}, meta)
+
+	_, err = LinkMeta{Title: strings.Repeat("я", MaxLinkTitleLength+1)}.normalize()
+	require.ErrorContains(t, err, "title:")
+	_, err = LinkMeta{Note: strings.Repeat("x", MaxLinkNoteLength+1)}.normalize()
+	require.ErrorContains(t, err, "note:")
+}
+
+func TestSearchTerms(t *testing.T) {
+	require.Equal(t, []string{"spring", "sale", "https", "example", "com", "docs", "v2", "1"},
+		SearchTerms("Spring sale! https://example.com/docs/v2?sale=1"))
+	require.Equal(t, []string{"весна", "2024"}, SearchTerms("Весна-2024, весна"))
+	require.Empty(t, SearchTerms(" -- "))
+}
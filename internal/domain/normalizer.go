@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultTrackingParams - известные трекинговые параметры, * в конце означает префикс
+var DefaultTrackingParams = []string{"utm_*", "fbclid", "gclid", "yclid"}
+
+// defaultPorts - порты по умолчанию для схем
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// NormalizeOptions - настройки нормализации ссылок
+type NormalizeOptions struct {
+	// StripTracking - удалять трекинговые параметры из query
+	StripTracking bool
+	// TrackingParams - список трекинговых параметров, по умолчанию DefaultTrackingParams
+	TrackingParams []string
+}
+
+// URLNormalizer - приведение ссылок к каноническому виду
+type URLNormalizer struct {
+	opts NormalizeOptions
+}
+
+// NewURLNormalizer конструктор
+func NewURLNormalizer(opts NormalizeOptions) *URLNormalizer {
+	if len(opts.TrackingParams) == 0 {
+		opts.TrackingParams = DefaultTrackingParams
+	}
+	return &URLNormalizer{opts: opts}
+}
+
+// Normalize приведение ссылки к каноническому виду
+func (n *URLNormalizer) Normalize(u url.URL) (url.URL, error) {
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	if u.Host != "" {
+		host, err := normalizeHost(u.Hostname())
+		if err != nil {
+			return u, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+		}
+		port := u.Port()
+		if port == defaultPorts[u.Scheme] {
+			port = ""
+		}
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		if port != "" {
+			host += ":" + port
+		}
+		u.Host = host
+	}
+
+	if u.Opaque == "" && u.Path != "" {
+		escaped := removeDotSegments(u.EscapedPath())
+		unescaped, err := url.PathUnescape(escaped)
+		if err != nil {
+			return u, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+		}
+		u.Path = unescaped
+		u.RawPath = escaped
+	}
+
+	if u.RawQuery != "" {
+		// пары не декодируются и не кодируются заново: ;, % и параметры без значения остаются как есть
+		pairs := splitRawQuery(u.RawQuery)
+		if n.opts.StripTracking {
+			pairs = slices.DeleteFunc(pairs, func(p queryPair) bool {
+				return n.isTrackingParam(p.name)
+			})
+		}
+		slices.SortStableFunc(pairs, func(a, b queryPair) int {
+			return strings.Compare(a.name, b.name)
+		})
+		u.RawQuery = joinRawQuery(pairs)
+	}
+	if u.RawQuery == "" {
+		u.ForceQuery = false
+	}
+
+	return u, nil
+}
+
+// queryPair - пара строки запроса в исходном виде
+type queryPair struct {
+	raw string
+	// name - декодированное имя, если декодировать нельзя - исходное
+	name string
+}
+
+// splitRawQuery пары строки запроса, разделённые &, пустые пары пропускаются
+func splitRawQuery(rawQuery string) []queryPair {
+	var pairs []queryPair
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(raw, "=")
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+		pairs = append(pairs, queryPair{raw: raw, name: name})
+	}
+	return pairs
+}
+
+// joinRawQuery строка запроса из пар
+func joinRawQuery(pairs []queryPair) string {
+	raw := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		raw = append(raw, p.raw)
+	}
+	return strings.Join(raw, "&")
+}
+
+// RemoveRawQueryParam строка запроса без пар с именем name, остальные пары не меняются
+func RemoveRawQueryParam(rawQuery string, name string) string {
+	return joinRawQuery(slices.DeleteFunc(splitRawQuery(rawQuery), func(p queryPair) bool {
+		return p.name == name
+	}))
+}
+
+func (n *URLNormalizer) isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range n.opts.TrackingParams {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == p {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return host, nil
+	}
+	return idna.Lookup.ToASCII(host)
+}
+
+// removeDotSegments удаление "." и ".." из пути по RFC 3986 5.2.4
+func removeDotSegments(p string) string {
+	if !strings.Contains(p, ".") {
+		return p
+	}
+	segments := strings.Split(p, "/")
+	out := make([]string, 0, len(segments))
+	root := 0
+	if strings.HasPrefix(p, "/") {
+		root = 1
+	}
+	for i, s := range segments {
+		last := i == len(segments)-1
+		switch s {
+		case ".":
+			if last {
+				out = append(out, "")
+			}
+		case "..":
+			if len(out) > root {
+				out = out[:len(out)-1]
+			}
+			if last {
+				out = append(out, "")
+			}
+		default:
+			out = append(out, s)
+		}
+	}
+	return strings.Join(out, "/")
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts NormalizeOptions
+		in   string
+		want string
+	}{
+		{
+			name: "already canonical",
+			in:   "https://github.com",
+			want: "https://github.com",
+		},
+		{
+			name: "scheme, host, default port, dot segments and query order",
+			in:   "HTTP://Example.com:80/a/../b?b=1&a=2",
+			want: "http://example.com/b?a=2&b=1",
+		},
+		{
+			name: "https default port",
+			in:   "https://example.com:443/",
+			want: "https://example.com/",
+		},
+		{
+			name: "non default port is kept",
+			in:   "http://example.com:8080/./a/./b/",
+			want: "http://example.com:8080/a/b/",
+		},
+		{
+			name: "idn host",
+			in:   "https://Пример.рф/путь",
+			want: "https://xn--e1afmkfd.xn--p1ai/%D0%BF%D1%83%D1%82%D1%8C",
+		},
+		{
+			name: "ipv6 host",
+			in:   "http://[::1]:80/",
+			want: "http://[::1]/",
+		},
+		{
+			name: "tracking params kept by default",
+			in:   "https://example.com/?utm_source=x&id=1",
+			want: "https://example.com/?id=1&utm_source=x",
+		},
+		{
+			name: "tracking params stripped",
+			opts: NormalizeOptions{StripTracking: true},
+			in:   "https://example.com/?utm_source=x&UTM_medium=y&fbclid=z&id=1",
+			want: "https://example.com/?id=1",
+		},
+		{
+			name: "raw pairs are sorted without re-encoding",
+			in:   "https://example.com/?sig=a%2Fb&flag&b=1;c=2&a=100%",
+			want: "https://example.com/?a=100%&b=1;c=2&flag&sig=a%2Fb",
+		},
+		{
+			name: "same name keeps order",
+			opts: NormalizeOptions{StripTracking: true},
+			in:   "https://example.com/?tag=b&utm_%73ource=x&tag=a",
+			want: "https://example.com/?tag=b&tag=a",
+		},
+		{
+			name: "empty query after strip",
+			opts: NormalizeOptions{StripTracking: true},
+			in:   "https://example.com/page?fbclid=z#top",
+			want: "https://example.com/page#top",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.in)
+			require.NoError(t, err)
+
+			got, err := NewURLNormalizer(tt.opts).Normalize(*u)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.String())
+		})
+	}
+}
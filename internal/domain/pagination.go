@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Размер страницы списка ссылок
+const (
+	DefaultLinkPageSize = 100
+	MaxLinkPageSize     = 1000
+)
+
+// LinkCursor - позиция в списке ссылок: время создания и ключ последней ссылки страницы
+type LinkCursor struct {
+	CreatedAt time.Time
+	Key       HashKey
+}
+
+// LinkQuery - параметры списка ссылок, сортировка по времени создания
+type LinkQuery struct {
+	// Limit - размер страницы, в репозитории 0 - без ограничения
+	Limit int
+	// After - ссылки после курсора, nil - с начала списка
+	After *LinkCursor
+	// Ascending - от старых к новым, по умолчанию от новых к старым
+	Ascending bool
+	// Domain - только ссылки на домен и его поддомены
+	Domain string
+	// Search - подстрока оригинальной ссылки или ключа без учёта регистра
+	Search string
+	// Tags - только ссылки со всеми тегами
+	Tags []string
+	// Text - полнотекстовый поиск по названию, заметке и оригинальной ссылке: ссылка содержит все слова SearchTerms
+	Text string
+}
+
+// LinkPage - страница списка ссылок, NextCursor пустой - страница последняя
+type LinkPage struct {
+	URLs       []URLEntry
+	NextCursor string
+}
+
+// Follows ссылка идёт в списке после курсора
+func (c LinkCursor) Follows(createdAt time.Time, key HashKey, ascending bool) bool {
+	cmp := createdAt.Compare(c.CreatedAt)
+	if cmp == 0 {
+		cmp = strings.Compare(key, c.Key)
+	}
+	if ascending {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+// String непрозрачное представление курсора для клиентов
+func (c LinkCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.Key))
+}
+
+// ParseLinkCursor разбор курсора, полученного от клиента
+func ParseLinkCursor(s string) (*LinkCursor, error) {
+	invalid := &ValidationError{Field: "cursor", Message: "invalid cursor"}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	nanos, key, ok := strings.Cut(string(b), ":")
+	if !ok || key == "" {
+		return nil, invalid
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+	return &LinkCursor{CreatedAt: time.Unix(0, n).UTC(), Key: key}, nil
+}
+
+// normalize размер страницы и домен в каноническом виде
+func (q LinkQuery) normalize() (LinkQuery, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultLinkPageSize
+	}
+	if q.Limit > MaxLinkPageSize {
+		q.Limit = MaxLinkPageSize
+	}
+	if q.Domain != "" {
+		host, err := normalizeHost(strings.TrimSpace(q.Domain))
+		if err != nil || host == "" {
+			return q, &ValidationError{Field: "domain", Message: "invalid domain"}
+		}
+		q.Domain = host
+	}
+	q.Search = strings.TrimSpace(q.Search)
+	tags, err := NormalizeTags(q.Tags)
+	if err != nil {
+		return q, err
+	}
+	q.Tags = tags
+	q.Text = strings.Join(SearchTerms(q.Text), " ")
+	return q, nil
+}
+
+// page страница из списка, запрошенного с лимитом на одну ссылку больше
+func (q LinkQuery) page(entries []URLEntry) *LinkPage {
+	page := &LinkPage{URLs: entries}
+	if len(entries) > q.Limit {
+		page.URLs = entries[:q.Limit]
+		last := page.URLs[q.Limit-1]
+		page.NextCursor = LinkCursor{CreatedAt: last.CreatedAt, Key: last.Key}.String()
+	}
+	return page
+}
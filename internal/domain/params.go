@@ -0,0 +1,217 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ограничения параметров запроса ссылки
+const (
+	MaxLinkParams           = 20
+	MaxLinkParamValueLength = 500
+)
+
+// MergePolicy - как параметры ссылки объединяются с параметрами адреса назначения
+type MergePolicy string
+
+// Правила объединения, пустое - MergeOverride
+const (
+	// MergeOverride - значение ссылки заменяет параметр адреса
+	MergeOverride MergePolicy = "override"
+	// MergeKeep - параметр адреса остаётся, значение ссылки добавляется, только если параметра нет
+	MergeKeep MergePolicy = "keep"
+	// MergeAppend - значение ссылки добавляется к значениям параметра адреса
+	MergeAppend MergePolicy = "append"
+)
+
+// Подстановки в значениях параметров, заполняются данными перехода
+const (
+	ParamKey          = "key"
+	ParamReferrerHost = "referrer_host"
+	ParamDevice       = "device"
+	ParamLanguage     = "language"
+)
+
+var paramPlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// ErrDefaultParamsUnavailable - ошибка хранилище параметров по умолчанию не настроено
+var ErrDefaultParamsUnavailable = errors.New("default params are not available")
+
+// LinkParams - параметры запроса, которые дописываются к адресу назначения при переходе, например utm_source.
+// Значения могут содержать подстановки {key}, {referrer_host}, {device} и {language},
+// параметр, значение которого после подстановки пустое, не добавляется.
+type LinkParams struct {
+	Query map[string]string `json:"query,omitempty"`
+	Merge MergePolicy       `json:"merge,omitempty"`
+}
+
+// DefaultParamsRepository - параметры пользователя, которые копируются в каждую его новую ссылку
+type DefaultParamsRepository interface {
+	// GetDefaultParams параметры пользователя, без настроек - пустые параметры
+	GetDefaultParams(ctx context.Context, userID uuid.UUID) (LinkParams, error)
+	SetDefaultParams(ctx context.Context, userID uuid.UUID, params LinkParams) error
+}
+
+// WithDefaultParamsRepository - параметры по умолчанию для новых ссылок пользователей
+func WithDefaultParamsRepository(repo DefaultParamsRepository) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.defaultParams = repo
+	}
+}
+
+// Validate проверка имён, значений, подстановок и правила объединения
+func (p LinkParams) Validate() error {
+	var errs []error
+	switch p.Merge {
+	case "", MergeOverride, MergeKeep, MergeAppend:
+	default:
+		errs = append(errs, &ValidationError{Field: "merge", Message: "must be one of override, keep, append"})
+	}
+	if len(p.Query) > MaxLinkParams {
+		errs = append(errs, &ValidationError{Field: "query", Message: fmt.Sprintf("must be at most %d params", MaxLinkParams)})
+	}
+	for name, value := range p.Query {
+		field := "query." + name
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, &ValidationError{Field: "query", Message: "parameter name required"})
+		}
+		if utf8.RuneCountInString(value) > MaxLinkParamValueLength {
+			errs = append(errs, &ValidationError{Field: field, Message: fmt.Sprintf("must be at most %d characters", MaxLinkParamValueLength)})
+		}
+		for _, m := range paramPlaceholder.FindAllStringSubmatch(value, -1) {
+			switch m[1] {
+			case ParamKey, ParamReferrerHost, ParamDevice, ParamLanguage:
+			default:
+				errs = append(errs, &ValidationError{Field: field, Message: "unknown placeholder " + m[0]})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Dynamic значения зависят от посетителя, такой редирект нельзя кешировать
+func (p LinkParams) Dynamic() bool {
+	for _, value := range p.Query {
+		for _, m := range paramPlaceholder.FindAllStringSubmatch(value, -1) {
+			if m[1] != ParamKey {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apply добавление параметров к адресу назначения с подстановкой данных перехода.
+// Остальные пары строки запроса не декодируются и не кодируются заново, их порядок сохраняется,
+// заменяемый параметр остаётся на месте первого вхождения, новые добавляются в конец по имени.
+func (p LinkParams) apply(u *url.URL, key HashKey, v Visit) {
+	if len(p.Query) == 0 {
+		return
+	}
+	vars := map[string]string{ParamKey: key}
+	if ref, err := url.Parse(v.Referrer); err == nil {
+		vars[ParamReferrerHost] = strings.ToLower(ref.Hostname())
+	}
+	if devices := visitDevices(v.UserAgent); len(devices) > 0 {
+		vars[ParamDevice] = devices[0]
+	}
+	vars[ParamLanguage] = preferredLanguage(v.AcceptLanguage)
+
+	names := make([]string, 0, len(p.Query))
+	for name := range p.Query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := splitRawQuery(u.RawQuery)
+	replaced := false
+	var added []string
+	for _, name := range names {
+		value := paramPlaceholder.ReplaceAllStringFunc(p.Query[name], func(m string) string {
+			return vars[m[1:len(m)-1]]
+		})
+		if value == "" {
+			continue
+		}
+		pair := url.QueryEscape(name) + "=" + url.QueryEscape(value)
+		i := slices.IndexFunc(pairs, func(q queryPair) bool { return q.name == name })
+		switch {
+		case i < 0 || p.Merge == MergeAppend:
+			added = append(added, pair)
+		case p.Merge == MergeKeep:
+		default:
+			pairs[i].raw = pair
+			rest := slices.DeleteFunc(pairs[i+1:], func(q queryPair) bool { return q.name == name })
+			pairs = pairs[:i+1+len(rest)]
+			replaced = true
+		}
+	}
+
+	rawQuery := u.RawQuery
+	if replaced {
+		rawQuery = joinRawQuery(pairs)
+	}
+	for _, pair := range added {
+		if rawQuery != "" {
+			rawQuery += "&"
+		}
+		rawQuery += pair
+	}
+	u.RawQuery = rawQuery
+}
+
+// SetParams замена параметров запроса ссылки, доступна тем же, кто может удалить ссылку
+func (r *ShortenerService) SetParams(ctx context.Context, key HashKey, userID uuid.UUID, params LinkParams) (*Link, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	before, err := r.urlRepo.GetLink(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if before.IsDeleted {
+		return nil, ErrURLNotFound
+	}
+	if err = r.authorizeManage(ctx, before, userID); err != nil {
+		return nil, err
+	}
+
+	return r.updateLink(ctx, before, userID, func(ctx context.Context) error {
+		return r.urlRepo.SetParams(ctx, key, params)
+	})
+}
+
+// DefaultParams параметры, которые получает каждая новая ссылка пользователя
+func (r *ShortenerService) DefaultParams(ctx context.Context, userID uuid.UUID) (LinkParams, error) {
+	if r.defaultParams == nil {
+		return LinkParams{}, ErrDefaultParamsUnavailable
+	}
+	return r.defaultParams.GetDefaultParams(ctx, userID)
+}
+
+// SetDefaultParams замена параметров для новых ссылок пользователя, уже созданные ссылки не меняются
+func (r *ShortenerService) SetDefaultParams(ctx context.Context, userID uuid.UUID, params LinkParams) (LinkParams, error) {
+	if r.defaultParams == nil {
+		return LinkParams{}, ErrDefaultParamsUnavailable
+	}
+	if err := params.Validate(); err != nil {
+		return LinkParams{}, err
+	}
+	return params, r.defaultParams.SetDefaultParams(ctx, userID, params)
+}
+
+// newLinkParams параметры по умолчанию создателя, с которыми сохраняются его новые ссылки
+func (r *ShortenerService) newLinkParams(ctx context.Context, userID uuid.UUID) (LinkParams, error) {
+	if r.defaultParams == nil {
+		return LinkParams{}, nil
+	}
+	return r.defaultParams.GetDefaultParams(ctx, userID)
+}
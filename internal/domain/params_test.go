@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkParams_Apply(t *testing.T) {
+	visit := Visit{
+		UserAgent:      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148",
+		AcceptLanguage: "de-AT,de;q=0.9",
+		Referrer:       "https://News.Example.org/post/1",
+	}
+	tests := []struct {
+		name   string
+		target string
+		params LinkParams
+		visit  Visit
+		want   string
+	}{
+		{name: "override", target: "https://example.com/?utm_source=site&id=1&utm_source=old", params: LinkParams{Query: map[string]string{"utm_source": "short"}}, want: "https://example.com/?utm_source=short&id=1"},
+		{name: "keep", target: "https://example.com/?utm_source=site", params: LinkParams{Query: map[string]string{"utm_source": "short", "utm_medium": "link"}, Merge: MergeKeep}, want: "https://example.com/?utm_source=site&utm_medium=link"},
+		{name: "other pairs untouched", target: "https://example.com/?b=2;c=3&flag&a=%7e", params: LinkParams{Query: map[string]string{"utm_source": "a b"}}, want: "https://example.com/?b=2;c=3&flag&a=%7e&utm_source=a+b"},
+		{name: "append", target: "https://example.com/?tag=a", params: LinkParams{Query: map[string]string{"tag": "b"}, Merge: MergeAppend}, want: "https://example.com/?tag=a&tag=b"},
+		{name: "placeholders", target: "https://example.com/", params: LinkParams{Query: map[string]string{
+			"utm_campaign": "{key}", "utm_source": "{referrer_host}", "device": "{device}", "lang": "{language}",
+		}}, visit: visit, want: "https://example.com/?device=ios&lang=de-at&utm_campaign=abc&utm_source=news.example.org"},
+		{name: "empty value skipped", target: "https://example.com/", params: LinkParams{Query: map[string]string{"utm_source": "{referrer_host}", "ref": "x-{device}"}}, want: "https://example.com/?ref=x-"},
+		{name: "no params", target: "https://example.com/path?b=2&a=1", want: "https://example.com/path?b=2&a=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.target)
+			require.NoError(t, err)
+			tt.params.apply(u, "abc", tt.visit)
+			require.Equal(t, tt.want, u.String())
+		})
+	}
+}
+
+func TestLinkParams_Validate(t *testing.T) {
+	require.NoError(t, LinkParams{}.Validate())
+	require.NoError(t, LinkParams{Query: map[string]string{"utm_source": "{referrer_host}-{key}"}, Merge: MergeAppend}.Validate())
+
+	err := LinkParams{Query: map[string]string{"utm_source": "{referer}", "": "x", "long": strings.Repeat("x", MaxLinkParamValueLength+1)}, Merge: "replace"}.Validate()
+	require.Error(t, err)
+	for _, field := range []string{"merge", "query", "query.utm_source", "query.long"} {
+		require.Contains(t, err.Error(), field+":")
+	}
+
+	query := make(map[string]string, MaxLinkParams+1)
+	for i := 0; i <= MaxLinkParams; i++ {
+		query[string(rune('a'+i))] = "1"
+	}
+	require.Error(t, LinkParams{Query: query}.Validate())
+}
+
+func TestLinkParams_Dynamic(t *testing.T) {
+	require.False(t, LinkParams{}.Dynamic())
+	require.False(t, LinkParams{Query: map[string]string{"utm_campaign": "{key}", "utm_source": "short"}}.Dynamic())
+	require.True(t, LinkParams{Query: map[string]string{"utm_source": "{referrer_host}"}}.Dynamic())
+}
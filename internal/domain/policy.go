@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"fmt"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultDeniedNetworks - сети, ссылки на которые запрещены по умолчанию (loopback, RFC1918, link-local)
+var DefaultDeniedNetworks = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+}
+
+// DefaultDeniedHosts - хосты, ссылки на которые запрещены по умолчанию
+var DefaultDeniedHosts = []string{"localhost", ".localhost", ".local", ".internal"}
+
+// DefaultMaxURLLength - максимальная длина ссылки по умолчанию
+const DefaultMaxURLLength = 2048
+
+// URLPolicyError - ссылка отклонена политикой безопасности
+type URLPolicyError struct {
+	Reason string
+}
+
+// Error - имлементация error
+func (e *URLPolicyError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidURL, e.Reason)
+}
+
+// Unwrap - ошибка политики является ErrInvalidURL
+func (e *URLPolicyError) Unwrap() error {
+	return ErrInvalidURL
+}
+
+var _ error = (*URLPolicyError)(nil)
+
+// DomainBlocklist - список запрещённых доменов
+type DomainBlocklist interface {
+	// Contains - домен или один из его родительских доменов в списке
+	Contains(host string) bool
+}
+
+// URLPolicy - политика допустимых ссылок назначения
+type URLPolicy struct {
+	// AllowedSchemes - разрешённые схемы
+	AllowedSchemes []string
+	// DeniedHosts - запрещённые хосты, с точкой в начале - домен и все поддомены
+	DeniedHosts []string
+	// DeniedNetworks - запрещённые сети для ip адресов в ссылке
+	DeniedNetworks []*net.IPNet
+	// SelfHosts - хосты самого сервиса, ссылки на них приводят к петле редиректов
+	SelfHosts []string
+	// MaxLength - максимальная длина ссылки, 0 - без ограничения
+	MaxLength int
+	// Blocklist - внешний список запрещённых доменов
+	Blocklist DomainBlocklist
+}
+
+// DefaultURLPolicy политика по умолчанию
+func DefaultURLPolicy() *URLPolicy {
+	nets, err := utils.ParseCIDRs(DefaultDeniedNetworks)
+	if err != nil {
+		panic(err)
+	}
+	return &URLPolicy{
+		AllowedSchemes: []string{"http", "https"},
+		DeniedHosts:    DefaultDeniedHosts,
+		DeniedNetworks: nets,
+		MaxLength:      DefaultMaxURLLength,
+	}
+}
+
+// Check проверка ссылки, ожидается нормализованная ссылка
+func (p *URLPolicy) Check(u url.URL) error {
+	if p.MaxLength > 0 && len(u.String()) > p.MaxLength {
+		return &URLPolicyError{Reason: fmt.Sprintf("url is longer than %d characters", p.MaxLength)}
+	}
+	if !slices.Contains(p.AllowedSchemes, strings.ToLower(u.Scheme)) {
+		return &URLPolicyError{Reason: fmt.Sprintf("scheme %q is not allowed", u.Scheme)}
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return &URLPolicyError{Reason: "host is empty"}
+	}
+	for _, self := range p.SelfHosts {
+		if strings.EqualFold(host, self) {
+			return &URLPolicyError{Reason: "url points to the shortener itself"}
+		}
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		var numeric bool
+		if ip, numeric = parseIPv4Host(host); numeric && ip == nil {
+			return &URLPolicyError{Reason: fmt.Sprintf("host %s is not a valid ipv4 address", host)}
+		}
+	}
+	if ip != nil {
+		for _, n := range p.DeniedNetworks {
+			if n.Contains(ip) {
+				return &URLPolicyError{Reason: fmt.Sprintf("address %s is not allowed", ip)}
+			}
+		}
+		return nil
+	}
+	for _, denied := range p.DeniedHosts {
+		if matchHost(host, denied) {
+			return &URLPolicyError{Reason: fmt.Sprintf("host %s is not allowed", host)}
+		}
+	}
+	if p.Blocklist != nil && p.Blocklist.Contains(host) {
+		return &URLPolicyError{Reason: fmt.Sprintf("domain %s is blocklisted", host)}
+	}
+	return nil
+}
+
+// parseIPv4Host адрес хоста, который браузеры по WHATWG URL считают ipv4: от одной до четырёх частей
+// в десятичной, шестнадцатеричной 0x или восьмеричной с ведущим 0 записи, последняя часть занимает оставшиеся байты.
+// Так 2130706433, 0x7f000001, 0177.1 и 127.1 - это 127.0.0.1. numeric - хост оканчивается числом и должен быть ipv4,
+// при этом ip nil - адрес некорректный.
+func parseIPv4Host(host string) (ip net.IP, numeric bool) {
+	parts := strings.Split(host, ".")
+	if !endsInNumber(parts[len(parts)-1]) {
+		return nil, false
+	}
+	if len(parts) > 4 {
+		return nil, true
+	}
+	var addr uint64
+	for i, part := range parts {
+		n, ok := parseIPv4Part(part)
+		last := i == len(parts)-1
+		if !ok || !last && n > 255 || last && n >= 1<<(8*(5-len(parts))) {
+			return nil, true
+		}
+		if last {
+			addr += n
+		} else {
+			addr += n << (8 * (3 - i))
+		}
+	}
+	return net.IPv4(byte(addr>>24), byte(addr>>16), byte(addr>>8), byte(addr)), true
+}
+
+// endsInNumber последняя часть хоста - число, такой хост разбирается как ipv4
+func endsInNumber(part string) bool {
+	if part == "" {
+		return false
+	}
+	if hex, ok := strings.CutPrefix(strings.ToLower(part), "0x"); ok {
+		return strings.Trim(hex, "0123456789abcdef") == ""
+	}
+	return strings.Trim(part, "0123456789") == ""
+}
+
+// parseIPv4Part число части ipv4 хоста: 0x - шестнадцатеричное, ведущий 0 - восьмеричное
+func parseIPv4Part(part string) (uint64, bool) {
+	base := 10
+	if hex, ok := strings.CutPrefix(strings.ToLower(part), "0x"); ok {
+		part, base = hex, 16
+		if part == "" {
+			return 0, true
+		}
+	} else if len(part) > 1 && part[0] == '0' {
+		part, base = part[1:], 8
+	}
+	n, err := strconv.ParseUint(part, base, 64)
+	return n, err == nil
+}
+
+func matchHost(host, pattern string) bool {
+	pattern = strings.ToLower(pattern)
+	if suffix, ok := strings.CutPrefix(pattern, "."); ok {
+		return host == suffix || strings.HasSuffix(host, pattern)
+	}
+	return host == pattern
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubBlocklist map[string]bool
+
+func (b stubBlocklist) Contains(host string) bool {
+	return b[host]
+}
+
+func TestURLPolicy_Check(t *testing.T) {
+	policy := DefaultURLPolicy()
+	policy.SelfHosts = []string{"short.example"}
+	policy.Blocklist = stubBlocklist{"evil.example": true}
+
+	tests := []struct {
+		name    string
+		url     string
+		allowed bool
+	}{
+		{name: "https", url: "https://github.com", allowed: true},
+		{name: "public ip", url: "http://8.8.8.8/", allowed: true},
+		{name: "javascript scheme", url: "javascript:alert(1)"},
+		{name: "file scheme", url: "file:///etc/passwd"},
+		{name: "no host", url: "http:///path"},
+		{name: "loopback", url: "http://127.0.0.1:8080/"},
+		{name: "loopback ipv6", url: "http://[::1]/"},
+		{name: "ipv4 mapped loopback", url: "http://[::ffff:127.0.0.1]/"},
+		{name: "rfc1918", url: "http://192.168.1.1/"},
+		{name: "decimal loopback", url: "http://2130706433/"},
+		{name: "hex loopback", url: "http://0x7f000001/"},
+		{name: "short loopback", url: "http://127.1/"},
+		{name: "octal loopback", url: "http://0177.0.0.01/"},
+		{name: "short rfc1918", url: "http://10.1/"},
+		{name: "mixed private", url: "http://0xc0.168.0x1.1/"},
+		{name: "numeric host out of range", url: "http://1.2.3.256/"},
+		{name: "numeric public", url: "http://134744072/", allowed: true},
+		{name: "numeric label in domain", url: "http://example.123a/", allowed: true},
+		{name: "localhost", url: "http://localhost/"},
+		{name: "localhost subdomain", url: "http://app.localhost/"},
+		{name: "redirect loop", url: "https://short.example/abc"},
+		{name: "blocklisted", url: "https://evil.example/"},
+		{name: "too long", url: "https://github.com/" + strings.Repeat("a", DefaultMaxURLLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+
+			err = policy.Check(*u)
+			if tt.allowed {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrInvalidURL)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/url"
+	"unicode/utf8"
+)
+
+// Ограничения описания ссылки для страницы предпросмотра
+const (
+	MaxPreviewTitleLength       = 200
+	MaxPreviewDescriptionLength = 1000
+)
+
+// LinkPreview - данные страницы предпросмотра ссылки, заполняются владельцем
+type LinkPreview struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Interstitial - показывать страницу предпросмотра каждому посетителю вместо редиректа
+	Interstitial bool `json:"interstitial,omitempty"`
+}
+
+// Validate проверка длины заголовка и описания
+func (p LinkPreview) Validate() error {
+	var errs []error
+	if utf8.RuneCountInString(p.Title) > MaxPreviewTitleLength {
+		errs = append(errs, &ValidationError{Field: "title", Message: fmt.Sprintf("must be at most %d characters", MaxPreviewTitleLength)})
+	}
+	if utf8.RuneCountInString(p.Description) > MaxPreviewDescriptionLength {
+		errs = append(errs, &ValidationError{Field: "description", Message: fmt.Sprintf("must be at most %d characters", MaxPreviewDescriptionLength)})
+	}
+	return errors.Join(errs...)
+}
+
+// SetPreview замена данных предпросмотра ссылки, доступна тем же, кто может удалить ссылку
+func (r *ShortenerService) SetPreview(ctx context.Context, key HashKey, userID uuid.UUID, preview LinkPreview) (*Link, error) {
+	if err := preview.Validate(); err != nil {
+		return nil, err
+	}
+	before, err := r.urlRepo.GetLink(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if before.IsDeleted {
+		return nil, ErrURLNotFound
+	}
+	if err = r.authorizeManage(ctx, before, userID); err != nil {
+		return nil, err
+	}
+
+	return r.updateLink(ctx, before, userID, func(ctx context.Context) error {
+		return r.urlRepo.SetPreview(ctx, key, preview)
+	})
+}
+
+// policyWarning причина, по которой ссылку отклонила бы текущая политика, пусто - ссылка допустима.
+// Списки запрещённых доменов обновляются, поэтому созданная раньше ссылка может попасть под запрет.
+func (r *ShortenerService) policyWarning(u url.URL) string {
+	if r.policy == nil {
+		return ""
+	}
+	var policyErr *URLPolicyError
+	if errors.As(r.policy.Check(u), &policyErr) {
+		return policyErr.Reason
+	}
+	return ""
+}
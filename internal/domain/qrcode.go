@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// QRFormat - формат изображения qr кода
+type QRFormat string
+
+// Форматы qr кода
+const (
+	QRFormatPNG QRFormat = "png"
+	QRFormatSVG QRFormat = "svg"
+)
+
+// ContentType - mime тип изображения
+func (f QRFormat) ContentType() string {
+	if f == QRFormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// QRLevel - уровень коррекции ошибок qr кода: L - 7%, M - 15%, Q - 25%, H - 30%
+type QRLevel string
+
+// Уровни коррекции ошибок
+const (
+	QRLevelLow      QRLevel = "L"
+	QRLevelMedium   QRLevel = "M"
+	QRLevelQuartile QRLevel = "Q"
+	QRLevelHigh     QRLevel = "H"
+)
+
+func (l QRLevel) valid() bool {
+	switch l {
+	case QRLevelLow, QRLevelMedium, QRLevelQuartile, QRLevelHigh:
+		return true
+	}
+	return false
+}
+
+// Ограничения qr кода, размер в пикселях, отступ в модулях
+const (
+	DefaultQRSize   = 256
+	MinQRSize       = 64
+	MaxQRSize       = 2048
+	DefaultQRMargin = 4
+	MaxQRMargin     = 16
+)
+
+// QROptions - параметры изображения qr кода
+type QROptions struct {
+	Format QRFormat
+	// Size - ширина и высота изображения в пикселях
+	Size  int
+	Level QRLevel
+	// Margin - светлая рамка вокруг кода в модулях, сканерам нужно не меньше 4
+	Margin     int
+	Foreground color.NRGBA
+	Background color.NRGBA
+}
+
+// DefaultQROptions параметры по умолчанию: чёрный png код на белом фоне
+func DefaultQROptions() QROptions {
+	return QROptions{
+		Format:     QRFormatPNG,
+		Size:       DefaultQRSize,
+		Level:      QRLevelMedium,
+		Margin:     DefaultQRMargin,
+		Foreground: color.NRGBA{A: 0xff},
+		Background: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// Validate проверка параметров
+func (o QROptions) Validate() error {
+	switch {
+	case o.Format != QRFormatPNG && o.Format != QRFormatSVG:
+		return &ValidationError{Field: "format", Message: "must be png or svg"}
+	case o.Size < MinQRSize || o.Size > MaxQRSize:
+		return &ValidationError{Field: "size", Message: fmt.Sprintf("must be between %d and %d", MinQRSize, MaxQRSize)}
+	case !o.Level.valid():
+		return &ValidationError{Field: "level", Message: "must be one of L, M, Q, H"}
+	case o.Margin < 0 || o.Margin > MaxQRMargin:
+		return &ValidationError{Field: "margin", Message: fmt.Sprintf("must be between 0 and %d", MaxQRMargin)}
+	}
+	return nil
+}
+
+// ETag - тег изображения: код ссылки не меняется, поэтому тег зависит только от содержимого и параметров
+func (o QROptions) ETag(content string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s:%d:%s:%d:%s:%s", content, o.Format, o.Size, o.Level, o.Margin, FormatQRColor(o.Foreground), FormatQRColor(o.Background))))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ParseQRColor цвет в виде RRGGBB или RRGGBBAA, # в начале допускается
+func ParseQRColor(field string, s string) (color.NRGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 6 {
+		s += "ff"
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if len(s) != 8 || err != nil {
+		return color.NRGBA{}, &ValidationError{Field: field, Message: "must be a hex color RRGGBB or RRGGBBAA"}
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// FormatQRColor цвет в виде RRGGBBAA
+func FormatQRColor(c color.NRGBA) string {
+	return fmt.Sprintf("%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// GetLink существующая неудалённая ссылка
+func (r *ShortenerService) GetLink(ctx context.Context, key HashKey) (*Link, error) {
+	link, err := r.urlRepo.GetLink(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if link.IsDeleted {
+		return nil, ErrURLNotFound
+	}
+	return link, nil
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit - лимит token bucket: ведро на Burst токенов пополняется со скоростью Rate токенов в секунду
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled - лимит задан
+func (l RateLimit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Fits - запрос стоимостью cost может пройти хотя бы с полным ведром.
+// Более дорогие запросы не пройдут никогда, их нужно отклонять сразу, а не просить повторить позже.
+func (l RateLimit) Fits(cost int) bool {
+	return cost <= l.Burst
+}
+
+// Take - списание cost токенов из ведра, в котором было tokens токенов elapsed назад.
+// Возвращает новое количество токенов и время, через которое стоит повторить запрос, если токенов не хватило.
+func (l RateLimit) Take(tokens float64, elapsed time.Duration, cost int) (float64, time.Duration) {
+	tokens = math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.Rate)
+	if tokens >= float64(cost) {
+		return tokens - float64(cost), 0
+	}
+	if cost > l.Burst {
+		cost = l.Burst
+	}
+	wait := time.Duration((float64(cost) - tokens) / l.Rate * float64(time.Second))
+	return tokens, max(wait, time.Second)
+}
+
+// ParseRateLimit разбор лимита вида "100/m" или "10/s:50", где после двоеточия размер ведра.
+// Пустая строка - лимит отключен.
+func ParseRateLimit(s string) (RateLimit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return RateLimit{}, nil
+	}
+	spec, burstStr, hasBurst := strings.Cut(s, ":")
+	countStr, periodStr, ok := strings.Cut(spec, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit count %q", s)
+	}
+	var period time.Duration
+	switch periodStr {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		period, err = time.ParseDuration(periodStr)
+		if err != nil || period <= 0 {
+			return RateLimit{}, fmt.Errorf("invalid rate limit period %q", s)
+		}
+	}
+	burst := count
+	if hasBurst {
+		burst, err = strconv.Atoi(burstStr)
+		if err != nil || burst <= 0 {
+			return RateLimit{}, fmt.Errorf("invalid rate limit burst %q", s)
+		}
+	}
+	return RateLimit{Rate: float64(count) / period.Seconds(), Burst: burst}, nil
+}
+
+// RateLimits - лимиты по видам операций
+type RateLimits struct {
+	Create   RateLimit
+	Batch    RateLimit
+	Redirect RateLimit
+	Delete   RateLimit
+}
+
+// RateLimiter - ограничение частоты запросов
+type RateLimiter interface {
+	// Allow списание cost токенов по ключу. Если токенов не хватает, возвращает время до повтора больше нуля.
+	Allow(ctx context.Context, key string, limit RateLimit, cost int) (time.Duration, error)
+}
+
+// WaitRateLimit списание cost токенов с ожиданием, пока их станет достаточно, - для потоков,
+// которым уже поздно отвечать отказом. Стоимость больше Burst списывается частями.
+func WaitRateLimit(ctx context.Context, limiter RateLimiter, key string, limit RateLimit, cost int) error {
+	for cost > 0 {
+		n := min(cost, limit.Burst)
+		retryAfter, err := limiter.Allow(ctx, key, limit, n)
+		if err != nil {
+			return err
+		}
+		if retryAfter == 0 {
+			cost -= n
+			continue
+		}
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    RateLimit
+		wantErr bool
+	}{
+		{spec: "", want: RateLimit{}},
+		{spec: "10/s", want: RateLimit{Rate: 10, Burst: 10}},
+		{spec: "60/m:5", want: RateLimit{Rate: 1, Burst: 5}},
+		{spec: "30/2s", want: RateLimit{Rate: 15, Burst: 30}},
+		{spec: "10", wantErr: true},
+		{spec: "0/s", wantErr: true},
+		{spec: "10/week", wantErr: true},
+		{spec: "10/s:x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseRateLimit(tt.spec)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRateLimit_Take(t *testing.T) {
+	limit := RateLimit{Rate: 1, Burst: 3}
+
+	tokens, retryAfter := limit.Take(3, 0, 2)
+	require.Zero(t, retryAfter)
+	require.InDelta(t, 1, tokens, 0.001)
+
+	tokens, retryAfter = limit.Take(tokens, 0, 2)
+	require.Equal(t, time.Second, retryAfter)
+	require.InDelta(t, 1, tokens, 0.001)
+
+	tokens, retryAfter = limit.Take(tokens, time.Hour, 3)
+	require.Zero(t, retryAfter, "bucket refills up to burst")
+	require.InDelta(t, 0, tokens, 0.001)
+
+	_, retryAfter = limit.Take(0, 0, 10)
+	require.Equal(t, 3*time.Second, retryAfter, "cost above burst waits for a full bucket")
+}
+
+func TestRateLimit_Fits(t *testing.T) {
+	limit := RateLimit{Rate: 1, Burst: 3}
+	require.True(t, limit.Fits(1))
+	require.True(t, limit.Fits(3))
+	require.False(t, limit.Fits(4), "cost above burst never succeeds")
+}
+
+// fakeRateLimiter отказывает в первом запросе на wait, запоминает стоимости
+type fakeRateLimiter struct {
+	wait  time.Duration
+	costs []int
+}
+
+func (l *fakeRateLimiter) Allow(ctx context.Context, key string, limit RateLimit, cost int) (time.Duration, error) {
+	l.costs = append(l.costs, cost)
+	if len(l.costs) == 1 {
+		return l.wait, nil
+	}
+	return 0, nil
+}
+
+func TestWaitRateLimit(t *testing.T) {
+	limiter := &fakeRateLimiter{wait: time.Millisecond}
+	require.NoError(t, WaitRateLimit(context.Background(), limiter, "batch:user", RateLimit{Rate: 1, Burst: 2}, 5))
+	require.Equal(t, []int{2, 2, 2, 1}, limiter.costs, "cost above burst is taken in parts, refused part is retried")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter = &fakeRateLimiter{wait: time.Hour}
+	require.ErrorIs(t, WaitRateLimit(ctx, limiter, "batch:user", RateLimit{Rate: 1, Burst: 2}, 1), context.Canceled)
+}
@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"net/url"
+	"time"
+)
+
+// RedirectType - код ответа при переходе по короткой ссылке
+type RedirectType int
+
+// Коды редиректа, RedirectDefault - код по умолчанию сервиса
+const (
+	RedirectDefault          RedirectType = 0
+	RedirectMovedPermanently RedirectType = 301
+	RedirectFound            RedirectType = 302
+	RedirectTemporary        RedirectType = 307
+	RedirectPermanent        RedirectType = 308
+)
+
+// Valid - поддерживаемый код редиректа
+func (t RedirectType) Valid() bool {
+	switch t {
+	case RedirectMovedPermanently, RedirectFound, RedirectTemporary, RedirectPermanent:
+		return true
+	}
+	return false
+}
+
+// Permanent - постоянный редирект, браузеры и CDN могут его кешировать
+func (t RedirectType) Permanent() bool {
+	return t == RedirectMovedPermanently || t == RedirectPermanent
+}
+
+// RedirectSettings - настройки редиректа ссылки, пустые значения - настройки сервиса по умолчанию
+type RedirectSettings struct {
+	Type RedirectType `json:"redirect_type,omitempty"`
+	// PassQuery - дописывать параметры запроса короткой ссылки к оригиналу
+	PassQuery *bool `json:"pass_query,omitempty"`
+}
+
+// Validate проверка кода редиректа
+func (s RedirectSettings) Validate() error {
+	if s.Type != RedirectDefault && !s.Type.Valid() {
+		return &ValidationError{Field: "redirect_type", Message: "must be one of 301, 302, 307, 308"}
+	}
+	return nil
+}
+
+// Redirect - куда и как перенаправлять по короткой ссылке, настройки по умолчанию уже применены
+type Redirect struct {
+	Key HashKey
+	// Domain - брендовый домен ссылки, пусто - основной домен
+	Domain    string
+	URL       url.URL
+	Type      RedirectType
+	PassQuery bool
+	// ExpiresAt - время, после которого ссылка не открывается, nil - бессрочная
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+	Preview   LinkPreview
+	// Warning - причина, по которой ссылку отклоняет политика безопасности, пусто - ссылка допустима
+	Warning string
+	// Routed - адрес зависит от посетителя: у ссылки есть правила маршрутизации, варианты или подстановки в параметрах
+	Routed bool
+	// Variant - выбранный вариант A/B теста, пусто - переход не на вариант
+	Variant string
+}
+
+// Interstitial - вместо редиректа показывается страница предпросмотра: по настройке ссылки или из-за предупреждения политики
+func (r *Redirect) Interstitial() bool {
+	return r.Preview.Interstitial || r.Warning != ""
+}
+
+// Location адрес перенаправления, при PassQuery параметры запроса короткой ссылки дописываются после параметров оригинала
+func (r *Redirect) Location(rawQuery string) string {
+	u := r.URL
+	if r.PassQuery && rawQuery != "" {
+		if u.RawQuery == "" {
+			u.RawQuery = rawQuery
+		} else {
+			u.RawQuery += "&" + rawQuery
+		}
+	}
+	return u.String()
+}
+
+// WithRedirectDefaults - код редиректа и передача параметров запроса для ссылок без своих настроек
+func WithRedirectDefaults(redirectType RedirectType, passQuery bool) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.redirectType = redirectType
+		s.passQuery = passQuery
+	}
+}
+
+// Resolve ссылка для перенаправления с настройками редиректа, удалённая, отключённая или истёкшая ссылка - ошибка.
+// Адрес выбирается правилами маршрутизации ссылки по данным перехода, без совпадений - вариантом A/B теста
+// или оригиналом ссылки, к адресу добавляются параметры ссылки. Переход на вариант не учитывается, для этого есть RecordClick.
+func (r *ShortenerService) Resolve(ctx context.Context, key HashKey, visit Visit) (*Redirect, error) {
+	link, err := r.urlRepo.GetLink(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case link.IsDeleted:
+		return nil, ErrURLDeleted
+	case link.IsDisabled:
+		return nil, ErrURLDisabled
+	case link.ExpiresAt != nil && !time.Now().Before(*link.ExpiresAt):
+		return nil, ErrURLExpired
+	}
+	var variant string
+	u, err := r.route(link.Rules, visit)
+	if err == nil && u == nil {
+		target := link.OriginalURL
+		if len(link.Variants) > 0 {
+			v := pickVariant(link.Variants, visit.Variant)
+			target, variant = v.URL, v.ID
+		}
+		u, err = url.Parse(target)
+	}
+	if err != nil {
+		return nil, err
+	}
+	link.Params.apply(u, link.Key, visit)
+
+	res := &Redirect{
+		Key:       link.Key,
+		Domain:    link.Domain,
+		URL:       *u,
+		Type:      link.Redirect.Type,
+		PassQuery: r.passQuery,
+		ExpiresAt: link.ExpiresAt,
+		CreatedAt: link.CreatedAt,
+		Preview:   link.Preview,
+		Warning:   r.policyWarning(*u),
+		Routed:    len(link.Rules) > 0 || len(link.Variants) > 0 || link.Params.Dynamic(),
+		Variant:   variant,
+	}
+	if res.Type == RedirectDefault {
+		res.Type = r.redirectType
+	}
+	if link.Redirect.PassQuery != nil {
+		res.PassQuery = *link.Redirect.PassQuery
+	}
+	return res, nil
+}
+
+// SetRedirect замена настроек редиректа ссылки, доступна тем же, кто может удалить ссылку
+func (r *ShortenerService) SetRedirect(ctx context.Context, key HashKey, userID uuid.UUID, settings RedirectSettings) (*Link, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	before, err := r.urlRepo.GetLink(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if before.IsDeleted {
+		return nil, ErrURLNotFound
+	}
+	if err = r.authorizeManage(ctx, before, userID); err != nil {
+		return nil, err
+	}
+
+	return r.updateLink(ctx, before, userID, func(ctx context.Context) error {
+		return r.urlRepo.SetRedirect(ctx, key, settings)
+	})
+}
+
+// authorizeManage право пользователя изменять ссылку: личную ссылку - владельцу, ссылку пространства - с ролью editor и выше
+func (r *ShortenerService) authorizeManage(ctx context.Context, link *Link, userID uuid.UUID) error {
+	return r.authorizeLink(ctx, link, userID, RoleEditor)
+}
+
+// authorizeLink доступ пользователя к ссылке: личной - только владельцу, ссылке пространства - с ролью не ниже required
+func (r *ShortenerService) authorizeLink(ctx context.Context, link *Link, userID uuid.UUID, required WorkspaceRole) error {
+	if link.WorkspaceID == uuid.Nil {
+		if link.UserID != userID {
+			return ErrForbidden
+		}
+		return nil
+	}
+	err := authorizeWorkspace(ctx, r.workspaces, link.WorkspaceID, userID, required)
+	if errors.Is(err, ErrWorkspaceNotFound) {
+		return ErrForbidden
+	}
+	return err
+}
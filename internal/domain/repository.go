@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"net/url"
+	"time"
 )
 
 // HashKey - ключ для короткой ссылки
@@ -17,15 +18,83 @@ type BatchItem struct {
 	URL     url.URL
 }
 
+// Результаты создания ссылки в пакете
+const (
+	BatchCreated BatchStatus = "created"
+	BatchExists  BatchStatus = "exists"
+	BatchInvalid BatchStatus = "invalid"
+	// BatchSkipped - ссылка не создана, потому что атомарный пакет отклонён из-за других ссылок
+	BatchSkipped BatchStatus = "skipped"
+)
+
+// BatchStatus - результат создания ссылки в пакете
+type BatchStatus string
+
+// BatchResult - результат создания ссылки в пакете, Key - созданная или уже существующая ссылка
+type BatchResult struct {
+	Key    HashKey
+	URL    url.URL
+	Status BatchStatus
+	Error  string
+}
+
 // URLEntry - ссылка короткая, оригинал
 type URLEntry struct {
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
+	Key HashKey `json:"-"`
+	// Domain - брендовый домен ссылки, пусто - основной домен
+	Domain      string    `json:"-"`
+	ShortURL    string    `json:"short_url"`
+	OriginalURL string    `json:"original_url"`
+	Title       string    `json:"title,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Link - ссылка со всеми данными, для администрирования
+type Link struct {
+	Key HashKey `json:"key"`
+	// Domain - брендовый домен ссылки, пусто - основной домен
+	Domain      string    `json:"domain,omitempty"`
+	ShortURL    string    `json:"short_url,omitempty"`
+	OriginalURL string    `json:"original_url"`
+	UserID      uuid.UUID `json:"user_id"`
+	WorkspaceID uuid.UUID `json:"workspace_id,omitempty"`
+	IsDeleted   bool      `json:"is_deleted"`
+	IsDisabled  bool      `json:"is_disabled"`
+	// ExpiresAt - время, после которого ссылка не открывается, nil - бессрочная
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Title, Note, Tags - описание ссылки для владельцев, см. LinkMeta
+	Title string   `json:"title,omitempty"`
+	Note  string   `json:"note,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	// Redirect - собственные настройки редиректа ссылки
+	Redirect RedirectSettings `json:"redirect"`
+	Preview  LinkPreview      `json:"preview"`
+	// Rules - правила маршрутизации по порядку проверки, без совпадений переход ведёт на OriginalURL
+	Rules []RouteRule `json:"rules,omitempty"`
+	// Variants - варианты A/B теста, заменяют OriginalURL для переходов, не подошедших под правила
+	Variants []Variant `json:"variants,omitempty"`
+	// Params - параметры запроса, которые дописываются к адресу назначения
+	Params    LinkParams `json:"params"`
+	CreatedAt time.Time  `json:"created_at"`
 }
 
 // ErrURLDeleted - ошибка ссылка была удалена
 var ErrURLDeleted = fmt.Errorf("url deleted")
 
+// ErrURLDisabled - ошибка ссылка отключена администратором
+var ErrURLDisabled = fmt.Errorf("url disabled")
+
+// ErrURLExpired - ошибка срок действия ссылки истёк
+var ErrURLExpired = fmt.Errorf("url expired")
+
+// ErrURLNotFound - ошибка ссылка не найдена
+var ErrURLNotFound = fmt.Errorf("url not found")
+
+// ErrInvalidURL - ошибка некорректная ссылка
+var ErrInvalidURL = fmt.Errorf("invalid url")
+
 // ErrURLAlreadyExists - ошибка ссылка уже существует
 type ErrURLAlreadyExists struct {
 	HashKey HashKey
@@ -38,16 +107,79 @@ func (e *ErrURLAlreadyExists) Error() string {
 
 var _ error = (*ErrURLAlreadyExists)(nil)
 
-// URLRepository - основной интерфейс управления ссылками
+// URLRepository - основной интерфейс управления ссылками, права проверяются в сервисе.
+// Ключи уникальны в пределах домена: методы по ключу и создание ссылок работают с доменом из LinkDomainFromCtx,
+// списки ссылок пользователя и пространства включают ссылки всех доменов.
 type URLRepository interface {
-	Add(ctx context.Context, key HashKey, u url.URL, userID uuid.UUID) error
-	BatchAdd(ctx context.Context, batch []BatchItem, userID uuid.UUID) error
+	// Add добавление ссылки, params - её параметры запроса, сохраняются вместе со ссылкой
+	Add(ctx context.Context, key HashKey, u url.URL, owner Owner, params LinkParams) error
+	// BatchAdd добавление всех ссылок или ни одной, params - параметры запроса каждой ссылки
+	BatchAdd(ctx context.Context, batch []BatchItem, owner Owner, params LinkParams) error
+	// Import добавление ссылок с пропуском занятых ключей и ссылок, возвращает ключи созданных,
+	// params - параметры запроса каждой ссылки
+	Import(ctx context.Context, items []ImportItem, owner Owner, params LinkParams) ([]HashKey, error)
 	GetByHash(ctx context.Context, key HashKey) (*url.URL, error)
-	GetByUser(ctx context.Context, userID uuid.UUID) ([]URLEntry, error)
-	DeleteByUser(ctx context.Context, keys []HashKey, userID uuid.UUID) (bool, error)
+	// GetKeysByURLs ключи существующих ссылок владельца по оригиналам, отсутствующих оригиналов в ответе нет.
+	// Оригинал уникален только среди ссылок одного владельца, ссылки других владельцев не возвращаются.
+	GetKeysByURLs(ctx context.Context, urls []string, owner Owner) (map[string]HashKey, error)
+	// GetByUser личные ссылки пользователя, без ссылок рабочих пространств
+	GetByUser(ctx context.Context, userID uuid.UUID, q LinkQuery) ([]URLEntry, error)
+	GetByWorkspace(ctx context.Context, workspaceID uuid.UUID, q LinkQuery) ([]URLEntry, error)
+	// Export обход ссылок владельца без загрузки всего списка: личных или рабочего пространства, если оно указано
+	Export(ctx context.Context, owner Owner, fn func(e ExportEntry) error) error
+	// GetOwners владельцы ссылок, отсутствующих ключей в ответе нет
+	GetOwners(ctx context.Context, keys []HashKey) (map[HashKey]Owner, error)
+	DeleteByKeys(ctx context.Context, keys []HashKey) (bool, error)
+	// DeleteByDomain удаление ссылок на домен и его поддомены со всех доменов коротких ссылок,
+	// возвращает удалённые ссылки в состоянии до удаления
+	DeleteByDomain(ctx context.Context, host string) ([]Link, error)
+	// GetLink ссылка с владельцем, в том числе удалённая или отключённая
+	GetLink(ctx context.Context, key HashKey) (*Link, error)
+	// GetLinks ссылки по ключам одним запросом, отсутствующих ключей в ответе нет
+	GetLinks(ctx context.Context, keys []HashKey) ([]Link, error)
+	// GetLinksByUser все ссылки, созданные пользователем, включая ссылки рабочих пространств
+	GetLinksByUser(ctx context.Context, userID uuid.UUID) ([]Link, error)
+	SetDisabled(ctx context.Context, key HashKey, disabled bool) error
+	// SetRedirect замена настроек редиректа ссылки
+	SetRedirect(ctx context.Context, key HashKey, settings RedirectSettings) error
+	// SetPreview замена данных предпросмотра ссылки
+	SetPreview(ctx context.Context, key HashKey, preview LinkPreview) error
+	// SetRules замена правил маршрутизации ссылки
+	SetRules(ctx context.Context, key HashKey, rules []RouteRule) error
+	// SetVariants замена вариантов A/B теста, счётчики переходов вариантов не сбрасываются
+	SetVariants(ctx context.Context, key HashKey, variants []Variant) error
+	// SetParams замена параметров запроса ссылки
+	SetParams(ctx context.Context, key HashKey, params LinkParams) error
+	// SetMeta замена названия, заметки и тегов ссылки
+	SetMeta(ctx context.Context, key HashKey, meta LinkMeta) error
+	// RenameTag переименование тега в ссылках владельца: личных или рабочего пространства,
+	// возвращает изменённые ссылки в состоянии до переименования
+	RenameTag(ctx context.Context, owner Owner, rename TagRename) ([]Link, error)
+	// AddVariantClick учёт перехода на вариант ссылки
+	AddVariantClick(ctx context.Context, key HashKey, variantID string) error
+	// GetVariantClicks переходы по идентификаторам вариантов, вариантов без переходов в ответе нет
+	GetVariantClicks(ctx context.Context, key HashKey) (map[string]int64, error)
+	// ReassignUser передача не больше limit ссылок пользователя from пользователю to,
+	// возвращает переданные ссылки в новом состоянии
+	ReassignUser(ctx context.Context, from uuid.UUID, to uuid.UUID, limit int) ([]Link, error)
 	CountUrls(ctx context.Context) (int64, error)
 	CountUsers(ctx context.Context) (int64, error)
 }
 
+// Transactor - выполнение нескольких изменений хранилища как одного, например изменения ссылки и записи аудита
+type Transactor interface {
+	// InTx выполнение fn в транзакции: репозитории, вызванные с контекстом fn, работают внутри неё,
+	// ошибка fn откатывает транзакцию
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// inTx выполнение fn в транзакции, без Transactor изменения не откатываются
+func inTx(ctx context.Context, t Transactor, fn func(ctx context.Context) error) error {
+	if t == nil {
+		return fn(ctx)
+	}
+	return t.InTx(ctx, fn)
+}
+
 // GenShortURLToken - интерфейс получения
 type GenShortURLToken = func() HashKey
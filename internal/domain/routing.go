@@ -0,0 +1,251 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxRouteRules - сколько правил маршрутизации может быть у ссылки
+const MaxRouteRules = 20
+
+// Устройства и операционные системы, которые определяются по User-Agent
+const (
+	DeviceIOS     = "ios"
+	DeviceAndroid = "android"
+	DeviceWindows = "windows"
+	DeviceMacOS   = "macos"
+	DeviceLinux   = "linux"
+	DeviceMobile  = "mobile"
+	DeviceDesktop = "desktop"
+)
+
+var routeDevices = []string{DeviceIOS, DeviceAndroid, DeviceWindows, DeviceMacOS, DeviceLinux, DeviceMobile, DeviceDesktop}
+
+// RouteRule - правило маршрутизации ссылки: если переход подходит под все заданные условия, он ведёт на URL.
+// Внутри одного условия достаточно совпадения с любым из значений.
+type RouteRule struct {
+	URL string `json:"url"`
+	// Devices - устройства и ОС посетителя: ios, android, windows, macos, linux, mobile, desktop
+	Devices []string `json:"devices,omitempty"`
+	// Languages - основной язык браузера из Accept-Language, "de" совпадает и с "de-AT"
+	Languages []string `json:"languages,omitempty"`
+	// Countries - коды стран ISO 3166-1 alpha-2 по базе GeoIP
+	Countries []string `json:"countries,omitempty"`
+	// From, Until - интервал времени, в который действует правило, границы необязательны
+	From  *time.Time `json:"from,omitempty"`
+	Until *time.Time `json:"until,omitempty"`
+	// Query - параметры запроса короткой ссылки, пустое значение - параметр с любым значением
+	Query map[string]string `json:"query,omitempty"`
+}
+
+// Visit - переход по короткой ссылке, по которому выбирается правило маршрутизации
+type Visit struct {
+	UserAgent      string
+	AcceptLanguage string
+	Referrer       string
+	IP             net.IP
+	Query          url.Values
+	Time           time.Time
+	// Variant - вариант A/B теста, который посетитель получил раньше
+	Variant string
+}
+
+// GeoIP - определение страны посетителя по ip
+type GeoIP interface {
+	// Country код страны ISO 3166-1 alpha-2, пустая строка - страна неизвестна
+	Country(ip net.IP) (string, error)
+}
+
+// WithGeoIP - база GeoIP для правил маршрутизации по странам, без неё такие правила не срабатывают
+func WithGeoIP(geoIP GeoIP) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.geoIP = geoIP
+	}
+}
+
+// normalize приведение значений к нижнему или верхнему регистру и проверка правила
+func (rule RouteRule) normalize(field string) (RouteRule, error) {
+	var errs []error
+	if len(rule.Devices) == 0 && len(rule.Languages) == 0 && len(rule.Countries) == 0 &&
+		rule.From == nil && rule.Until == nil && len(rule.Query) == 0 {
+		errs = append(errs, &ValidationError{Field: field, Message: "at least one condition required"})
+	}
+	rule.Devices = slices.Clone(rule.Devices)
+	for i, device := range rule.Devices {
+		rule.Devices[i] = strings.ToLower(strings.TrimSpace(device))
+		if !slices.Contains(routeDevices, rule.Devices[i]) {
+			errs = append(errs, &ValidationError{Field: field + ".devices", Message: "must be one of " + strings.Join(routeDevices, ", ")})
+		}
+	}
+	rule.Languages = slices.Clone(rule.Languages)
+	for i, lang := range rule.Languages {
+		rule.Languages[i] = strings.ToLower(strings.TrimSpace(lang))
+		if rule.Languages[i] == "" || strings.ContainsAny(rule.Languages[i], " ,;") {
+			errs = append(errs, &ValidationError{Field: field + ".languages", Message: "invalid language tag"})
+		}
+	}
+	rule.Countries = slices.Clone(rule.Countries)
+	for i, country := range rule.Countries {
+		rule.Countries[i] = strings.ToUpper(strings.TrimSpace(country))
+		if len(rule.Countries[i]) != 2 {
+			errs = append(errs, &ValidationError{Field: field + ".countries", Message: "must be ISO 3166-1 alpha-2 code"})
+		}
+	}
+	if rule.From != nil && rule.Until != nil && !rule.From.Before(*rule.Until) {
+		errs = append(errs, &ValidationError{Field: field + ".until", Message: "must be after from"})
+	}
+	for name := range rule.Query {
+		if name == "" {
+			errs = append(errs, &ValidationError{Field: field + ".query", Message: "parameter name required"})
+		}
+	}
+	return rule, errors.Join(errs...)
+}
+
+// match подходит ли переход под все условия правила, country - страна посетителя
+func (rule RouteRule) match(v Visit, country string) bool {
+	if len(rule.Devices) > 0 && !slices.ContainsFunc(visitDevices(v.UserAgent), func(device string) bool {
+		return slices.Contains(rule.Devices, device)
+	}) {
+		return false
+	}
+	if len(rule.Languages) > 0 {
+		lang := preferredLanguage(v.AcceptLanguage)
+		if !slices.ContainsFunc(rule.Languages, func(want string) bool {
+			return lang == want || strings.HasPrefix(lang, want+"-")
+		}) {
+			return false
+		}
+	}
+	if len(rule.Countries) > 0 && !slices.Contains(rule.Countries, country) {
+		return false
+	}
+	if rule.From != nil && v.Time.Before(*rule.From) {
+		return false
+	}
+	if rule.Until != nil && !v.Time.Before(*rule.Until) {
+		return false
+	}
+	for name, value := range rule.Query {
+		if !v.Query.Has(name) || value != "" && v.Query.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// visitDevices ОС и тип устройства по User-Agent
+func visitDevices(userAgent string) []string {
+	ua := strings.ToLower(userAgent)
+	var os string
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		os = DeviceIOS
+	case strings.Contains(ua, "android"):
+		os = DeviceAndroid
+	case strings.Contains(ua, "windows"):
+		os = DeviceWindows
+	case strings.Contains(ua, "macintosh"), strings.Contains(ua, "mac os x"):
+		os = DeviceMacOS
+	case strings.Contains(ua, "linux"), strings.Contains(ua, "cros"):
+		os = DeviceLinux
+	default:
+		return nil
+	}
+	if os == DeviceIOS || os == DeviceAndroid || strings.Contains(ua, "mobile") {
+		return []string{os, DeviceMobile}
+	}
+	return []string{os, DeviceDesktop}
+}
+
+// preferredLanguage язык с наибольшим весом из Accept-Language в нижнем регистре, при равных весах - первый
+func preferredLanguage(header string) string {
+	var lang string
+	best := 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > best {
+			lang, best = tag, q
+		}
+	}
+	return lang
+}
+
+// route адрес первого подходящего правила, nil - ни одно правило не подошло
+func (r *ShortenerService) route(rules []RouteRule, v Visit) (*url.URL, error) {
+	var country string
+	if r.geoIP != nil && v.IP != nil && slices.ContainsFunc(rules, func(rule RouteRule) bool { return len(rule.Countries) > 0 }) {
+		// без страны правила по странам просто не срабатывают
+		country, _ = r.geoIP.Country(v.IP)
+	}
+	for _, rule := range rules {
+		if rule.match(v, country) {
+			return url.Parse(rule.URL)
+		}
+	}
+	return nil, nil
+}
+
+// SetRules замена правил маршрутизации ссылки, доступна тем же, кто может удалить ссылку.
+// Правила проверяются по порядку, адреса правил проходят ту же нормализацию и проверку, что и оригинал.
+func (r *ShortenerService) SetRules(ctx context.Context, key HashKey, userID uuid.UUID, rules []RouteRule) (*Link, error) {
+	if len(rules) > MaxRouteRules {
+		return nil, &ValidationError{Field: "rules", Message: fmt.Sprintf("must be at most %d rules", MaxRouteRules)}
+	}
+	normalized := make([]RouteRule, 0, len(rules))
+	var errs []error
+	for i, rule := range rules {
+		field := fmt.Sprintf("rules[%d]", i)
+		rule, err := rule.normalize(field)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		u, err := r.parseURL(rule.URL)
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			err = &ValidationError{Field: field + ".url", Message: validationErr.Message}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		rule.URL = u.String()
+		normalized = append(normalized, rule)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	before, err := r.urlRepo.GetLink(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if before.IsDeleted {
+		return nil, ErrURLNotFound
+	}
+	if err = r.authorizeManage(ctx, before, userID); err != nil {
+		return nil, err
+	}
+
+	return r.updateLink(ctx, before, userID, func(ctx context.Context) error {
+		return r.urlRepo.SetRules(ctx, key, normalized)
+	})
+}
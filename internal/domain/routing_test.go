@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteRule_Match(t *testing.T) {
+	const (
+		iphone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148"
+		android = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36"
+		mac     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 Safari/605.1.15"
+	)
+	now := time.Date(2024, 10, 19, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		rule    RouteRule
+		visit   Visit
+		country string
+		want    bool
+	}{
+		{name: "ios", rule: RouteRule{Devices: []string{DeviceIOS}}, visit: Visit{UserAgent: iphone}, want: true},
+		{name: "iphone is not android", rule: RouteRule{Devices: []string{DeviceAndroid}}, visit: Visit{UserAgent: iphone}},
+		{name: "android is not linux", rule: RouteRule{Devices: []string{DeviceLinux}}, visit: Visit{UserAgent: android}},
+		{name: "mobile", rule: RouteRule{Devices: []string{DeviceMobile}}, visit: Visit{UserAgent: android}, want: true},
+		{name: "desktop", rule: RouteRule{Devices: []string{DeviceDesktop, DeviceIOS}}, visit: Visit{UserAgent: mac}, want: true},
+		{name: "unknown user agent", rule: RouteRule{Devices: []string{DeviceDesktop}}, visit: Visit{UserAgent: "curl/8.0"}},
+		{name: "language region", rule: RouteRule{Languages: []string{"de"}}, visit: Visit{AcceptLanguage: "de-AT,de;q=0.9,en;q=0.8"}, want: true},
+		{name: "only preferred language", rule: RouteRule{Languages: []string{"de"}}, visit: Visit{AcceptLanguage: "en-US,de;q=0.3"}},
+		{name: "language weights", rule: RouteRule{Languages: []string{"de"}}, visit: Visit{AcceptLanguage: "en;q=0.5, DE;q=0.8"}, want: true},
+		{name: "language prefix is not a subtag", rule: RouteRule{Languages: []string{"d"}}, visit: Visit{AcceptLanguage: "de"}},
+		{name: "country", rule: RouteRule{Countries: []string{"AT", "DE"}}, country: "DE", want: true},
+		{name: "unknown country", rule: RouteRule{Countries: []string{"DE"}}},
+		{name: "inside time window", rule: RouteRule{From: &before, Until: &after}, visit: Visit{Time: now}, want: true},
+		{name: "until is exclusive", rule: RouteRule{Until: &now}, visit: Visit{Time: now}},
+		{name: "before window", rule: RouteRule{From: &after}, visit: Visit{Time: now}},
+		{name: "query value", rule: RouteRule{Query: map[string]string{"src": "print"}}, visit: Visit{Query: url.Values{"src": {"print"}}}, want: true},
+		{name: "query presence", rule: RouteRule{Query: map[string]string{"src": ""}}, visit: Visit{Query: url.Values{"src": {"web"}}}, want: true},
+		{name: "query mismatch", rule: RouteRule{Query: map[string]string{"src": "print"}}, visit: Visit{Query: url.Values{"src": {"web"}}}},
+		{name: "all conditions", rule: RouteRule{Devices: []string{DeviceIOS}, Countries: []string{"DE"}}, visit: Visit{UserAgent: iphone}, country: "AT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.rule.match(tt.visit, tt.country))
+		})
+	}
+}
+
+func TestRouteRule_Normalize(t *testing.T) {
+	from := time.Now()
+	until := from.Add(-time.Minute)
+
+	rule, err := RouteRule{Devices: []string{" iOS"}, Languages: []string{"DE-at"}, Countries: []string{"de"}}.normalize("rules[0]")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ios"}, rule.Devices)
+	require.Equal(t, []string{"de-at"}, rule.Languages)
+	require.Equal(t, []string{"DE"}, rule.Countries)
+
+	_, err = RouteRule{}.normalize("rules[0]")
+	require.Equal(t, []FieldError{{Field: "rules[0]", Message: "at least one condition required"}}, FieldErrors(err))
+
+	_, err = RouteRule{Devices: []string{"tv"}, Countries: []string{"DEU"}, From: &from, Until: &until}.normalize("rules[1]")
+	fields := make([]string, 0)
+	for _, e := range FieldErrors(err) {
+		fields = append(fields, e.Field)
+	}
+	require.Equal(t, []string{"rules[1].devices", "rules[1].countries", "rules[1].until"}, fields)
+}
@@ -3,8 +3,11 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"net/url"
+	"strings"
 )
 
 // StatsResponse - dto
@@ -17,43 +20,454 @@ type StatsResponse struct {
 type ShortenerService struct {
 	urlRepo          URLRepository
 	genShortURLToken GenShortURLToken
+	normalizer       *URLNormalizer
+	policy           *URLPolicy
+	workspaces       WorkspaceRepository
+	bans             BanRepository
+	audit            *AuditService
+	tx               Transactor
+	importJobs       ImportJobRepository
+	importLimiter    RateLimiter
+	importLimit      RateLimit
+	redirectType     RedirectType
+	passQuery        bool
+	domains          *DomainService
+	geoIP            GeoIP
+	defaultParams    DefaultParamsRepository
+}
+
+// ShortenerOption - опция сервиса
+type ShortenerOption func(s *ShortenerService)
+
+// WithNormalizer - нормализатор ссылок перед сохранением
+func WithNormalizer(normalizer *URLNormalizer) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.normalizer = normalizer
+	}
+}
+
+// WithURLPolicy - политика допустимых ссылок
+func WithURLPolicy(policy *URLPolicy) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.policy = policy
+	}
+}
+
+// WithWorkspaceRepository - рабочие пространства для проверки прав на общие ссылки
+func WithWorkspaceRepository(workspaces WorkspaceRepository) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.workspaces = workspaces
+	}
+}
+
+// WithBanRepository - запреты на создание ссылок
+func WithBanRepository(bans BanRepository) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.bans = bans
+	}
+}
+
+// WithAuditService - журнал изменений ссылок
+func WithAuditService(audit *AuditService) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.audit = audit
+	}
+}
+
+// WithTransactor - транзакции хранилища, в них изменения ссылок записываются вместе с журналом аудита
+func WithTransactor(tx Transactor) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.tx = tx
+	}
+}
+
+// WithDomainService - брендовые домены, без них ссылки создаются только на основном домене
+func WithDomainService(domains *DomainService) ShortenerOption {
+	return func(s *ShortenerService) {
+		s.domains = domains
+	}
 }
 
 // NewShortenerService конструктор
-func NewShortenerService(urlRepo URLRepository, genShortURLToken GenShortURLToken) *ShortenerService {
-	return &ShortenerService{urlRepo: urlRepo, genShortURLToken: genShortURLToken}
+func NewShortenerService(urlRepo URLRepository, genShortURLToken GenShortURLToken, opts ...ShortenerOption) *ShortenerService {
+	s := &ShortenerService{
+		urlRepo:          urlRepo,
+		genShortURLToken: genShortURLToken,
+		normalizer:       NewURLNormalizer(NormalizeOptions{}),
+		policy:           DefaultURLPolicy(),
+		redirectType:     RedirectTemporary,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// BatchAdd создание ссылок с результатом для каждой в порядке запроса: created, exists с ключом существующей ссылки или invalid.
+// При atomic ссылки создаются, только если все они новые и корректные, иначе ничего не сохраняется,
+// а корректные новые ссылки получают статус skipped.
+func (r *ShortenerService) BatchAdd(ctx context.Context, rawURLs []string, owner Owner, atomic bool) ([]BatchResult, error) {
+	if err := r.authorizeCreate(ctx, owner); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(rawURLs))
+	batch := make([]BatchItem, 0, len(rawURLs))
+	// first - первая ссылка пакета с тем же оригиналом, без atomic повторы получают её ключ
+	first := make(map[string]int, len(rawURLs))
+	duplicates := map[int]int{}
+	failed := false
+	for i, raw := range rawURLs {
+		u, err := r.parseURL(raw)
+		if err != nil {
+			results[i] = BatchResult{Status: BatchInvalid, Error: err.Error()}
+			failed = true
+			continue
+		}
+		if j, ok := first[u.String()]; ok {
+			if atomic {
+				results[i] = BatchResult{Status: BatchInvalid, Error: fmt.Sprintf("duplicates url at index %d", j)}
+				failed = true
+			} else {
+				duplicates[i] = j
+			}
+			continue
+		}
+		first[u.String()] = i
+		results[i] = BatchResult{Key: r.genShortURLToken(), URL: u}
+		batch = append(batch, BatchItem{HashKey: results[i].Key, URL: u})
+	}
+
+	params, err := r.newLinkParams(ctx, owner.UserID)
+	if err != nil {
+		return nil, err
+	}
+	err = inTx(ctx, r.tx, func(ctx context.Context) error {
+		var created map[HashKey]struct{}
+		var err error
+		if atomic {
+			created, err = r.batchAddAtomic(ctx, batch, owner, params, failed)
+		} else {
+			created, err = r.batchAddEach(ctx, batch, owner, params)
+		}
+		if err != nil {
+			return err
+		}
+
+		var existing map[string]HashKey
+		if len(created) < len(batch) {
+			urls := make([]string, 0, len(batch)-len(created))
+			for _, item := range batch {
+				if _, ok := created[item.HashKey]; !ok {
+					urls = append(urls, item.URL.String())
+				}
+			}
+			if existing, err = r.urlRepo.GetKeysByURLs(ctx, urls, owner); err != nil {
+				return err
+			}
+		}
+
+		changes := make([]AuditChange, 0, len(created))
+		for i, res := range results {
+			if res.Status == BatchInvalid {
+				continue
+			}
+			if j, ok := duplicates[i]; ok {
+				res = results[j]
+			}
+			key := res.Key
+			switch _, isCreated := created[key]; {
+			case isCreated && i == first[res.URL.String()]:
+				res.Status = BatchCreated
+				changes = append(changes, AuditChange{Key: key, After: newLink(ctx, key, res.URL, owner, params)})
+			case isCreated:
+				res.Status = BatchExists
+			case existing[res.URL.String()] != "":
+				res.Status, res.Key = BatchExists, existing[res.URL.String()]
+			case atomic:
+				res.Status, res.Key = BatchSkipped, ""
+			default:
+				// ключ совпал со случайно сгенерированным ключом другой ссылки
+				res.Status, res.Key, res.Error = BatchInvalid, "", "key already exists, retry"
+			}
+			results[i] = res
+		}
+		return r.audit.Record(ctx, AuditLinkBatchCreate, owner.UserID, changes...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
 }
 
-// GetOriginLink получение
-func (r *ShortenerService) GetOriginLink(ctx context.Context, hashkey string) (*url.URL, error) {
-	originURL, err := r.urlRepo.GetByHash(ctx, hashkey)
-	return originURL, err
+// batchAddEach сохранение ссылок по отдельности, уже существующие пропускаются
+func (r *ShortenerService) batchAddEach(ctx context.Context, batch []BatchItem, owner Owner, params LinkParams) (map[HashKey]struct{}, error) {
+	created := map[HashKey]struct{}{}
+	if len(batch) == 0 {
+		return created, nil
+	}
+	items := make([]ImportItem, 0, len(batch))
+	for _, item := range batch {
+		items = append(items, ImportItem{Key: item.HashKey, URL: item.URL})
+	}
+	keys, err := r.urlRepo.Import(ctx, items, owner, params)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		created[key] = struct{}{}
+	}
+	return created, nil
 }
 
-// BatchAdd создание
-func (r *ShortenerService) BatchAdd(ctx context.Context, batch []BatchItem, userID uuid.UUID) ([]BatchItem, error) {
-	for i, item := range batch {
-		item.HashKey = r.genShortURLToken()
-		batch[i] = item
+// batchAddAtomic сохранение всех ссылок одной транзакцией, если в пакете нет ошибок.
+// Если какая-то ссылка уже существует, не сохраняется ничего.
+func (r *ShortenerService) batchAddAtomic(ctx context.Context, batch []BatchItem, owner Owner, params LinkParams, failed bool) (map[HashKey]struct{}, error) {
+	if failed || len(batch) == 0 {
+		return nil, nil
 	}
-	return batch, r.urlRepo.BatchAdd(ctx, batch, userID)
+	err := r.urlRepo.BatchAdd(ctx, batch, owner, params)
+	var dupErr *ErrURLAlreadyExists
+	if errors.As(err, &dupErr) {
+		// существующие ссылки найдутся по оригиналам при разборе результатов
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	created := make(map[HashKey]struct{}, len(batch))
+	for _, item := range batch {
+		created[item.HashKey] = struct{}{}
+	}
+	return created, nil
 }
 
-// DeleteByUser удаление
+// DeleteByUser удаление ссылок, которыми пользователь может управлять: личных и из пространств с ролью editor и выше
 func (r *ShortenerService) DeleteByUser(ctx context.Context, keys []HashKey, userID uuid.UUID) (bool, error) {
-	return r.urlRepo.DeleteByUser(ctx, keys, userID)
+	owners, err := r.urlRepo.GetOwners(ctx, keys)
+	if err != nil {
+		return false, err
+	}
+
+	allowed := make([]HashKey, 0, len(keys))
+	roles := map[uuid.UUID]error{}
+	for _, key := range keys {
+		owner, ok := owners[key]
+		if !ok {
+			continue
+		}
+		if owner.WorkspaceID == uuid.Nil {
+			if owner.UserID == userID {
+				allowed = append(allowed, key)
+			}
+			continue
+		}
+		authErr, checked := roles[owner.WorkspaceID]
+		if !checked {
+			authErr = authorizeWorkspace(ctx, r.workspaces, owner.WorkspaceID, userID, RoleEditor)
+			if authErr != nil && !errors.Is(authErr, ErrWorkspaceNotFound) && !errors.Is(authErr, ErrForbidden) {
+				return false, authErr
+			}
+			roles[owner.WorkspaceID] = authErr
+		}
+		if authErr == nil {
+			allowed = append(allowed, key)
+		}
+	}
+	if len(allowed) == 0 {
+		return false, nil
+	}
+
+	var deleted bool
+	err = inTx(ctx, r.tx, func(ctx context.Context) error {
+		var changes []AuditChange
+		if r.audit != nil {
+			links, err := r.urlRepo.GetLinks(ctx, allowed)
+			if err != nil {
+				return err
+			}
+			changes = make([]AuditChange, 0, len(links))
+			for _, before := range links {
+				after := before
+				after.IsDeleted = true
+				changes = append(changes, AuditChange{Key: before.Key, Before: &before, After: &after})
+			}
+		}
+
+		var err error
+		if deleted, err = r.urlRepo.DeleteByKeys(ctx, allowed); err != nil {
+			return err
+		}
+		return r.audit.Record(ctx, AuditLinkDelete, userID, changes...)
+	})
+	if err != nil {
+		return false, err
+	}
+	return deleted && len(allowed) == len(keys), nil
 }
 
-// GetByUser получение
-func (r *ShortenerService) GetByUser(ctx context.Context, userID uuid.UUID) ([]URLEntry, error) {
-	return r.urlRepo.GetByUser(ctx, userID)
+// GetByUser страница личных ссылок пользователя
+func (r *ShortenerService) GetByUser(ctx context.Context, userID uuid.UUID, q LinkQuery) (*LinkPage, error) {
+	q, err := q.normalize()
+	if err != nil {
+		return nil, err
+	}
+	// лишняя ссылка - признак следующей страницы
+	fetch := q
+	fetch.Limit++
+	entries, err := r.urlRepo.GetByUser(ctx, userID, fetch)
+	if err != nil {
+		return nil, err
+	}
+	return q.page(entries), nil
+}
+
+// GetByWorkspace страница ссылок рабочего пространства, доступно любому участнику
+func (r *ShortenerService) GetByWorkspace(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID, q LinkQuery) (*LinkPage, error) {
+	if err := authorizeWorkspace(ctx, r.workspaces, workspaceID, userID, RoleViewer); err != nil {
+		return nil, err
+	}
+	q, err := q.normalize()
+	if err != nil {
+		return nil, err
+	}
+	// лишняя ссылка - признак следующей страницы
+	fetch := q
+	fetch.Limit++
+	entries, err := r.urlRepo.GetByWorkspace(ctx, workspaceID, fetch)
+	if err != nil {
+		return nil, err
+	}
+	return q.page(entries), nil
+}
+
+// reassignChunkSize - сколько ссылок передаётся другому пользователю одной транзакцией
+const reassignChunkSize = 500
+
+// ReassignUser передача всех ссылок пользователя другому пользователю частями по reassignChunkSize,
+// каждая часть передаётся и записывается в журнал одной транзакцией
+func (r *ShortenerService) ReassignUser(ctx context.Context, from uuid.UUID, to uuid.UUID) (int64, error) {
+	if from == to {
+		return 0, nil
+	}
+	var n int64
+	for {
+		var links []Link
+		err := inTx(ctx, r.tx, func(ctx context.Context) error {
+			var err error
+			if links, err = r.urlRepo.ReassignUser(ctx, from, to, reassignChunkSize); err != nil {
+				return err
+			}
+			changes := make([]AuditChange, 0, len(links))
+			for _, after := range links {
+				before := after
+				before.UserID = from
+				changes = append(changes, AuditChange{Key: after.Key, Before: &before, After: &after})
+			}
+			return r.audit.Record(ctx, AuditLinkReassign, to, changes...)
+		})
+		if err != nil {
+			return n, err
+		}
+		n += int64(len(links))
+		if len(links) < reassignChunkSize {
+			return n, nil
+		}
+	}
 }
 
 // CreateShort создание
-func (r *ShortenerService) CreateShort(ctx context.Context, u url.URL, userID uuid.UUID) (HashKey, error) {
+func (r *ShortenerService) CreateShort(ctx context.Context, u url.URL, owner Owner) (HashKey, error) {
+	if err := r.authorizeCreate(ctx, owner); err != nil {
+		return "", err
+	}
+	u, err := r.prepareURL(u)
+	if err != nil {
+		return "", err
+	}
 	key := r.genShortURLToken()
+	params, err := r.newLinkParams(ctx, owner.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	return key, inTx(ctx, r.tx, func(ctx context.Context) error {
+		if err := r.urlRepo.Add(ctx, key, u, owner, params); err != nil {
+			return err
+		}
+		return r.audit.Record(ctx, AuditLinkCreate, owner.UserID, AuditChange{Key: key, After: newLink(ctx, key, u, owner, params)})
+	})
+}
+
+// newLink новая ссылка для журнала аудита
+func newLink(ctx context.Context, key HashKey, u url.URL, owner Owner, params LinkParams) *Link {
+	return &Link{Key: key, Domain: LinkDomainFromCtx(ctx), OriginalURL: u.String(), UserID: owner.UserID, WorkspaceID: owner.WorkspaceID, Params: params}
+}
 
-	return key, r.urlRepo.Add(ctx, key, u, userID)
+// updateLink изменение ссылки и запись в журнал одной транзакцией, возвращает ссылку после изменения
+func (r *ShortenerService) updateLink(ctx context.Context, before *Link, userID uuid.UUID, set func(ctx context.Context) error) (*Link, error) {
+	var after *Link
+	err := inTx(ctx, r.tx, func(ctx context.Context) error {
+		if err := set(ctx); err != nil {
+			return err
+		}
+		var err error
+		if after, err = r.urlRepo.GetLink(ctx, before.Key); err != nil {
+			return err
+		}
+		return r.audit.Record(ctx, AuditLinkUpdate, userID, AuditChange{Key: before.Key, Before: before, After: after})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// authorizeCreate заблокированные пользователи не создают ссылки, в пространстве нужна роль editor и выше,
+// на брендовом домене - разрешение на домен
+func (r *ShortenerService) authorizeCreate(ctx context.Context, owner Owner) error {
+	allowed, err := r.domains.IsAllowed(ctx, LinkDomainFromCtx(ctx), owner.UserID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrDomainNotAllowed
+	}
+	if r.bans != nil {
+		banned, err := r.bans.IsBanned(ctx, owner.UserID)
+		if err != nil {
+			return err
+		}
+		if banned {
+			return ErrUserBanned
+		}
+	}
+	if owner.WorkspaceID == uuid.Nil {
+		return nil
+	}
+	return authorizeWorkspace(ctx, r.workspaces, owner.WorkspaceID, owner.UserID, RoleEditor)
+}
+
+// parseURL разбор ссылки от пользователя с нормализацией и проверкой
+func (r *ShortenerService) parseURL(raw string) (url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return url.URL{}, &ValidationError{Field: "url", Message: "required"}
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return url.URL{}, &ValidationError{Field: "url", Message: err.Error()}
+	}
+	return r.prepareURL(*u)
+}
+
+// prepareURL нормализация и проверка ссылки перед сохранением
+func (r *ShortenerService) prepareURL(u url.URL) (url.URL, error) {
+	u, err := r.normalizer.Normalize(u)
+	if err != nil {
+		return u, err
+	}
+	return u, r.policy.Check(u)
 }
 
 // Stats статистика
@@ -0,0 +1,226 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// MinPasswordLength - минимальная длина пароля
+const MinPasswordLength = 8
+
+// MaxPasswordBytes - максимальная длина пароля в байтах, больше bcrypt не принимает
+const MaxPasswordBytes = 72
+
+// dummyPasswordHash - хеш bcrypt со стоимостью по умолчанию, с которым сравнивается пароль при входе без пользователя
+const dummyPasswordHash = "$2a$10$SqAFfpAm1EPtzQNPPk07vemuFSNpKxyILDjij/OwCFgOOyfGSUBTO"
+
+// ErrUserNotFound - ошибка пользователь не найден
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrUserAlreadyExists - ошибка пользователь с таким email уже зарегистрирован
+var ErrUserAlreadyExists = errors.New("user already exists")
+
+// ErrInvalidCredentials - ошибка неверный email или пароль
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ErrNotAnonymous - ошибка ссылки можно забрать только у анонимного пользователя
+var ErrNotAnonymous = errors.New("links can be claimed only from an anonymous user")
+
+// ErrIdentityAlreadyLinked - ошибка учётная запись провайдера уже привязана
+var ErrIdentityAlreadyLinked = errors.New("identity already linked")
+
+// ValidationError - ошибка некорректных данных пользователя
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+// Error - имлементация error
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// User - зарегистрированный пользователь
+type User struct {
+	ID           uuid.UUID `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	// EmailVerified - email подтверждён внешним провайдером, при регистрации по паролю email не проверяется
+	EmailVerified bool `json:"email_verified"`
+}
+
+// UserIdentity - привязка учётной записи внешнего провайдера к пользователю
+type UserIdentity struct {
+	Issuer    string
+	Subject   string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+// ExternalUser - пользователь, подтверждённый внешним провайдером
+type ExternalUser struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// UserRepository - хранение пользователей
+type UserRepository interface {
+	// Create добавление пользователя, пустой email не проверяется на уникальность
+	Create(ctx context.Context, user User) error
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
+	GetByIdentity(ctx context.Context, issuer string, subject string) (*User, error)
+	AddIdentity(ctx context.Context, identity UserIdentity) error
+}
+
+// UserService - регистрация и вход пользователей
+type UserService struct {
+	users     UserRepository
+	shortener *ShortenerService
+}
+
+// NewUserService конструктор
+func NewUserService(users UserRepository, shortener *ShortenerService) *UserService {
+	return &UserService{users: users, shortener: shortener}
+}
+
+// Signup регистрация по email и паролю
+func (s *UserService) Signup(ctx context.Context, email string, password string) (*User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if len([]rune(password)) < MinPasswordLength {
+		return nil, &ValidationError{Field: "password", Message: fmt.Sprintf("must be at least %d characters", MinPasswordLength)}
+	}
+	if len(password) > MaxPasswordBytes {
+		return nil, &ValidationError{Field: "password", Message: fmt.Sprintf("must be at most %d bytes", MaxPasswordBytes)}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := User{
+		ID:           uuid.New(),
+		Email:        email,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err = s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Login проверка email и пароля
+func (s *UserService) Login(ctx context.Context, email string, password string) (*User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+	// пароль проверяется и для неизвестного email или пользователя без пароля,
+	// чтобы по времени ответа нельзя было узнать зарегистрированные адреса
+	hash := dummyPasswordHash
+	if user != nil && user.PasswordHash != "" {
+		hash = user.PasswordHash
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil || hash == dummyPasswordHash {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// LoginExternal вход через внешний провайдер: subject провайдера отображается в пользователя.
+// При первом входе учётная запись привязывается к пользователю с тем же email, только если email
+// подтверждён и провайдером, и у пользователя. Email регистрации по паролю не проверяется, и иначе
+// зарегистрировавшийся заранее с чужим email сохранил бы доступ к учётной записи после входа владельца,
+// поэтому в этом случае создаётся отдельный пользователь без email.
+func (s *UserService) LoginExternal(ctx context.Context, ext ExternalUser) (*User, error) {
+	user, err := s.users.GetByIdentity(ctx, ext.Issuer, ext.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	var email string
+	if ext.EmailVerified {
+		email, _ = normalizeEmail(ext.Email)
+	}
+	if email != "" {
+		user, err = s.users.GetByEmail(ctx, email)
+		if err != nil && !errors.Is(err, ErrUserNotFound) {
+			return nil, err
+		}
+		if user != nil && !user.EmailVerified {
+			user, email = nil, ""
+		}
+	}
+	if user == nil {
+		user = &User{ID: uuid.New(), Email: email, EmailVerified: email != "", CreatedAt: time.Now().UTC()}
+		if err = s.users.Create(ctx, *user); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.users.AddIdentity(ctx, UserIdentity{
+		Issuer:    ext.Issuer,
+		Subject:   ext.Subject,
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if errors.Is(err, ErrIdentityAlreadyLinked) {
+		// параллельный первый вход
+		return s.users.GetByIdentity(ctx, ext.Issuer, ext.Subject)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetByID получение пользователя
+func (s *UserService) GetByID(ctx context.Context, id uuid.UUID) (*User, error) {
+	return s.users.GetByID(ctx, id)
+}
+
+// ClaimAnonymousLinks перенос ссылок анонимного пользователя зарегистрированному
+func (s *UserService) ClaimAnonymousLinks(ctx context.Context, anonymousID uuid.UUID, userID uuid.UUID) (int64, error) {
+	if anonymousID == userID {
+		return 0, nil
+	}
+	if _, err := s.users.GetByID(ctx, userID); err != nil {
+		return 0, err
+	}
+	_, err := s.users.GetByID(ctx, anonymousID)
+	if err == nil {
+		return 0, ErrNotAnonymous
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return 0, err
+	}
+	return s.shortener.ReassignUser(ctx, anonymousID, userID)
+}
+
+func normalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return "", &ValidationError{Field: "email", Message: "invalid email"}
+	}
+	return strings.ToLower(addr.Address), nil
+}
@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+)
+
+// Ограничения вариантов A/B теста ссылки
+const (
+	MaxLinkVariants  = 10
+	MaxVariantWeight = 1000
+)
+
+var variantIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Variant - вариант адреса ссылки для A/B теста, посетители распределяются пропорционально весу
+type Variant struct {
+	// ID - постоянный идентификатор варианта, по нему запоминается выбор посетителя и считаются переходы
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// VariantStats - переходы на вариант ссылки
+type VariantStats struct {
+	Variant
+	Clicks int64 `json:"clicks"`
+}
+
+// LinkStats - статистика переходов по ссылке с вариантами
+type LinkStats struct {
+	Key HashKey `json:"key"`
+	// Clicks - переходы на все варианты
+	Clicks   int64          `json:"clicks"`
+	Variants []VariantStats `json:"variants"`
+}
+
+// pickVariant вариант, который посетитель уже видел, иначе случайный с учётом весов
+func pickVariant(variants []Variant, sticky string) Variant {
+	total := 0
+	for _, v := range variants {
+		if v.ID == sticky {
+			return v
+		}
+		total += v.Weight
+	}
+	n := rand.IntN(total)
+	for _, v := range variants {
+		if n < v.Weight {
+			return v
+		}
+		n -= v.Weight
+	}
+	return variants[len(variants)-1]
+}
+
+// normalizeVariants проверка вариантов и идентификаторы a, b, c... для вариантов без них
+func (r *ShortenerService) normalizeVariants(variants []Variant) ([]Variant, error) {
+	if len(variants) == 0 {
+		return []Variant{}, nil
+	}
+	if len(variants) < 2 || len(variants) > MaxLinkVariants {
+		return nil, &ValidationError{Field: "variants", Message: fmt.Sprintf("must be from 2 to %d variants", MaxLinkVariants)}
+	}
+	res := make([]Variant, 0, len(variants))
+	seen := make(map[string]struct{}, len(variants))
+	var errs []error
+	for i, v := range variants {
+		field := fmt.Sprintf("variants[%d]", i)
+		v.ID = strings.ToLower(strings.TrimSpace(v.ID))
+		if v.ID == "" {
+			v.ID = string(rune('a' + i))
+		}
+		if !variantIDPattern.MatchString(v.ID) {
+			errs = append(errs, &ValidationError{Field: field + ".id", Message: "must be 1 to 32 of a-z, 0-9, _ and -"})
+		} else if _, ok := seen[v.ID]; ok {
+			errs = append(errs, &ValidationError{Field: field + ".id", Message: "duplicate id"})
+		}
+		seen[v.ID] = struct{}{}
+		if v.Weight < 1 || v.Weight > MaxVariantWeight {
+			errs = append(errs, &ValidationError{Field: field + ".weight", Message: fmt.Sprintf("must be from 1 to %d", MaxVariantWeight)})
+		}
+		u, err := r.parseURL(v.URL)
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			err = &ValidationError{Field: field + ".url", Message: validationErr.Message}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		v.URL = u.String()
+		res = append(res, v)
+	}
+	return res, errors.Join(errs...)
+}
+
+// SetVariants замена вариантов A/B теста ссылки, пустой список отключает тест.
+// Переходы считаются по идентификаторам, поэтому у варианта с прежним id счётчик сохраняется.
+func (r *ShortenerService) SetVariants(ctx context.Context, key HashKey, userID uuid.UUID, variants []Variant) (*Link, error) {
+	variants, err := r.normalizeVariants(variants)
+	if err != nil {
+		return nil, err
+	}
+	before, err := r.urlRepo.GetLink(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if before.IsDeleted {
+		return nil, ErrURLNotFound
+	}
+	if err = r.authorizeManage(ctx, before, userID); err != nil {
+		return nil, err
+	}
+
+	return r.updateLink(ctx, before, userID, func(ctx context.Context) error {
+		return r.urlRepo.SetVariants(ctx, key, variants)
+	})
+}
+
+// RecordClick учёт перехода по ссылке на выбранный вариант, переходы без варианта не считаются
+func (r *ShortenerService) RecordClick(ctx context.Context, redirect *Redirect) error {
+	if redirect.Variant == "" {
+		return nil
+	}
+	return r.urlRepo.AddVariantClick(ctx, redirect.Key, redirect.Variant)
+}
+
+// LinkStats переходы по вариантам ссылки, доступна всем, кто видит ссылку
+func (r *ShortenerService) LinkStats(ctx context.Context, key HashKey, userID uuid.UUID) (*LinkStats, error) {
+	link, err := r.urlRepo.GetLink(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if link.IsDeleted {
+		return nil, ErrURLNotFound
+	}
+	if err = r.authorizeLink(ctx, link, userID, RoleViewer); err != nil {
+		return nil, err
+	}
+	clicks, err := r.urlRepo.GetVariantClicks(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	stats := &LinkStats{Key: link.Key, Variants: make([]VariantStats, 0, len(link.Variants))}
+	for _, v := range link.Variants {
+		stats.Variants = append(stats.Variants, VariantStats{Variant: v, Clicks: clicks[v.ID]})
+		stats.Clicks += clicks[v.ID]
+	}
+	return stats, nil
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickVariant(t *testing.T) {
+	variants := []Variant{{ID: "a", Weight: 70}, {ID: "b", Weight: 30}, {ID: "off", Weight: 1}}
+
+	require.Equal(t, "b", pickVariant(variants, "b").ID, "sticky variant")
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[pickVariant(variants, "gone").ID]++
+	}
+	require.InDelta(t, 7000, counts["a"], 400)
+	require.InDelta(t, 3000, counts["b"], 400)
+	require.Less(t, counts["off"], 300)
+}
+
+func TestShortenerService_NormalizeVariants(t *testing.T) {
+	service := NewShortenerService(nil, nil)
+
+	variants, err := service.normalizeVariants([]Variant{
+		{URL: "https://Example.com/a", Weight: 70},
+		{ID: " Landing-B ", URL: "https://example.com/b", Weight: 30},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Variant{
+		{ID: "a", URL: "https://example.com/a", Weight: 70},
+		{ID: "landing-b", URL: "https://example.com/b", Weight: 30},
+	}, variants)
+
+	variants, err = service.normalizeVariants(nil)
+	require.NoError(t, err)
+	require.Empty(t, variants)
+
+	_, err = service.normalizeVariants([]Variant{{URL: "https://example.com", Weight: 1}})
+	require.Equal(t, []FieldError{{Field: "variants", Message: "must be from 2 to 10 variants"}}, FieldErrors(err))
+
+	_, err = service.normalizeVariants([]Variant{
+		{ID: "b", URL: "https://example.com/a", Weight: 0},
+		{URL: "", Weight: 1},
+	})
+	require.Equal(t, []FieldError{
+		{Field: "variants[0].weight", Message: "must be from 1 to 1000"},
+		{Field: "variants[1].id", Message: "duplicate id"},
+		{Field: "variants[1].url", Message: "required"},
+	}, FieldErrors(err))
+}
@@ -0,0 +1,252 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// Роли участников рабочего пространства
+const (
+	RoleOwner  WorkspaceRole = "owner"
+	RoleEditor WorkspaceRole = "editor"
+	RoleViewer WorkspaceRole = "viewer"
+)
+
+// ErrWorkspaceNotFound - ошибка рабочее пространство не найдено или пользователь не участник
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+
+// ErrMemberNotFound - ошибка пользователь не участник рабочего пространства
+var ErrMemberNotFound = errors.New("workspace member not found")
+
+// ErrForbidden - ошибка недостаточно прав
+var ErrForbidden = errors.New("forbidden")
+
+// ErrLastOwner - ошибка нельзя убрать последнего владельца
+var ErrLastOwner = errors.New("workspace must have at least one owner")
+
+// WorkspaceRole - роль участника
+type WorkspaceRole string
+
+var roleRank = map[WorkspaceRole]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// Valid известная роль
+func (r WorkspaceRole) Valid() bool {
+	return roleRank[r] > 0
+}
+
+// Allows роль не ниже требуемой
+func (r WorkspaceRole) Allows(required WorkspaceRole) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+// Owner - владелец ссылки: автор и рабочее пространство, uuid.Nil - личная ссылка
+type Owner struct {
+	UserID      uuid.UUID
+	WorkspaceID uuid.UUID
+}
+
+// Workspace - рабочее пространство с общими ссылками
+type Workspace struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WorkspaceMember - участник рабочего пространства
+type WorkspaceMember struct {
+	WorkspaceID uuid.UUID     `json:"workspace_id"`
+	UserID      uuid.UUID     `json:"user_id"`
+	Role        WorkspaceRole `json:"role"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// WorkspaceMembership - рабочее пространство и роль в нём пользователя
+type WorkspaceMembership struct {
+	Workspace
+	Role WorkspaceRole `json:"role"`
+}
+
+// WorkspaceRepository - хранение рабочих пространств и участников
+type WorkspaceRepository interface {
+	// Create создание пространства вместе с первым владельцем
+	Create(ctx context.Context, ws Workspace, owner WorkspaceMember) error
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]WorkspaceMembership, error)
+	GetMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) (*WorkspaceMember, error)
+	ListMembers(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceMember, error)
+	// SetMember добавление участника или смена роли
+	SetMember(ctx context.Context, member WorkspaceMember) error
+	RemoveMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) error
+}
+
+// authorizeWorkspace проверка роли пользователя, не участнику пространство не видно
+func authorizeWorkspace(ctx context.Context, repo WorkspaceRepository, workspaceID uuid.UUID, userID uuid.UUID, required WorkspaceRole) error {
+	if repo == nil {
+		return ErrWorkspaceNotFound
+	}
+	member, err := repo.GetMember(ctx, workspaceID, userID)
+	if errors.Is(err, ErrMemberNotFound) {
+		return ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !member.Role.Allows(required) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// WorkspaceService - управление рабочими пространствами
+type WorkspaceService struct {
+	repo  WorkspaceRepository
+	audit *AuditService
+	tx    Transactor
+}
+
+// WorkspaceOption - опция рабочих пространств
+type WorkspaceOption func(s *WorkspaceService)
+
+// WithWorkspaceAuditService - журнал изменений пространств и их участников
+func WithWorkspaceAuditService(audit *AuditService) WorkspaceOption {
+	return func(s *WorkspaceService) {
+		s.audit = audit
+	}
+}
+
+// WithWorkspaceTransactor - транзакции хранилища, в них изменения участников записываются вместе с журналом аудита
+func WithWorkspaceTransactor(tx Transactor) WorkspaceOption {
+	return func(s *WorkspaceService) {
+		s.tx = tx
+	}
+}
+
+// NewWorkspaceService конструктор
+func NewWorkspaceService(repo WorkspaceRepository, opts ...WorkspaceOption) *WorkspaceService {
+	s := &WorkspaceService{repo: repo}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Create создание пространства, создатель становится владельцем
+func (s *WorkspaceService) Create(ctx context.Context, name string, userID uuid.UUID) (*Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, &ValidationError{Field: "name", Message: "must not be empty"}
+	}
+	now := time.Now().UTC()
+	ws := Workspace{ID: uuid.New(), Name: name, CreatedAt: now}
+	owner := WorkspaceMember{WorkspaceID: ws.ID, UserID: userID, Role: RoleOwner, CreatedAt: now}
+	err := inTx(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, ws, owner); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditWorkspaceCreate, userID, AuditChange{Subject: ws.ID.String(), Details: map[string]string{"name": ws.Name}})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ws, nil
+}
+
+// List пространства пользователя
+func (s *WorkspaceService) List(ctx context.Context, userID uuid.UUID) ([]WorkspaceMembership, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Authorize проверка роли пользователя в пространстве
+func (s *WorkspaceService) Authorize(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID, required WorkspaceRole) error {
+	return authorizeWorkspace(ctx, s.repo, workspaceID, userID, required)
+}
+
+// Members участники пространства, доступно любому участнику
+func (s *WorkspaceService) Members(ctx context.Context, workspaceID uuid.UUID, actorID uuid.UUID) ([]WorkspaceMember, error) {
+	if err := s.Authorize(ctx, workspaceID, actorID, RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(ctx, workspaceID)
+}
+
+// SetMember добавление участника или смена роли, только владельцем
+func (s *WorkspaceService) SetMember(ctx context.Context, workspaceID uuid.UUID, actorID uuid.UUID, userID uuid.UUID, role WorkspaceRole) (*WorkspaceMember, error) {
+	if !role.Valid() {
+		return nil, &ValidationError{Field: "role", Message: "must be one of owner, editor, viewer"}
+	}
+	if err := s.Authorize(ctx, workspaceID, actorID, RoleOwner); err != nil {
+		return nil, err
+	}
+
+	member, err := s.repo.GetMember(ctx, workspaceID, userID)
+	if errors.Is(err, ErrMemberNotFound) {
+		member = &WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, CreatedAt: time.Now().UTC()}
+	} else if err != nil {
+		return nil, err
+	}
+	if member.Role == RoleOwner && role != RoleOwner {
+		if err = s.ensureAnotherOwner(ctx, workspaceID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	details := map[string]string{"user_id": userID.String(), "role": string(role), "previous_role": string(member.Role)}
+	member.Role = role
+	err = inTx(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.SetMember(ctx, *member); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditWorkspaceMemberSet, actorID, AuditChange{Subject: workspaceID.String(), Details: details})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember исключение участника владельцем или выход из пространства
+func (s *WorkspaceService) RemoveMember(ctx context.Context, workspaceID uuid.UUID, actorID uuid.UUID, userID uuid.UUID) error {
+	required := RoleOwner
+	if actorID == userID {
+		required = RoleViewer
+	}
+	if err := s.Authorize(ctx, workspaceID, actorID, required); err != nil {
+		return err
+	}
+
+	member, err := s.repo.GetMember(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role == RoleOwner {
+		if err = s.ensureAnotherOwner(ctx, workspaceID, userID); err != nil {
+			return err
+		}
+	}
+	return inTx(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.RemoveMember(ctx, workspaceID, userID); err != nil {
+			return err
+		}
+		details := map[string]string{"user_id": userID.String(), "role": string(member.Role)}
+		return s.audit.Record(ctx, AuditWorkspaceMemberRemove, actorID, AuditChange{Subject: workspaceID.String(), Details: details})
+	})
+}
+
+func (s *WorkspaceService) ensureAnotherOwner(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) error {
+	members, err := s.repo.ListMembers(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if m.Role == RoleOwner && m.UserID != userID {
+			return nil
+		}
+	}
+	return ErrLastOwner
+}
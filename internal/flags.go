@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 )
 
 // InitConfig инициализация конфигурации
@@ -62,11 +63,29 @@ func InitConfig() {
 
 	Config.DatabaseDSN = strings.TrimSpace(Config.DatabaseDSN)
 
-	if Config.JwtSecret == "" {
-		Config.JwtSecret = "secret"
+	// с JWT_KEYS_DIR секрет нужен только для старых токенов и по умолчанию не задаётся
+	if Config.JwtSecret == "" && Config.JwtKeysDir == "" {
+		Config.JwtSecret = DefaultJwtSecret
+	}
+	if Config.JwtRefreshTTL == 0 {
+		Config.JwtRefreshTTL = 30 * 24 * time.Hour
+	}
+	if Config.JwtKeyGrace == 0 {
+		Config.JwtKeyGrace = Config.JwtRefreshTTL
 	}
 
 	parseFromConfigFile(configFile)
+
+	if Config.OIDCRedirectURL == "" {
+		Config.OIDCRedirectURL = strings.TrimSuffix(Config.BaseURL, "/") + "/api/auth/oidc/callback"
+	}
+	if len(Config.OIDCScopes) == 0 {
+		Config.OIDCScopes = []string{"openid", "email", "profile"}
+	}
+
+	if Config.IsProduction() && Config.JwtSecret == DefaultJwtSecret {
+		log.Fatal("refusing to start in production with the default jwt secret: set JWT_SECRET or JWT_KEYS_DIR")
+	}
 }
 
 func parseFromConfigFile(configFile *string) {
@@ -97,16 +116,55 @@ func parseFromConfigFile(configFile *string) {
 	if c.DatabaseDSN != "" {
 		Config.DatabaseDSN = c.DatabaseDSN
 	}
+	if len(c.Domains) > 0 {
+		Config.Domains = c.Domains
+	}
 	if c.TrustedSubnet != "" {
 		Config.TrustedSubnet = c.TrustedSubnet
 	}
+	if len(c.TrustedProxies) > 0 {
+		Config.TrustedProxies = c.TrustedProxies
+	}
+	if c.ClientIPHeader != "" {
+		Config.ClientIPHeader = c.ClientIPHeader
+	}
+	if c.NormalizeStripTracking {
+		Config.NormalizeStripTracking = true
+	}
+	if c.DomainBlocklistFile != "" {
+		Config.DomainBlocklistFile = c.DomainBlocklistFile
+	}
+	if c.OIDCIssuer != "" {
+		Config.OIDCIssuer = c.OIDCIssuer
+	}
+	if c.OIDCClientID != "" {
+		Config.OIDCClientID = c.OIDCClientID
+	}
+	if len(c.AdminUserIDs) > 0 {
+		Config.AdminUserIDs = c.AdminUserIDs
+	}
+	if c.AuditLogFile != "" {
+		Config.AuditLogFile = c.AuditLogFile
+	}
 }
 
 type jsonConfig struct {
-	ServerAddress   string `json:"server_address"`
-	BaseURL         string `json:"base_url"`
-	FileStoragePath string `json:"file_storage_path"`
-	DatabaseDSN     string `json:"database_dsn"`
-	EnableHTTPS     bool   `json:"enable_https"`
-	TrustedSubnet   string `json:"trusted_subnet"`
+	ServerAddress   string   `json:"server_address"`
+	BaseURL         string   `json:"base_url"`
+	FileStoragePath string   `json:"file_storage_path"`
+	DatabaseDSN     string   `json:"database_dsn"`
+	EnableHTTPS     bool     `json:"enable_https"`
+	Domains         []string `json:"domains"`
+	TrustedSubnet   string   `json:"trusted_subnet"`
+	TrustedProxies  []string `json:"trusted_proxies"`
+	ClientIPHeader  string   `json:"client_ip_header"`
+
+	NormalizeStripTracking bool   `json:"normalize_strip_tracking"`
+	DomainBlocklistFile    string `json:"domain_blocklist_file"`
+
+	OIDCIssuer   string `json:"oidc_issuer"`
+	OIDCClientID string `json:"oidc_client_id"`
+
+	AdminUserIDs []string `json:"admin_user_ids"`
+	AuditLogFile string   `json:"audit_log_file"`
 }
@@ -0,0 +1,117 @@
+package grpc
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/proto"
+)
+
+// AdminService - grpc сервис модерации
+type AdminService struct {
+	proto.UnimplementedURLShortenerAdminServer
+	admin   *domain.AdminService
+	domains *domain.DomainService
+}
+
+// NewAdminService конструктор
+func NewAdminService(admin *domain.AdminService, domains *domain.DomainService) *AdminService {
+	return &AdminService{admin: admin, domains: domains}
+}
+
+// GetLink любая ссылка с владельцем
+func (s *AdminService) GetLink(ctx context.Context, req *proto.AdminGetLinkRequest) (*proto.AdminLink, error) {
+	link, err := s.admin.GetLink(ctx, req.Key)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return adminLink(*link), nil
+}
+
+// SetLinkDisabled отключение или включение ссылки
+func (s *AdminService) SetLinkDisabled(ctx context.Context, req *proto.SetLinkDisabledRequest) (*proto.AdminLink, error) {
+	link, err := s.admin.SetLinkDisabled(ctx, req.Key, req.Disabled)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return adminLink(*link), nil
+}
+
+// DeleteByDomain удаление ссылок на домен
+func (s *AdminService) DeleteByDomain(ctx context.Context, req *proto.DeleteByDomainRequest) (*proto.DeleteByDomainResponse, error) {
+	keys, err := s.admin.DeleteByDomain(ctx, req.Domain)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &proto.DeleteByDomainResponse{Keys: keys}, nil
+}
+
+// ListUserLinks ссылки пользователя
+func (s *AdminService) ListUserLinks(ctx context.Context, req *proto.ListUserLinksRequest) (*proto.ListUserLinksResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fieldError(ctx, "user_id", "invalid uuid")
+	}
+	links, err := s.admin.ListUserLinks(ctx, userID)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	res := &proto.ListUserLinksResponse{Links: make([]*proto.AdminLink, 0, len(links))}
+	for _, link := range links {
+		res.Links = append(res.Links, adminLink(link))
+	}
+	return res, nil
+}
+
+// SetUserBan запрет или разрешение создавать ссылки
+func (s *AdminService) SetUserBan(ctx context.Context, req *proto.SetUserBanRequest) (*proto.SetUserBanResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fieldError(ctx, "user_id", "invalid uuid")
+	}
+	if req.Banned {
+		adminID, _ := adapters.UserIDFromCtx(ctx)
+		err = s.admin.BanUser(ctx, userID, req.Reason, adminID)
+	} else {
+		err = s.admin.UnbanUser(ctx, userID)
+	}
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &proto.SetUserBanResponse{}, nil
+}
+
+// SetUserDomain разрешение или запрет создавать ссылки на брендовом домене
+func (s *AdminService) SetUserDomain(ctx context.Context, req *proto.SetUserDomainRequest) (*proto.SetUserDomainResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fieldError(ctx, "user_id", "invalid uuid")
+	}
+	if req.Allowed {
+		adminID, _ := adapters.UserIDFromCtx(ctx)
+		_, err = s.domains.Grant(ctx, req.Domain, userID, adminID)
+	} else {
+		err = s.domains.Revoke(ctx, req.Domain, userID)
+	}
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &proto.SetUserDomainResponse{}, nil
+}
+
+func adminLink(link domain.Link) *proto.AdminLink {
+	res := &proto.AdminLink{
+		Key:         link.Key,
+		Domain:      link.Domain,
+		ShortUrl:    link.ShortURL,
+		OriginalUrl: link.OriginalURL,
+		UserId:      link.UserID.String(),
+		IsDeleted:   link.IsDeleted,
+		IsDisabled:  link.IsDisabled,
+	}
+	if link.WorkspaceID != uuid.Nil {
+		res.WorkspaceId = link.WorkspaceID.String()
+	}
+	return res
+}
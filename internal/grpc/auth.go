@@ -0,0 +1,111 @@
+package grpc
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strings"
+)
+
+// methodScopes - права api ключа, необходимые для вызова метода
+var methodScopes = map[string]string{
+	proto.URLShortener_CreateShort_FullMethodName:      domain.ScopeCreate,
+	proto.URLShortener_Shorten_FullMethodName:          domain.ScopeCreate,
+	proto.URLShortener_ShortenBatch_FullMethodName:     domain.ScopeCreate,
+	proto.URLShortener_GetUserUrls_FullMethodName:      domain.ScopeRead,
+	proto.URLShortener_DeleteUrls_FullMethodName:       domain.ScopeDelete,
+	proto.URLShortener_ImportUrls_FullMethodName:       domain.ScopeCreate,
+	proto.URLShortener_ExportUrls_FullMethodName:       domain.ScopeRead,
+	proto.URLShortener_GetStats_FullMethodName:         domain.ScopeStats,
+	proto.URLShortener_SetLinkRedirect_FullMethodName:  domain.ScopeCreate,
+	proto.URLShortener_SetLinkPreview_FullMethodName:   domain.ScopeCreate,
+	proto.URLShortener_SetLinkRules_FullMethodName:     domain.ScopeCreate,
+	proto.URLShortener_SetLinkVariants_FullMethodName:  domain.ScopeCreate,
+	proto.URLShortener_GetLinkStats_FullMethodName:     domain.ScopeRead,
+	proto.URLShortener_SetLinkParams_FullMethodName:    domain.ScopeCreate,
+	proto.URLShortener_GetDefaultParams_FullMethodName: domain.ScopeRead,
+	proto.URLShortener_SetDefaultParams_FullMethodName: domain.ScopeCreate,
+	proto.URLShortener_SetLinkMeta_FullMethodName:      domain.ScopeCreate,
+	proto.URLShortener_RenameTag_FullMethodName:        domain.ScopeCreate,
+}
+
+// adminMethodPrefix - методы сервиса модерации
+var adminMethodPrefix = "/" + proto.URLShortenerAdmin_ServiceDesc.ServiceName + "/"
+
+// AuthInterceptor проверка jwt или api ключа из метаданных authorization или x-api-key и добавление пользователя в context.
+// Запросы без токена пропускаются, но методы с пользователем или рабочим пространством без токена отклоняются,
+// см. userIDFromRequest. Методы модерации доступны только администраторам.
+func AuthInterceptor(auth *adapters.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, auth, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor проверка токена для потоковых методов, как в AuthInterceptor
+func StreamAuthInterceptor(auth *adapters.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), auth, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate context с пользователем из токена, без токена context не меняется
+func authenticate(ctx context.Context, auth *adapters.Authenticator, fullMethod string) (context.Context, error) {
+	adminOnly := strings.HasPrefix(fullMethod, adminMethodPrefix)
+	token := tokenFromMetadata(ctx)
+	if token == "" {
+		if adminOnly {
+			return nil, statusError(ctx, domain.CodeUnauthorized, "access token required", nil)
+		}
+		return ctx, nil
+	}
+	identity, err := auth.Authenticate(ctx, token)
+	if err != nil {
+		return nil, statusError(ctx, domain.CodeUnauthorized, "invalid access token", nil)
+	}
+	if scope, ok := methodScopes[fullMethod]; ok && !identity.HasScope(scope) {
+		return nil, statusError(ctx, domain.CodeInsufficientScope, "api key has no "+scope+" scope", nil)
+	}
+	if adminOnly && !auth.IsAdmin(identity) {
+		return nil, statusError(ctx, domain.CodeAdminRequired, "admin role required", nil)
+	}
+	return adapters.IdentityToCtx(ctx, identity), nil
+}
+
+func tokenFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get("x-api-key"); len(v) > 0 && v[0] != "" {
+		return v[0]
+	}
+	if v := md.Get("authorization"); len(v) > 0 {
+		return strings.TrimPrefix(v[0], "Bearer ")
+	}
+	return ""
+}
+
+// userIDFromRequest пользователь из токена. Поле user_id запроса пользователя не задаёт -
+// его может подставить кто угодно, оно лишь должно совпадать с токеном, если указано.
+func userIDFromRequest(ctx context.Context, reqUserID string) (uuid.UUID, error) {
+	identity, ok := adapters.IdentityFromCtx(ctx)
+	if !ok {
+		return uuid.Nil, statusError(ctx, domain.CodeUnauthorized, "access token required", nil)
+	}
+	if reqUserID != "" && reqUserID != identity.UserID.String() {
+		return uuid.Nil, statusError(ctx, domain.CodeForbidden, "user_id does not match access token", nil)
+	}
+	return identity.UserID, nil
+}
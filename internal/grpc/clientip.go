@@ -0,0 +1,40 @@
+package grpc
+
+import (
+	"context"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net/http"
+)
+
+// ClientIPInterceptor определение ip клиента с учётом доверенных прокси и добавление его в context
+func ClientIPInterceptor(resolver *adapters.ClientIPResolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(clientIPToCtx(ctx, resolver), req)
+	}
+}
+
+// StreamClientIPInterceptor ip клиента для потоковых методов, как в ClientIPInterceptor
+func StreamClientIPInterceptor(resolver *adapters.ClientIPResolver) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: clientIPToCtx(ss.Context(), resolver)})
+	}
+}
+
+func clientIPToCtx(ctx context.Context, resolver *adapters.ClientIPResolver) context.Context {
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	header := http.Header{}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for k, values := range md {
+			for _, v := range values {
+				header.Add(k, v)
+			}
+		}
+	}
+	return adapters.ClientIPToCtx(ctx, resolver.Resolve(remoteAddr, header))
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain - домен ErrorInfo, reason в нём - код ошибки как в http ответах
+const errorDomain = "url-shortener"
+
+// statusCodes - коды статуса grpc для кодов ошибок
+var statusCodes = map[domain.ErrorCode]codes.Code{
+	domain.CodeInvalidRequest:        codes.InvalidArgument,
+	domain.CodeValidationFailed:      codes.InvalidArgument,
+	domain.CodeInvalidURL:            codes.InvalidArgument,
+	domain.CodePayloadTooLarge:       codes.InvalidArgument,
+	domain.CodeUnauthorized:          codes.Unauthenticated,
+	domain.CodeInvalidCredentials:    codes.Unauthenticated,
+	domain.CodeForbidden:             codes.PermissionDenied,
+	domain.CodeInsufficientScope:     codes.PermissionDenied,
+	domain.CodeAdminRequired:         codes.PermissionDenied,
+	domain.CodeUserBanned:            codes.PermissionDenied,
+	domain.CodeDomainNotAllowed:      codes.PermissionDenied,
+	domain.CodeNotFound:              codes.NotFound,
+	domain.CodeMethodNotAllowed:      codes.Unimplemented,
+	domain.CodeLinkNotFound:          codes.NotFound,
+	domain.CodeLinkDeleted:           codes.NotFound,
+	domain.CodeLinkExpired:           codes.NotFound,
+	domain.CodeLinkDisabled:          codes.PermissionDenied,
+	domain.CodeURLAlreadyExists:      codes.AlreadyExists,
+	domain.CodeUserNotFound:          codes.NotFound,
+	domain.CodeUserAlreadyExists:     codes.AlreadyExists,
+	domain.CodeIdentityAlreadyLinked: codes.AlreadyExists,
+	domain.CodeNotAnonymous:          codes.FailedPrecondition,
+	domain.CodeAPIKeyNotFound:        codes.NotFound,
+	domain.CodeWorkspaceNotFound:     codes.NotFound,
+	domain.CodeMemberNotFound:        codes.NotFound,
+	domain.CodeLastOwner:             codes.FailedPrecondition,
+	domain.CodeImportJobNotFound:     codes.NotFound,
+	domain.CodeImportInterrupted:     codes.Aborted,
+	domain.CodeFeatureUnavailable:    codes.Unimplemented,
+	domain.CodeRateLimited:           codes.ResourceExhausted,
+	domain.CodeInternal:              codes.Internal,
+}
+
+// statusError ошибка с кодом в деталях: ErrorInfo с reason code, BadRequest с ошибками полей и RequestInfo с идентификатором запроса
+func statusError(ctx context.Context, code domain.ErrorCode, msg string, fields []domain.FieldError, details ...protoadapt.MessageV1) error {
+	grpcCode, ok := statusCodes[code]
+	if !ok {
+		grpcCode = codes.Internal
+	}
+	details = append(details, &errdetails.ErrorInfo{Reason: string(code), Domain: errorDomain})
+	if len(fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(fields))
+		for _, f := range fields {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message})
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+	if requestID := adapters.RequestIDFromCtx(ctx); requestID != "" {
+		details = append(details, &errdetails.RequestInfo{RequestId: requestID})
+	}
+
+	st := status.New(grpcCode, msg)
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// fieldError ошибка некорректного значения поля запроса
+func fieldError(ctx context.Context, field string, message string) error {
+	return statusError(ctx, domain.CodeValidationFailed, field+": "+message, []domain.FieldError{{Field: field, Message: message}})
+}
+
+// serviceError ошибка сервиса с кодом из domain.ErrorCodeOf, подробности внутренних ошибок не отдаются
+func serviceError(ctx context.Context, err error) error {
+	code := domain.ErrorCodeOf(err)
+	if code == domain.CodeInternal {
+		return statusError(ctx, code, "internal server error", nil)
+	}
+	return statusError(ctx, code, err.Error(), domain.FieldErrors(err))
+}
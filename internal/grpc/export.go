@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/proto"
+	"google.golang.org/grpc"
+)
+
+// ExportUrls выгрузка всех ссылок пользователя или рабочего пространства
+func (s *GrpcService) ExportUrls(req *proto.ExportUrlsRequest, stream grpc.ServerStreamingServer[proto.ExportUrl]) error {
+	owner, err := ownerFromRequest(stream.Context(), req.UserId, req.WorkspaceId)
+	if err != nil {
+		return err
+	}
+	sent := false
+	err = s.service.Export(stream.Context(), owner, func(e domain.ExportEntry) error {
+		sent = true
+		res := &proto.ExportUrl{
+			Key:         e.Key,
+			ShortUrl:    e.ShortURL,
+			OriginalUrl: e.OriginalURL,
+			CreatedAt:   e.CreatedAt.Unix(),
+			IsDeleted:   e.IsDeleted,
+			Clicks:      e.Clicks,
+		}
+		if e.ExpiresAt != nil {
+			res.ExpiresAt = e.ExpiresAt.Unix()
+		}
+		return stream.Send(res)
+	})
+	if err != nil && !sent {
+		return serviceError(stream.Context(), err)
+	}
+	return err
+}
@@ -7,10 +7,8 @@ import (
 	"github.com/sashaaro/url-shortener/internal/adapters"
 	"github.com/sashaaro/url-shortener/internal/domain"
 	"github.com/sashaaro/url-shortener/proto"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"net/url"
-	"strings"
+	"time"
 )
 
 // GrpcService - сервис
@@ -27,50 +25,120 @@ func NewGrpcService(service *domain.ShortenerService, genShortURLToken domain.Ge
 
 // Shorten создание
 func (s *GrpcService) Shorten(ctx context.Context, req *proto.ShortenRequest) (*proto.ShortenResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	owner, err := ownerFromRequest(ctx, req.UserId, req.WorkspaceId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, err
 	}
 	originURL, err := url.Parse(req.Url)
-	if err != nil || !strings.HasPrefix(originURL.Scheme, "http") {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+	if err != nil {
+		return nil, statusError(ctx, domain.CodeInvalidURL, err.Error(), nil)
 	}
 
-	key, err := s.service.CreateShort(ctx, *originURL, userID)
+	key, err := s.service.CreateShort(ctx, *originURL, owner)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, serviceError(ctx, err)
 	}
 
-	return &proto.ShortenResponse{Result: adapters.CreatePublicURL(key)}, nil
+	return &proto.ShortenResponse{Result: adapters.CreatePublicURL(domain.LinkDomainFromCtx(ctx), key)}, nil
 }
 
-// GetOriginLink получение
+// ShortenBatch создание нескольких ссылок с результатом для каждой, с atomic - все или ни одной
+func (s *GrpcService) ShortenBatch(ctx context.Context, req *proto.ShortenBatchRequest) (*proto.ShortenBatchResponse, error) {
+	owner, err := ownerFromRequest(ctx, req.UserId, req.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	rawURLs := make([]string, 0, len(req.Items))
+	for _, item := range req.Items {
+		rawURLs = append(rawURLs, item.OriginalUrl)
+	}
+
+	results, err := s.service.BatchAdd(ctx, rawURLs, owner, req.Atomic)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	res := &proto.ShortenBatchResponse{Results: make([]*proto.ShortenBatchResult, 0, len(results))}
+	for i, r := range results {
+		item := &proto.ShortenBatchResult{CorrelationId: req.Items[i].CorrelationId, Status: string(r.Status), Error: r.Error}
+		if r.Key != "" {
+			item.ShortUrl = adapters.CreatePublicURL(domain.LinkDomainFromCtx(ctx), r.Key)
+		}
+		res.Results = append(res.Results, item)
+	}
+	return res, nil
+}
+
+// GetOriginLink адрес и код редиректа
 func (s *GrpcService) GetOriginLink(ctx context.Context, req *proto.GetOriginLinkRequest) (*proto.GetOriginLinkResponse, error) {
-	originLink, err := s.service.GetOriginLink(ctx, req.Hash)
+	query, err := url.ParseQuery(req.Query)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, fieldError(ctx, "query", "invalid query")
+	}
+	redirect, err := s.service.Resolve(ctx, req.Hash, domain.Visit{
+		UserAgent:      req.UserAgent,
+		AcceptLanguage: req.AcceptLanguage,
+		Referrer:       req.Referrer,
+		IP:             adapters.ClientIPFromCtx(ctx),
+		Query:          query,
+		Time:           time.Now(),
+		Variant:        req.Variant,
+	})
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	if !redirect.Interstitial() {
+		if err = s.service.RecordClick(ctx, redirect); err != nil {
+			return nil, serviceError(ctx, err)
+		}
 	}
-	return &proto.GetOriginLinkResponse{OriginalUrl: originLink.String()}, nil
+	return &proto.GetOriginLinkResponse{
+		OriginalUrl:  redirect.Location(req.Query),
+		RedirectType: int32(redirect.Type),
+		Interstitial: redirect.Interstitial(),
+		Warning:      redirect.Warning,
+		Variant:      redirect.Variant,
+	}, nil
 }
 
 // GetUserUrls получение
 func (s *GrpcService) GetUserUrls(ctx context.Context, req *proto.GetUserUrlsRequest) (*proto.GetUserUrlsResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	owner, err := ownerFromRequest(ctx, req.UserId, req.WorkspaceId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, err
+	}
+
+	q := domain.LinkQuery{Limit: int(req.Limit), Ascending: req.Ascending, Domain: req.Domain, Search: req.Search, Tags: req.Tags, Text: req.Text}
+	if req.Cursor != "" {
+		if q.After, err = domain.ParseLinkCursor(req.Cursor); err != nil {
+			return nil, serviceError(ctx, err)
+		}
 	}
 
-	list, err := s.service.GetByUser(ctx, userID)
+	var page *domain.LinkPage
+	if owner.WorkspaceID != uuid.Nil {
+		page, err = s.service.GetByWorkspace(ctx, owner.WorkspaceID, owner.UserID, q)
+	} else {
+		page, err = s.service.GetByUser(ctx, owner.UserID, q)
+	}
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, serviceError(ctx, err)
 	}
 
 	res := &proto.GetUserUrlsResponse{
-		Urls: make([]string, 0, len(list)),
+		Items:      make([]*proto.UserUrl, 0, len(page.URLs)),
+		NextCursor: page.NextCursor,
 	}
 
-	for _, i := range list {
-		res.Urls = append(res.Urls, i.OriginalURL)
+	for _, i := range page.URLs {
+		res.Items = append(res.Items, &proto.UserUrl{
+			ShortUrl:    i.ShortURL,
+			OriginalUrl: i.OriginalURL,
+			CreatedAt:   i.CreatedAt.Unix(),
+			Title:       i.Title,
+			Note:        i.Note,
+			Tags:        i.Tags,
+		})
 	}
 
 	return res, nil
@@ -78,9 +146,9 @@ func (s *GrpcService) GetUserUrls(ctx context.Context, req *proto.GetUserUrlsReq
 
 // DeleteUrls удаление
 func (s *GrpcService) DeleteUrls(ctx context.Context, req *proto.DeleteUrlsRequest) (*proto.DeleteUrlsResponse, error) {
-	userID, err := uuid.Parse(req.UserId)
+	userID, err := userIDFromRequest(ctx, req.UserId)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, err
 	}
 
 	keys := make([]domain.HashKey, 0, len(req.Keys))
@@ -90,17 +158,49 @@ func (s *GrpcService) DeleteUrls(ctx context.Context, req *proto.DeleteUrlsReque
 
 	_, err = s.service.DeleteByUser(ctx, keys, userID)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, serviceError(ctx, err)
 	}
 
 	return &proto.DeleteUrlsResponse{}, nil
 }
 
+// SetLinkRedirect замена настроек редиректа ссылки
+func (s *GrpcService) SetLinkRedirect(ctx context.Context, req *proto.SetLinkRedirectRequest) (*proto.SetLinkRedirectResponse, error) {
+	userID, err := userIDFromRequest(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	settings := domain.RedirectSettings{Type: domain.RedirectType(req.RedirectType), PassQuery: req.PassQuery}
+	link, err := s.service.SetRedirect(ctx, req.Key, userID, settings)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &proto.SetLinkRedirectResponse{RedirectType: int32(link.Redirect.Type), PassQuery: link.Redirect.PassQuery}, nil
+}
+
+// SetLinkPreview замена данных страницы предпросмотра ссылки
+func (s *GrpcService) SetLinkPreview(ctx context.Context, req *proto.SetLinkPreviewRequest) (*proto.SetLinkPreviewResponse, error) {
+	userID, err := userIDFromRequest(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	preview := domain.LinkPreview{Title: req.Title, Description: req.Description, Interstitial: req.Interstitial}
+	link, err := s.service.SetPreview(ctx, req.Key, userID, preview)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &proto.SetLinkPreviewResponse{
+		Title:        link.Preview.Title,
+		Description:  link.Preview.Description,
+		Interstitial: link.Preview.Interstitial,
+	}, nil
+}
+
 // GetStats статистика
 func (s *GrpcService) GetStats(ctx context.Context, req *proto.StatsRequest) (*proto.StatsResponse, error) {
 	res, err := s.service.Stats(ctx)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, serviceError(ctx, err)
 	}
 
 	return &proto.StatsResponse{
@@ -109,6 +209,23 @@ func (s *GrpcService) GetStats(ctx context.Context, req *proto.StatsRequest) (*p
 	}, nil
 }
 
+// ownerFromRequest владелец ссылок: пользователь и необязательное рабочее пространство
+func ownerFromRequest(ctx context.Context, userID string, workspaceID string) (domain.Owner, error) {
+	var owner domain.Owner
+	var err error
+	owner.UserID, err = userIDFromRequest(ctx, userID)
+	if err != nil {
+		return owner, err
+	}
+	if workspaceID != "" {
+		owner.WorkspaceID, err = uuid.Parse(workspaceID)
+		if err != nil {
+			return owner, fieldError(ctx, "workspace_id", "invalid uuid")
+		}
+	}
+	return owner, nil
+}
+
 // Ping пинг
 func (s *GrpcService) Ping(ctx context.Context, req *proto.PingRequest) (*proto.PongResponse, error) {
 	return &proto.PongResponse{}, nil
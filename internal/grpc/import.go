@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/proto"
+	"google.golang.org/grpc"
+	"io"
+)
+
+// ImportUrls потоковый импорт ссылок, прерванный импорт продолжается повторной отправкой строк с job_id
+func (s *GrpcService) ImportUrls(stream grpc.BidiStreamingServer[proto.ImportUrlsRequest, proto.ImportUrlsResponse]) error {
+	ctx := stream.Context()
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return statusError(ctx, domain.CodeInvalidRequest, "no rows to import", nil)
+	}
+	if err != nil {
+		return err
+	}
+	owner, err := ownerFromRequest(ctx, first.UserId, first.WorkspaceId)
+	if err != nil {
+		return err
+	}
+	var jobID uuid.UUID
+	if first.JobId != "" {
+		if jobID, err = uuid.Parse(first.JobId); err != nil {
+			return fieldError(ctx, "job_id", "invalid uuid")
+		}
+	}
+
+	job, err := s.service.StartImport(ctx, owner, jobID)
+	if err != nil {
+		return serviceError(ctx, err)
+	}
+	if err = stream.Send(&proto.ImportUrlsResponse{Job: importJobResponse(job)}); err != nil {
+		return err
+	}
+
+	rows := first.Rows
+	next := func() (domain.ImportRow, error) {
+		for len(rows) == 0 {
+			req, err := stream.Recv()
+			if err != nil {
+				return domain.ImportRow{}, err
+			}
+			rows = req.Rows
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return domain.ImportRow{Key: row.Key, URL: row.Url, Expiry: row.Expiry, Tags: row.Tags}, nil
+	}
+	err = s.service.Import(ctx, job, next, func(results []domain.ImportResult) error {
+		res := &proto.ImportUrlsResponse{Results: make([]*proto.ImportRowResult, 0, len(results))}
+		for _, r := range results {
+			item := &proto.ImportRowResult{Row: r.Row, Key: r.Key, Status: string(r.Status), Error: r.Error}
+			if r.Status == domain.ImportCreated {
+				item.ShortUrl = adapters.CreatePublicURL(domain.LinkDomainFromCtx(ctx), r.Key)
+			}
+			res.Results = append(res.Results, item)
+		}
+		return stream.Send(res)
+	})
+	if err != nil {
+		return statusError(ctx, domain.CodeImportInterrupted, fmt.Sprintf("import interrupted, resume with job_id %s: %v", job.ID, err), nil)
+	}
+	return stream.Send(&proto.ImportUrlsResponse{Job: importJobResponse(job)})
+}
+
+func importJobResponse(job *domain.ImportJob) *proto.ImportJob {
+	return &proto.ImportJob{
+		Id:        job.ID.String(),
+		Status:    job.Status,
+		Processed: job.Processed,
+		Created:   job.Created,
+		Conflicts: job.Conflicts,
+		Invalid:   job.Invalid,
+	}
+}
@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"context"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// linkDomainHeader - метаданные с брендовым доменом коротких ссылок
+const linkDomainHeader = "x-link-domain"
+
+// LinkDomainInterceptor брендовый домен из метаданных x-link-domain добавляется в context,
+// без метаданных запрос работает с основным доменом, неизвестный домен - ошибка
+func LinkDomainInterceptor(domains *domain.DomainService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := linkDomainToCtx(ctx, domains)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamLinkDomainInterceptor брендовый домен для потоковых методов, как в LinkDomainInterceptor
+func StreamLinkDomainInterceptor(domains *domain.DomainService) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := linkDomainToCtx(ss.Context(), domains)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func linkDomainToCtx(ctx context.Context, domains *domain.DomainService) (context.Context, error) {
+	var host string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(linkDomainHeader); len(v) > 0 {
+			host = v[0]
+		}
+	}
+	host, err := domains.Lookup(host)
+	if err != nil {
+		return ctx, serviceError(ctx, err)
+	}
+	return domain.WithLinkDomain(ctx, host), nil
+}
@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"context"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/proto"
+)
+
+// SetLinkMeta замена названия, заметки и тегов ссылки
+func (s *GrpcService) SetLinkMeta(ctx context.Context, req *proto.SetLinkMetaRequest) (*proto.LinkMeta, error) {
+	userID, err := userIDFromRequest(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	meta := domain.LinkMeta{Title: req.Meta.GetTitle(), Note: req.Meta.GetNote(), Tags: req.Meta.GetTags()}
+	link, err := s.service.SetMeta(ctx, req.Key, userID, meta)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &proto.LinkMeta{Title: link.Title, Note: link.Note, Tags: link.Tags}, nil
+}
+
+// RenameTag переименование или объединение тега в ссылках пользователя или рабочего пространства
+func (s *GrpcService) RenameTag(ctx context.Context, req *proto.RenameTagRequest) (*proto.RenameTagResponse, error) {
+	owner, err := ownerFromRequest(ctx, req.UserId, req.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.service.RenameTag(ctx, owner, domain.TagRename{From: req.From, To: req.To})
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &proto.RenameTagResponse{Updated: updated}, nil
+}
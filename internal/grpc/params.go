@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/proto"
+)
+
+// SetLinkParams замена параметров запроса ссылки
+func (s *GrpcService) SetLinkParams(ctx context.Context, req *proto.SetLinkParamsRequest) (*proto.LinkParams, error) {
+	userID, err := userIDFromRequest(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	link, err := s.service.SetParams(ctx, req.Key, userID, linkParamsFromProto(req.Params))
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return linkParamsToProto(link.Params), nil
+}
+
+// GetDefaultParams параметры для новых ссылок пользователя
+func (s *GrpcService) GetDefaultParams(ctx context.Context, req *proto.GetDefaultParamsRequest) (*proto.LinkParams, error) {
+	userID, err := userIDFromRequest(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	params, err := s.service.DefaultParams(ctx, userID)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return linkParamsToProto(params), nil
+}
+
+// SetDefaultParams замена параметров для новых ссылок пользователя
+func (s *GrpcService) SetDefaultParams(ctx context.Context, req *proto.SetDefaultParamsRequest) (*proto.LinkParams, error) {
+	userID, err := userIDFromRequest(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	params, err := s.service.SetDefaultParams(ctx, userID, linkParamsFromProto(req.Params))
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return linkParamsToProto(params), nil
+}
+
+func linkParamsFromProto(params *proto.LinkParams) domain.LinkParams {
+	return domain.LinkParams{Query: params.GetQuery(), Merge: domain.MergePolicy(params.GetMerge())}
+}
+
+func linkParamsToProto(params domain.LinkParams) *proto.LinkParams {
+	return &proto.LinkParams{Query: params.Query, Merge: string(params.Merge)}
+}
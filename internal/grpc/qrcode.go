@@ -0,0 +1,61 @@
+package grpc
+
+import (
+	"context"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/proto"
+	"strings"
+)
+
+// qrOptionsFromRequest параметры qr кода из запроса, пустые поля - по умолчанию
+func qrOptionsFromRequest(req *proto.GetQRCodeRequest) (domain.QROptions, error) {
+	opts := domain.DefaultQROptions()
+	if req.Format != "" {
+		opts.Format = domain.QRFormat(strings.ToLower(req.Format))
+	}
+	if req.Size != 0 {
+		opts.Size = int(req.Size)
+	}
+	if req.Level != "" {
+		opts.Level = domain.QRLevel(strings.ToUpper(req.Level))
+	}
+	if req.Margin != nil {
+		opts.Margin = int(*req.Margin)
+	}
+	var err error
+	if req.Foreground != "" {
+		if opts.Foreground, err = domain.ParseQRColor("foreground", req.Foreground); err != nil {
+			return opts, err
+		}
+	}
+	if req.Background != "" {
+		if opts.Background, err = domain.ParseQRColor("background", req.Background); err != nil {
+			return opts, err
+		}
+	}
+	return opts, opts.Validate()
+}
+
+// GetQRCode qr код публичной короткой ссылки
+func (s *GrpcService) GetQRCode(ctx context.Context, req *proto.GetQRCodeRequest) (*proto.QRCode, error) {
+	opts, err := qrOptionsFromRequest(req)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	link, err := s.service.GetLink(ctx, req.Hash)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	content := adapters.CreatePublicURL(link.Domain, link.Key)
+	res := &proto.QRCode{ContentType: opts.Format.ContentType(), Etag: opts.ETag(content)}
+	if req.IfNoneMatch == res.Etag {
+		res.NotModified = true
+		return res, nil
+	}
+	if res.Image, err = adapters.RenderQRCode(content, opts); err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return res, nil
+}
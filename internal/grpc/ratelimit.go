@@ -0,0 +1,104 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/proto"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"math"
+	"strconv"
+	"time"
+)
+
+// RateLimitInterceptor ограничение частоты запросов по пользователю из токена, иначе по ip клиента.
+// Ip клиента и пользователь берутся из контекста, см. ClientIPInterceptor и AuthInterceptor.
+func RateLimitInterceptor(logger zap.SugaredLogger, limiter domain.RateLimiter, limits domain.RateLimits) grpc.UnaryServerInterceptor {
+	methodLimits := map[string]struct {
+		kind  string
+		limit domain.RateLimit
+	}{
+		proto.URLShortener_CreateShort_FullMethodName:   {"create", limits.Create},
+		proto.URLShortener_Shorten_FullMethodName:       {"create", limits.Create},
+		proto.URLShortener_ShortenBatch_FullMethodName:  {"batch", limits.Batch},
+		proto.URLShortener_GetOriginLink_FullMethodName: {"redirect", limits.Redirect},
+		proto.URLShortener_GetQRCode_FullMethodName:     {"redirect", limits.Redirect},
+		proto.URLShortener_DeleteUrls_FullMethodName:    {"delete", limits.Delete},
+	}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ml, ok := methodLimits[info.FullMethod]
+		if limiter == nil || !ok || !ml.limit.Enabled() {
+			return handler(ctx, req)
+		}
+
+		// пакет стоит столько запросов, сколько в нём ссылок
+		cost := 1
+		if batch, ok := req.(*proto.ShortenBatchRequest); ok && len(batch.Items) > 1 {
+			cost = len(batch.Items)
+		}
+		if !ml.limit.Fits(cost) {
+			return nil, statusError(ctx, domain.CodePayloadTooLarge, fmt.Sprintf("request costs %d, at most %d is allowed", cost, ml.limit.Burst), nil)
+		}
+		retryAfter, err := limiter.Allow(ctx, ml.kind+":"+rateLimitSubject(ctx), ml.limit, cost)
+		if err != nil {
+			// не блокируем запросы из-за недоступности хранилища лимитов
+			logger.Errorw("cannot check rate limit", "error", err)
+			return handler(ctx, req)
+		}
+		if retryAfter > 0 {
+			return nil, rateLimitError(ctx, retryAfter)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimitInterceptor ограничение частоты открытия потоков, как в RateLimitInterceptor.
+// Строки импорта дополнительно списываются из лимита пакетов по мере сохранения, см. domain.WithImportRateLimit.
+func StreamRateLimitInterceptor(logger zap.SugaredLogger, limiter domain.RateLimiter, limits domain.RateLimits) grpc.StreamServerInterceptor {
+	methodLimits := map[string]struct {
+		kind  string
+		limit domain.RateLimit
+	}{
+		proto.URLShortener_ImportUrls_FullMethodName: {"create", limits.Create},
+	}
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ml, ok := methodLimits[info.FullMethod]
+		if limiter == nil || !ok || !ml.limit.Enabled() {
+			return handler(srv, ss)
+		}
+		ctx := ss.Context()
+		retryAfter, err := limiter.Allow(ctx, ml.kind+":"+rateLimitSubject(ctx), ml.limit, 1)
+		if err != nil {
+			// не блокируем запросы из-за недоступности хранилища лимитов
+			logger.Errorw("cannot check rate limit", "error", err)
+			return handler(srv, ss)
+		}
+		if retryAfter > 0 {
+			return rateLimitError(ctx, retryAfter)
+		}
+		return handler(srv, ss)
+	}
+}
+
+func rateLimitSubject(ctx context.Context) string {
+	if identity, ok := adapters.IdentityFromCtx(ctx); ok {
+		return "user:" + identity.UserID.String()
+	}
+	return "ip:" + adapters.ClientIPFromCtx(ctx).String()
+}
+
+func rateLimitError(ctx context.Context, retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+
+	return statusError(ctx, domain.CodeRateLimited, "too many requests", nil, &errdetails.RetryInfo{
+		RetryDelay: durationpb.New(time.Duration(seconds) * time.Second),
+	})
+}
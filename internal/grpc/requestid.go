@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"context"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIDHeader - метаданные с идентификатором запроса
+const requestIDHeader = "x-request-id"
+
+// RequestIDInterceptor идентификатор запроса из метаданных x-request-id или новый, добавляется в context и заголовки ответа
+func RequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		requestID := requestIDFromMetadata(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+		return handler(adapters.RequestIDToCtx(ctx, requestID), req)
+	}
+}
+
+// StreamRequestIDInterceptor идентификатор запроса для потоковых методов, как в RequestIDInterceptor
+func StreamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		requestID := requestIDFromMetadata(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(requestIDHeader, requestID))
+		return handler(srv, &serverStream{ServerStream: ss, ctx: adapters.RequestIDToCtx(ss.Context(), requestID)})
+	}
+}
+
+func requestIDFromMetadata(ctx context.Context) string {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDHeader); len(v) > 0 {
+			requestID = v[0]
+		}
+	}
+	return adapters.RequestIDOrNew(requestID)
+}
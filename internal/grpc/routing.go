@@ -0,0 +1,66 @@
+package grpc
+
+import (
+	"context"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/proto"
+	"time"
+)
+
+// SetLinkRules замена правил маршрутизации ссылки
+func (s *GrpcService) SetLinkRules(ctx context.Context, req *proto.SetLinkRulesRequest) (*proto.SetLinkRulesResponse, error) {
+	userID, err := userIDFromRequest(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]domain.RouteRule, 0, len(req.Rules))
+	for _, rule := range req.Rules {
+		rules = append(rules, routeRuleFromProto(rule))
+	}
+	link, err := s.service.SetRules(ctx, req.Key, userID, rules)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	res := &proto.SetLinkRulesResponse{Rules: make([]*proto.RouteRule, 0, len(link.Rules))}
+	for _, rule := range link.Rules {
+		res.Rules = append(res.Rules, routeRuleToProto(rule))
+	}
+	return res, nil
+}
+
+// routeRuleFromProto правило маршрутизации из запроса, 0 в границах интервала - без границы
+func routeRuleFromProto(rule *proto.RouteRule) domain.RouteRule {
+	res := domain.RouteRule{
+		URL:       rule.Url,
+		Devices:   rule.Devices,
+		Languages: rule.Languages,
+		Countries: rule.Countries,
+		Query:     rule.Query,
+	}
+	if rule.From != 0 {
+		from := time.Unix(rule.From, 0).UTC()
+		res.From = &from
+	}
+	if rule.Until != 0 {
+		until := time.Unix(rule.Until, 0).UTC()
+		res.Until = &until
+	}
+	return res
+}
+
+func routeRuleToProto(rule domain.RouteRule) *proto.RouteRule {
+	res := &proto.RouteRule{
+		Url:       rule.URL,
+		Devices:   rule.Devices,
+		Languages: rule.Languages,
+		Countries: rule.Countries,
+		Query:     rule.Query,
+	}
+	if rule.From != nil {
+		res.From = rule.From.Unix()
+	}
+	if rule.Until != nil {
+		res.Until = rule.Until.Unix()
+	}
+	return res
+}
@@ -0,0 +1,17 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+)
+
+// serverStream - поток с context, дополненным перехватчиками
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context context потока
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
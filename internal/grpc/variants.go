@@ -0,0 +1,45 @@
+package grpc
+
+import (
+	"context"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/proto"
+)
+
+// SetLinkVariants замена вариантов A/B теста ссылки
+func (s *GrpcService) SetLinkVariants(ctx context.Context, req *proto.SetLinkVariantsRequest) (*proto.SetLinkVariantsResponse, error) {
+	userID, err := userIDFromRequest(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	variants := make([]domain.Variant, 0, len(req.Variants))
+	for _, v := range req.Variants {
+		variants = append(variants, domain.Variant{ID: v.Id, URL: v.Url, Weight: int(v.Weight)})
+	}
+	link, err := s.service.SetVariants(ctx, req.Key, userID, variants)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	res := &proto.SetLinkVariantsResponse{Variants: make([]*proto.Variant, 0, len(link.Variants))}
+	for _, v := range link.Variants {
+		res.Variants = append(res.Variants, &proto.Variant{Id: v.ID, Url: v.URL, Weight: int32(v.Weight)})
+	}
+	return res, nil
+}
+
+// GetLinkStats переходы по вариантам A/B теста ссылки
+func (s *GrpcService) GetLinkStats(ctx context.Context, req *proto.GetLinkStatsRequest) (*proto.LinkStats, error) {
+	userID, err := userIDFromRequest(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.service.LinkStats(ctx, req.Key, userID)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	res := &proto.LinkStats{Key: stats.Key, Clicks: stats.Clicks, Variants: make([]*proto.Variant, 0, len(stats.Variants))}
+	for _, v := range stats.Variants {
+		res.Variants = append(res.Variants, &proto.Variant{Id: v.ID, Url: v.URL, Weight: int32(v.Weight), Clicks: v.Clicks})
+	}
+	return res, nil
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// BanRequest - запрос на запрет создания ссылок
+type BanRequest struct {
+	Reason string `json:"reason"`
+}
+
+// DeleteByDomainResponse - удалённые ссылки на домен
+type DeleteByDomainResponse struct {
+	Deleted int              `json:"deleted"`
+	Keys    []domain.HashKey `json:"keys"`
+}
+
+// WithAdminService - модерация
+func WithAdminService(admin *domain.AdminService) ServeMuxOption {
+	return func(h *HTTPHandlers) {
+		h.admin = admin
+	}
+}
+
+// WithAuditService - журнал изменений ссылок для администраторов
+func WithAuditService(audit *domain.AuditService) ServeMuxOption {
+	return func(h *HTTPHandlers) {
+		h.audit = audit
+	}
+}
+
+// RequireAdmin доступ только администраторам
+func RequireAdmin(auth *adapters.Authenticator, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, _ := adapters.IdentityFromCtx(r.Context())
+		if !auth.IsAdmin(identity) {
+			writeProblem(w, domain.CodeAdminRequired, "admin role required")
+			return
+		}
+		h.ServeHTTP(w, r)
+	}
+}
+
+func (r *HTTPHandlers) adminGetLink(w http.ResponseWriter, request *http.Request) {
+	link, err := r.admin.GetLink(request.Context(), chi.URLParam(request, "key"))
+	r.writeAdminLink(w, link, err)
+}
+
+func (r *HTTPHandlers) adminDisableLink(w http.ResponseWriter, request *http.Request) {
+	link, err := r.admin.SetLinkDisabled(request.Context(), chi.URLParam(request, "key"), true)
+	r.writeAdminLink(w, link, err)
+}
+
+func (r *HTTPHandlers) adminEnableLink(w http.ResponseWriter, request *http.Request) {
+	link, err := r.admin.SetLinkDisabled(request.Context(), chi.URLParam(request, "key"), false)
+	r.writeAdminLink(w, link, err)
+}
+
+func (r *HTTPHandlers) writeAdminLink(w http.ResponseWriter, link *domain.Link, err error) {
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot moderate link", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, link)
+}
+
+func (r *HTTPHandlers) adminDeleteByDomain(w http.ResponseWriter, request *http.Request) {
+	keys, err := r.admin.DeleteByDomain(request.Context(), request.URL.Query().Get("domain"))
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot delete links by domain", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	if keys == nil {
+		keys = []domain.HashKey{}
+	}
+	r.writeJSON(w, http.StatusOK, DeleteByDomainResponse{Deleted: len(keys), Keys: keys})
+}
+
+func (r *HTTPHandlers) adminListUserLinks(w http.ResponseWriter, request *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		writeFieldError(w, "id", "invalid uuid")
+		return
+	}
+	links, err := r.admin.ListUserLinks(request.Context(), userID)
+	if err != nil {
+		r.logger.Error("cannot list user links", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, links)
+}
+
+func (r *HTTPHandlers) adminBanUser(w http.ResponseWriter, request *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		writeFieldError(w, "id", "invalid uuid")
+		return
+	}
+	var req BanRequest
+	if request.ContentLength != 0 {
+		if err = json.NewDecoder(request.Body).Decode(&req); err != nil {
+			writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+			return
+		}
+	}
+	if err = r.admin.BanUser(request.Context(), userID, req.Reason, adapters.MustUserIDFromReq(request)); err != nil {
+		r.logger.Error("cannot ban user", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *HTTPHandlers) adminUnbanUser(w http.ResponseWriter, request *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		writeFieldError(w, "id", "invalid uuid")
+		return
+	}
+	if err = r.admin.UnbanUser(request.Context(), userID); err != nil {
+		r.logger.Error("cannot unban user", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// auditFilterFromQuery фильтр журнала из параметров запроса
+func auditFilterFromQuery(q url.Values) (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{Action: q.Get("action"), Key: q.Get("key"), Domain: q.Get("domain")}
+	var err error
+	if v := q.Get("actor_id"); v != "" {
+		if filter.ActorID, err = uuid.Parse(v); err != nil {
+			return filter, &domain.ValidationError{Field: "actor_id", Message: "invalid uuid"}
+		}
+	}
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				return filter, &domain.ValidationError{Field: name, Message: "must be RFC 3339 time"}
+			}
+		}
+	}
+	if v := q.Get("cursor"); v != "" {
+		if filter.Cursor, err = strconv.ParseInt(v, 10, 64); err != nil || filter.Cursor <= 0 {
+			return filter, &domain.ValidationError{Field: "cursor", Message: "invalid cursor"}
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			return filter, &domain.ValidationError{Field: "limit", Message: "must be a positive integer"}
+		}
+	}
+	return filter, nil
+}
+
+func (r *HTTPHandlers) adminAuditLog(w http.ResponseWriter, request *http.Request) {
+	filter, err := auditFilterFromQuery(request.URL.Query())
+	if writeServiceError(w, err) {
+		return
+	}
+	page, err := r.audit.Query(request.Context(), filter)
+	if err != nil {
+		r.logger.Error("cannot query audit log", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, page)
+}
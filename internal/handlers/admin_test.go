@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmin(t *testing.T) {
+	urlRepo := adapters.NewMemURLRepository()
+	bans := adapters.NewMemBanRepository()
+	service := domain.NewShortenerService(urlRepo, adapters.GenBase64ShortURLToken, domain.WithBanRepository(bans))
+	apiKeys := domain.NewAPIKeyService(adapters.NewMemAPIKeyRepository())
+	adminID, userID := uuid.New(), uuid.New()
+	srv := newTestServer(t, service, newTestAuthenticator(apiKeys, adapters.WithAdmins(adminID)),
+		WithAPIKeyService(apiKeys), WithAdminService(domain.NewAdminService(urlRepo, bans)))
+
+	adminToken := srv.token(adminID)
+	userToken := srv.token(userID)
+
+	resp, body := srv.do(userToken, http.MethodPost, "/", "https://spam.example.com/offer")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	spamKey := path.Base(body)
+	resp, body = srv.do(userToken, http.MethodPost, "/", "https://github.com")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	githubKey := path.Base(body)
+
+	t.Run("only admins", func(t *testing.T) {
+		resp, _ := srv.do(userToken, http.MethodGet, "/api/admin/links/"+spamKey, "")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, _ = srv.do("", http.MethodGet, "/api/admin/links/"+spamKey, "")
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, _ = srv.do(userToken, http.MethodPost, "/api/user/api-keys", `{"name": "ci", "scopes": ["admin"]}`)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode, "admin scope only for admins")
+	})
+
+	t.Run("admin api key", func(t *testing.T) {
+		resp, body := srv.do(adminToken, http.MethodPost, "/api/user/api-keys", `{"name": "read", "scopes": ["read"]}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var readKey CreateAPIKeyResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &readKey))
+		resp, _ = srv.do(readKey.Key, http.MethodGet, "/api/admin/links/"+spamKey, "")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode, "api key without admin scope")
+
+		resp, body = srv.do(adminToken, http.MethodPost, "/api/user/api-keys", `{"name": "moderation", "scopes": ["admin"]}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var adminKey CreateAPIKeyResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &adminKey))
+		resp, body = srv.do(adminKey.Key, http.MethodGet, "/api/admin/links/"+spamKey, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var link domain.Link
+		require.NoError(t, json.Unmarshal([]byte(body), &link))
+		require.Equal(t, userID, link.UserID)
+		require.Equal(t, "https://spam.example.com/offer", link.OriginalURL)
+	})
+
+	t.Run("disable link", func(t *testing.T) {
+		resp, _ := srv.do(adminToken, http.MethodPost, "/api/admin/links/"+githubKey+"/disable", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = srv.do("", http.MethodGet, "/"+githubKey, "")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _ = srv.do(adminToken, http.MethodPost, "/api/admin/links/"+githubKey+"/enable", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = srv.do("", http.MethodGet, "/"+githubKey, "")
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+		resp, _ = srv.do(adminToken, http.MethodPost, "/api/admin/links/unknown/disable", "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("delete by domain", func(t *testing.T) {
+		resp, body := srv.do(adminToken, http.MethodDelete, "/api/admin/links?domain=Example.com", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var res DeleteByDomainResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &res))
+		require.Equal(t, []domain.HashKey{spamKey}, res.Keys)
+
+		resp, _ = srv.do("", http.MethodGet, "/"+spamKey, "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp, _ = srv.do(adminToken, http.MethodDelete, "/api/admin/links", "")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("user links", func(t *testing.T) {
+		resp, body := srv.do(adminToken, http.MethodGet, "/api/admin/users/"+userID.String()+"/links", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var links []domain.Link
+		require.NoError(t, json.Unmarshal([]byte(body), &links))
+		require.Len(t, links, 1)
+		require.Equal(t, githubKey, links[0].Key)
+	})
+
+	t.Run("ban", func(t *testing.T) {
+		resp, _ := srv.do(adminToken, http.MethodPut, "/api/admin/users/"+userID.String()+"/ban", `{"reason": "spam"}`)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		resp, _ = srv.do(userToken, http.MethodPost, "/api/shorten", `{"url": "https://spam.example.org"}`)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _ = srv.do(adminToken, http.MethodDelete, "/api/admin/users/"+userID.String()+"/ban", "")
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		resp, _ = srv.do(userToken, http.MethodPost, "/api/shorten", `{"url": "https://spam.example.org"}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+}
+
+func TestAuditLog(t *testing.T) {
+	urlRepo := adapters.NewMemURLRepository()
+	audit := domain.NewAuditService(adapters.NewMemAuditRepository(), adapters.AuditActorFromCtx)
+	service := domain.NewShortenerService(urlRepo, adapters.GenBase64ShortURLToken, domain.WithAuditService(audit))
+	adminID, userID := uuid.New(), uuid.New()
+	admin := domain.NewAdminService(urlRepo, adapters.NewMemBanRepository(), domain.WithAdminAuditService(audit))
+	srv := newTestServer(t, service, newTestAuthenticator(nil, adapters.WithAdmins(adminID)),
+		WithAdminService(admin), WithAuditService(audit))
+
+	adminToken := srv.token(adminID)
+	userToken := srv.token(userID)
+	query := func(params string) domain.AuditPage {
+		resp, body := srv.do(adminToken, http.MethodGet, "/api/admin/audit"+params, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		var page domain.AuditPage
+		require.NoError(t, json.Unmarshal([]byte(body), &page))
+		return page
+	}
+
+	resp, body := srv.do(userToken, http.MethodPost, "/", "https://example.com/one")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	key := path.Base(body)
+	resp, _ = srv.do(userToken, http.MethodPost, "/api/shorten/batch", `[{"correlation_id": "1", "original_url": "https://example.com/two"}]`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp, _ = srv.do(adminToken, http.MethodPost, "/api/admin/links/"+key+"/disable", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = srv.do(userToken, http.MethodDelete, "/api/user/urls", `["`+key+`"]`, "X-Request-Id", "req-DELETE")
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	t.Run("events", func(t *testing.T) {
+		page := query("")
+		require.Len(t, page.Events, 4)
+		require.Zero(t, page.NextCursor)
+
+		deleted := page.Events[0]
+		require.Equal(t, domain.AuditLinkDelete, deleted.Action)
+		require.Equal(t, key, deleted.Key)
+		require.Equal(t, userID, deleted.UserID)
+		require.Equal(t, string(adapters.AuthMethodJWT), deleted.AuthMethod)
+		require.Equal(t, "127.0.0.1", deleted.ClientIP)
+		require.Equal(t, "req-DELETE", deleted.RequestID)
+		require.False(t, deleted.Before.IsDeleted)
+		require.True(t, deleted.Before.IsDisabled)
+		require.True(t, deleted.After.IsDeleted)
+
+		disabled := page.Events[1]
+		require.Equal(t, domain.AuditLinkDisable, disabled.Action)
+		require.Equal(t, adminID, disabled.UserID)
+
+		require.Equal(t, domain.AuditLinkBatchCreate, page.Events[2].Action)
+		created := page.Events[3]
+		require.Equal(t, domain.AuditLinkCreate, created.Action)
+		require.Nil(t, created.Before)
+		require.Equal(t, "https://example.com/one", created.After.OriginalURL)
+	})
+
+	t.Run("filters and pagination", func(t *testing.T) {
+		page := query("?actor_id=" + userID.String() + "&limit=2")
+		require.Len(t, page.Events, 2)
+		require.NotZero(t, page.NextCursor)
+		next := query("?actor_id=" + userID.String() + "&limit=2&cursor=" + strconv.FormatInt(page.NextCursor, 10))
+		require.Len(t, next.Events, 1)
+		require.Equal(t, domain.AuditLinkCreate, next.Events[0].Action)
+		require.Zero(t, next.NextCursor)
+
+		require.Len(t, query("?key="+key).Events, 3)
+		require.Len(t, query("?action="+domain.AuditLinkDisable).Events, 1)
+		require.Empty(t, query("?since="+time.Now().Add(time.Hour).UTC().Format(time.RFC3339)).Events)
+
+		resp, _ := srv.do(adminToken, http.MethodGet, "/api/admin/audit?since=yesterday", "")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp, _ = srv.do(userToken, http.MethodGet, "/api/admin/audit", "")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("bans", func(t *testing.T) {
+		resp, _ := srv.do(adminToken, http.MethodPut, "/api/admin/users/"+userID.String()+"/ban", `{"reason": "spam"}`)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		resp, _ = srv.do(adminToken, http.MethodDelete, "/api/admin/users/"+userID.String()+"/ban", "")
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		page := query("?actor_id=" + adminID.String() + "&limit=2")
+		require.Len(t, page.Events, 2)
+		require.Equal(t, domain.AuditUserUnban, page.Events[0].Action)
+		banned := page.Events[1]
+		require.Equal(t, domain.AuditUserBan, banned.Action)
+		require.Equal(t, userID.String(), banned.Subject)
+		require.Equal(t, map[string]string{"reason": "spam"}, banned.Details)
+	})
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"net/http"
+	"slices"
+)
+
+// CreateAPIKeyRequest - запрос на создание api ключа
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateAPIKeyResponse - созданный api ключ, Key показывается только один раз
+type CreateAPIKeyResponse struct {
+	domain.APIKey
+	Key string `json:"key"`
+}
+
+// RenameAPIKeyRequest - запрос на переименование api ключа
+type RenameAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// withoutAPIKey управлять ключами можно только через jwt, но не через другой api ключ
+func withoutAPIKey(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if adapters.AuthMethodFromCtx(r.Context()) == adapters.AuthMethodAPIKey {
+			writeProblem(w, domain.CodeForbidden, "api keys cannot be managed with an api key")
+			return
+		}
+		h.ServeHTTP(w, r)
+	}
+}
+
+func (r *HTTPHandlers) createAPIKey(w http.ResponseWriter, request *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	identity, _ := adapters.IdentityFromCtx(request.Context())
+	if slices.Contains(req.Scopes, domain.ScopeAdmin) && !r.auth.IsAdmin(identity) {
+		writeProblem(w, domain.CodeAdminRequired, "admin scope requires an admin")
+		return
+	}
+	token, key, err := r.apiKeys.Create(request.Context(), adapters.MustUserIDFromReq(request), req.Name, req.Scopes)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot create api key", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(CreateAPIKeyResponse{APIKey: *key, Key: token}); err != nil {
+		r.logger.Debug("cannot encode response JSON", zap.Error(err))
+	}
+}
+
+func (r *HTTPHandlers) listAPIKeys(w http.ResponseWriter, request *http.Request) {
+	keys, err := r.apiKeys.List(request.Context(), adapters.MustUserIDFromReq(request))
+	if err != nil {
+		r.logger.Error("cannot list api keys", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(keys); err != nil {
+		r.logger.Debug("cannot encode response JSON", zap.Error(err))
+	}
+}
+
+func (r *HTTPHandlers) renameAPIKey(w http.ResponseWriter, request *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		writeFieldError(w, "id", "invalid uuid")
+		return
+	}
+	var req RenameAPIKeyRequest
+	if err = json.NewDecoder(request.Body).Decode(&req); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	key, err := r.apiKeys.Rename(request.Context(), id, adapters.MustUserIDFromReq(request), req.Name)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot rename api key", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(key); err != nil {
+		r.logger.Debug("cannot encode response JSON", zap.Error(err))
+	}
+}
+
+func (r *HTTPHandlers) revokeAPIKey(w http.ResponseWriter, request *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		writeFieldError(w, "id", "invalid uuid")
+		return
+	}
+
+	err = r.apiKeys.Revoke(request.Context(), id, adapters.MustUserIDFromReq(request))
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot revoke api key", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	logger := adapters.CreateLogger()
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	testServer := httptest.NewServer(CreateServeMux(service, logger, nil))
+	defer testServer.Close()
+
+	do := func(method, path, body string, header http.Header) *http.Response {
+		req := utils.Must(http.NewRequest(method, testServer.URL+path, strings.NewReader(body)))
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := do(http.MethodPost, "/", "https://github.com", nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	jwtHeader := http.Header{"Authorization": {resp.Header.Get("Authorization")}}
+
+	resp = do(http.MethodPost, "/api/user/api-keys", `{"name": "backend", "scopes": ["create"]}`, jwtHeader)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created CreateAPIKeyResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.True(t, strings.HasPrefix(created.Key, "sk_"+created.Prefix+"_"))
+	require.Equal(t, []string{domain.ScopeCreate}, created.Scopes)
+	keyHeader := http.Header{"X-Api-Key": {created.Key}}
+
+	t.Run("api key creates links without cookies", func(t *testing.T) {
+		resp := do(http.MethodPost, "/api/shorten", `{"url": "https://yandex.ru"}`, keyHeader)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Empty(t, resp.Header.Get("Set-Cookie"))
+		require.Empty(t, resp.Header.Get("Authorization"))
+
+		resp = do(http.MethodPost, "/", "https://rambler.ru", http.Header{"Authorization": {"Bearer " + created.Key}})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("scopes are enforced", func(t *testing.T) {
+		resp := do(http.MethodGet, "/api/user/urls", "", keyHeader)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = do(http.MethodGet, "/api/user/api-keys", "", keyHeader)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("list and rename", func(t *testing.T) {
+		resp := do(http.MethodPatch, "/api/user/api-keys/"+created.ID.String(), `{"name": "renamed"}`, jwtHeader)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = do(http.MethodGet, "/api/user/api-keys", "", jwtHeader)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var keys []domain.APIKey
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&keys))
+		require.Len(t, keys, 1)
+		require.Equal(t, "renamed", keys[0].Name)
+		require.Equal(t, created.Prefix, keys[0].Prefix)
+	})
+
+	t.Run("revoked key is rejected", func(t *testing.T) {
+		resp := do(http.MethodDelete, "/api/user/api-keys/"+created.ID.String(), "", jwtHeader)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp = do(http.MethodPost, "/api/shorten", `{"url": "https://google.com"}`, keyHeader)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+)
+
+// RefreshRequest - запрос на обновление токенов, без тела берётся refresh токен из cookie
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse - выданные токены
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// refreshTokenFromReq refresh токен из тела запроса или cookie, false - некорректное тело
+func refreshTokenFromReq(request *http.Request) (string, bool) {
+	var req RefreshRequest
+	if request.ContentLength != 0 {
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			return "", false
+		}
+	}
+	if req.RefreshToken == "" {
+		if cookie, err := request.Cookie(refreshTokenCookie); err == nil {
+			req.RefreshToken = cookie.Value
+		}
+	}
+	return req.RefreshToken, true
+}
+
+func (r *HTTPHandlers) refreshTokens(w http.ResponseWriter, request *http.Request) {
+	refreshToken, ok := refreshTokenFromReq(request)
+	if !ok {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	userID, err := r.auth.Refresh(request.Context(), refreshToken)
+	if err != nil {
+		writeProblem(w, domain.CodeUnauthorized, "invalid refresh token")
+		return
+	}
+	r.issueTokens(w, userID)
+}
+
+// issueTokens выдача новой пары токенов в cookie и теле ответа
+func (r *HTTPHandlers) issueTokens(w http.ResponseWriter, userID uuid.UUID) {
+	tokens, err := r.setTokens(w, userID)
+	if err != nil {
+		r.logger.Error("cannot build tokens", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, tokens)
+}
+
+// setTokens выпуск пары токенов и установка cookie
+func (r *HTTPHandlers) setTokens(w http.ResponseWriter, userID uuid.UUID) (TokenResponse, error) {
+	accessToken, refreshToken, err := buildTokens(r.auth, userID)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	hostname := utils.Must(url.Parse(internal.Config.BaseURL)).Hostname()
+	setAccessToken(w, hostname, accessToken)
+	setRefreshCookie(w, hostname, refreshToken, r.auth.RefreshTTL())
+
+	return TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(adapters.JwtTTL.Seconds()),
+	}, nil
+}
+
+func (r *HTTPHandlers) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		r.logger.Debug("cannot encode response JSON", zap.Error(err))
+	}
+}
+
+// CredentialsRequest - запрос регистрации и входа
+type CredentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	// ClaimLinks - перенести ссылки текущего анонимного пользователя в аккаунт
+	ClaimLinks bool `json:"claim_links"`
+}
+
+// AuthResponse - ответ регистрации и входа
+type AuthResponse struct {
+	TokenResponse
+	User         domain.User `json:"user"`
+	ClaimedLinks int64       `json:"claimed_links"`
+}
+
+// ClaimRequest - перенос ссылок анонимного пользователя по его access или refresh токену
+type ClaimRequest struct {
+	Token string `json:"token"`
+}
+
+// ClaimResponse - количество перенесённых ссылок
+type ClaimResponse struct {
+	ClaimedLinks int64 `json:"claimed_links"`
+}
+
+func (r *HTTPHandlers) signup(w http.ResponseWriter, request *http.Request) {
+	var req CredentialsRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	user, err := r.users.Signup(request.Context(), req.Email, req.Password)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot signup", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.authenticated(w, request, http.StatusCreated, user, req.ClaimLinks)
+}
+
+func (r *HTTPHandlers) login(w http.ResponseWriter, request *http.Request) {
+	var req CredentialsRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	user, err := r.users.Login(request.Context(), req.Email, req.Password)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot login", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.authenticated(w, request, http.StatusOK, user, req.ClaimLinks)
+}
+
+// claimAnonymous перенос ссылок текущей анонимной сессии вошедшему пользователю
+func (r *HTTPHandlers) claimAnonymous(request *http.Request, userID uuid.UUID, claim bool) (int64, error) {
+	identity, ok := adapters.IdentityFromCtx(request.Context())
+	if !claim || !ok || identity.Method != adapters.AuthMethodJWT {
+		return 0, nil
+	}
+	claimed, err := r.users.ClaimAnonymousLinks(request.Context(), identity.UserID, userID)
+	if errors.Is(err, domain.ErrNotAnonymous) {
+		return 0, nil
+	}
+	return claimed, err
+}
+
+// authenticated выдача токенов зарегистрированному пользователю, при claim переносит ссылки анонимной сессии
+func (r *HTTPHandlers) authenticated(w http.ResponseWriter, request *http.Request, status int, user *domain.User, claim bool) {
+	claimed, err := r.claimAnonymous(request, user.ID, claim)
+	if err != nil {
+		r.logger.Error("cannot claim links", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+
+	tokens, err := r.setTokens(w, user.ID)
+	if err != nil {
+		r.logger.Error("cannot build tokens", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, status, AuthResponse{TokenResponse: tokens, User: *user, ClaimedLinks: claimed})
+}
+
+// logout отзыв refresh токена из тела или cookie, чтобы его копия не выдавала новые токены после выхода, и удаление cookie
+func (r *HTTPHandlers) logout(w http.ResponseWriter, request *http.Request) {
+	refreshToken, ok := refreshTokenFromReq(request)
+	if !ok {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	if err := r.auth.Revoke(request.Context(), refreshToken); err != nil {
+		r.logger.Error("cannot revoke refresh token", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+
+	hostname := utils.Must(url.Parse(internal.Config.BaseURL)).Hostname()
+	for _, name := range []string{accessTokenCookie, refreshTokenCookie} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Path:     "/",
+			Domain:   hostname,
+			MaxAge:   -1,
+			Secure:   true,
+			HttpOnly: true,
+		})
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *HTTPHandlers) claimLinks(w http.ResponseWriter, request *http.Request) {
+	var req ClaimRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	anonymousID, err := r.auth.Refresh(request.Context(), req.Token)
+	if err != nil {
+		identity, authErr := r.auth.Authenticate(request.Context(), req.Token)
+		if authErr != nil || identity.Method != adapters.AuthMethodJWT {
+			writeFieldError(w, "token", "invalid token")
+			return
+		}
+		anonymousID = identity.UserID
+	}
+
+	userID, _ := adapters.UserIDFromCtx(request.Context())
+	claimed, err := r.users.ClaimAnonymousLinks(request.Context(), anonymousID, userID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		writeProblem(w, domain.CodeForbidden, "registered account required")
+		return
+	}
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot claim links", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, ClaimResponse{ClaimedLinks: claimed})
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshToken(t *testing.T) {
+	logger := adapters.CreateLogger()
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	testServer := httptest.NewServer(CreateServeMux(service, logger, nil))
+	defer testServer.Close()
+
+	resp, err := http.Post(testServer.URL, "text/plain", strings.NewReader("https://github.com"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var refreshCookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == refreshTokenCookie {
+			refreshCookie = c
+		}
+	}
+	require.NotNil(t, refreshCookie)
+
+	t.Run("refresh cookie restores the same user", func(t *testing.T) {
+		req := utils.Must(http.NewRequest(http.MethodGet, testServer.URL+"/api/user/urls", nil))
+		req.AddCookie(&http.Cookie{Name: refreshTokenCookie, Value: refreshCookie.Value})
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NotEmpty(t, resp.Header.Get("Authorization"))
+
+		var list []domain.URLEntry
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+		require.Len(t, list, 1)
+	})
+
+	t.Run("refresh endpoint", func(t *testing.T) {
+		resp, err := http.Post(testServer.URL+"/api/auth/refresh", "application/json",
+			strings.NewReader(`{"refresh_token": "`+refreshCookie.Value+`"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var tokens TokenResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&tokens))
+
+		req := utils.Must(http.NewRequest(http.MethodGet, testServer.URL+"/api/user/urls", nil))
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = http.Post(testServer.URL+"/api/auth/refresh", "application/json",
+			strings.NewReader(`{"refresh_token": "`+tokens.AccessToken+`"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode, "access token cannot be used as refresh token")
+	})
+}
+
+func TestSignupLogin(t *testing.T) {
+	logger := adapters.CreateLogger()
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	testServer := httptest.NewServer(CreateServeMux(service, logger, nil))
+	defer testServer.Close()
+
+	// анонимный пользователь создаёт ссылку
+	resp, err := http.Post(testServer.URL, "text/plain", strings.NewReader("https://github.com"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	anonymousToken := strings.TrimPrefix(resp.Header.Get("Authorization"), "Bearer ")
+	require.NotEmpty(t, anonymousToken)
+
+	post := func(path string, token string, body string) *http.Response {
+		req := utils.Must(http.NewRequest(http.MethodPost, testServer.URL+path, strings.NewReader(body)))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("signup validation", func(t *testing.T) {
+		resp := post("/api/auth/signup", "", `{"email": "not an email", "password": "password123"}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = post("/api/auth/signup", "", `{"email": "user@example.com", "password": "short"}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = post("/api/auth/signup", "", `{"email": "user@example.com", "password": "`+strings.Repeat("п", 37)+`"}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "bcrypt accepts at most 72 bytes")
+	})
+
+	var auth AuthResponse
+	t.Run("signup claims anonymous links", func(t *testing.T) {
+		resp := post("/api/auth/signup", anonymousToken, `{"email": "User@Example.com", "password": "password123", "claim_links": true}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&auth))
+		require.Equal(t, "user@example.com", auth.User.Email)
+		require.EqualValues(t, 1, auth.ClaimedLinks)
+
+		req := utils.Must(http.NewRequest(http.MethodGet, testServer.URL+"/api/user/urls", nil))
+		req.Header.Set("Authorization", "Bearer "+auth.AccessToken)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var list []domain.URLEntry
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+		require.Len(t, list, 1)
+	})
+
+	t.Run("signup duplicate email", func(t *testing.T) {
+		resp := post("/api/auth/signup", "", `{"email": "user@example.com", "password": "password123"}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("login", func(t *testing.T) {
+		resp := post("/api/auth/login", "", `{"email": "user@example.com", "password": "wrong password"}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = post("/api/auth/login", "", `{"email": "user@example.com", "password": "password123"}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var login AuthResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&login))
+		require.Equal(t, auth.User.ID, login.User.ID)
+	})
+
+	t.Run("claim endpoint", func(t *testing.T) {
+		resp := post("/api/shorten", "", `{"url": "https://example.org"}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		token := strings.TrimPrefix(resp.Header.Get("Authorization"), "Bearer ")
+
+		resp = post("/api/user/claim", token, `{"token": "`+auth.AccessToken+`"}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusForbidden, resp.StatusCode, "anonymous user cannot claim links")
+
+		resp = post("/api/user/claim", auth.AccessToken, `{"token": "`+token+`"}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var claim ClaimResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&claim))
+		require.EqualValues(t, 1, claim.ClaimedLinks)
+	})
+
+	t.Run("logout clears cookies", func(t *testing.T) {
+		resp := post("/api/auth/logout", "", "")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		cleared := map[string]bool{}
+		for _, c := range resp.Cookies() {
+			cleared[c.Name] = c.MaxAge < 0
+		}
+		require.True(t, cleared[accessTokenCookie])
+		require.True(t, cleared[refreshTokenCookie])
+	})
+
+	t.Run("logout revokes the refresh token", func(t *testing.T) {
+		body := `{"refresh_token": "` + auth.RefreshToken + `"}`
+		resp := post("/api/auth/refresh", "", body)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = post("/api/auth/logout", "", body)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp = post("/api/auth/refresh", "", body)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode, "a copy of the token is not accepted after logout")
+
+		resp = post("/api/auth/logout", "", `{"refresh_token": "invalid"}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// WithDomainService - брендовые домены коротких ссылок
+func WithDomainService(domains *domain.DomainService) ServeMuxOption {
+	return func(h *HTTPHandlers) {
+		h.domains = domains
+	}
+}
+
+// LinkDomainMiddleware домен коротких ссылок по заголовку Host добавляется в context,
+// запросы на неизвестные хосты работают с основным доменом
+func LinkDomainMiddleware(domains *domain.DomainService) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(domain.WithLinkDomain(r.Context(), domains.Resolve(r.Host))))
+		})
+	}
+}
+
+// listMyDomains брендовые домены, на которых пользователь может создавать ссылки
+func (r *HTTPHandlers) listMyDomains(w http.ResponseWriter, request *http.Request) {
+	hosts, err := r.domains.ListByUser(request.Context(), adapters.MustUserIDFromReq(request))
+	if err != nil {
+		r.logger.Error("cannot list domains", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	if hosts == nil {
+		hosts = []string{}
+	}
+	r.writeJSON(w, http.StatusOK, hosts)
+}
+
+func (r *HTTPHandlers) adminGrantDomain(w http.ResponseWriter, request *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		writeFieldError(w, "id", "invalid uuid")
+		return
+	}
+	grant, err := r.domains.Grant(request.Context(), chi.URLParam(request, "domain"), userID, adapters.MustUserIDFromReq(request))
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot grant domain", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, grant)
+}
+
+func (r *HTTPHandlers) adminRevokeDomain(w http.ResponseWriter, request *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		writeFieldError(w, "id", "invalid uuid")
+		return
+	}
+	err = r.domains.Revoke(request.Context(), chi.URLParam(request, "domain"), userID)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot revoke domain", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
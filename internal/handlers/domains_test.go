@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkDomains(t *testing.T) {
+	urlRepo := adapters.NewMemURLRepository()
+	domains := domain.NewDomainService(adapters.NewMemDomainRepository(), "Go.Brand.com")
+	service := domain.NewShortenerService(urlRepo, adapters.GenBase64ShortURLToken, domain.WithDomainService(domains))
+	apiKeys := domain.NewAPIKeyService(adapters.NewMemAPIKeyRepository())
+	keyring := adapters.NewKeyring(adapters.NewHMACKey(adapters.DefaultKeyID, []byte(internal.Config.JwtSecret)))
+	adminID, userID := uuid.New(), uuid.New()
+	auth := adapters.NewAuthenticator(keyring, apiKeys, 0, adapters.WithAdmins(adminID))
+
+	testServer := httptest.NewServer(CreateServeMux(service, adapters.CreateLogger(), nil,
+		WithAuthenticator(auth), WithAPIKeyService(apiKeys), WithDomainService(domains)))
+	defer testServer.Close()
+
+	adminToken := utils.Must(auth.BuildJWTString(adminID))
+	userToken := utils.Must(auth.BuildJWTString(userID))
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	do := func(token string, host string, method string, target string, body string) (*http.Response, string) {
+		req := utils.Must(http.NewRequest(method, testServer.URL+target, strings.NewReader(body)))
+		if host != "" {
+			req.Host = host
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+
+	resp, body := do(userToken, "go.brand.com", http.MethodPost, "/", "https://example.com/brand")
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "domain is not granted yet")
+	require.Contains(t, body, string(domain.CodeDomainNotAllowed))
+
+	resp, _ = do(userToken, "", http.MethodPut, "/api/admin/domains/go.brand.com/users/"+userID.String(), "")
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "only admins grant domains")
+	resp, _ = do(adminToken, "", http.MethodPut, "/api/admin/domains/other.com/users/"+userID.String(), "")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "unknown domain")
+	resp, _ = do(adminToken, "", http.MethodPut, "/api/admin/domains/go.brand.com/users/"+userID.String(), "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body = do(userToken, "", http.MethodGet, "/api/user/domains", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `["go.brand.com"]`, body)
+
+	resp, brandURL := do(userToken, "GO.brand.com:443", http.MethodPost, "/", "https://example.com/brand")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.True(t, strings.HasPrefix(brandURL, "https://go.brand.com/"), brandURL)
+	resp, mainURL := do(userToken, "", http.MethodPost, "/", "https://example.com/brand")
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "the same url on another domain is a new link")
+	brandKey, mainKey := path.Base(brandURL), path.Base(mainURL)
+
+	t.Run("redirect by host", func(t *testing.T) {
+		resp, _ := do(userToken, "go.brand.com", http.MethodGet, "/"+brandKey, "")
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		require.Equal(t, "https://example.com/brand", resp.Header.Get("Location"))
+		resp, _ = do(userToken, "", http.MethodGet, "/"+brandKey, "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode, "key of a branded link is free on the main domain")
+		resp, _ = do(userToken, "go.brand.com", http.MethodGet, "/"+mainKey, "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp, _ = do(userToken, "unknown.example.com", http.MethodGet, "/"+mainKey, "")
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode, "unknown hosts serve the main domain")
+	})
+
+	t.Run("user links of all domains", func(t *testing.T) {
+		resp, body := do(userToken, "", http.MethodGet, "/api/user/urls", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var entries []domain.URLEntry
+		require.NoError(t, json.Unmarshal([]byte(body), &entries))
+		shortURLs := make([]string, 0, len(entries))
+		for _, e := range entries {
+			shortURLs = append(shortURLs, e.ShortURL)
+		}
+		require.ElementsMatch(t, []string{brandURL, mainURL}, shortURLs)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		resp, _ := do(adminToken, "", http.MethodDelete, "/api/admin/domains/go.brand.com/users/"+userID.String(), "")
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		resp, _ = do(userToken, "go.brand.com", http.MethodPost, "/api/shorten", `{"url": "https://example.com/after"}`)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, _ = do(userToken, "go.brand.com", http.MethodGet, "/"+brandKey, "")
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode, "existing links keep working")
+	})
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// exportCSVHeader - колонки csv выгрузки
+var exportCSVHeader = []string{"key", "short_url", "original_url", "created_at", "expires_at", "is_deleted", "clicks"}
+
+// exportWriter - запись выгрузки в одном из форматов
+type exportWriter interface {
+	Write(e domain.ExportEntry) error
+	Close() error
+}
+
+// exportUrls выгрузка всех ссылок пользователя или рабочего пространства в csv, jsonl или json без загрузки списка в память
+func (r *HTTPHandlers) exportUrls(w http.ResponseWriter, request *http.Request) {
+	format := request.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	contentType, ok := map[string]string{
+		"csv":   "text/csv",
+		"jsonl": "application/x-ndjson",
+		"json":  "application/json",
+	}[format]
+	if !ok {
+		writeFieldError(w, "format", "must be csv, jsonl or json")
+		return
+	}
+	owner, err := ownerFromReq(request)
+	if writeServiceError(w, err) {
+		return
+	}
+
+	var out exportWriter
+	// заголовки отправляются с первой ссылкой, чтобы ошибки доступа получили свой статус
+	start := func() {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="links.`+format+`"`)
+		w.WriteHeader(http.StatusOK)
+		out = newExportWriter(w, format)
+	}
+	err = r.service.Export(request.Context(), owner, func(e domain.ExportEntry) error {
+		if out == nil {
+			start()
+		}
+		return out.Write(e)
+	})
+	if out == nil {
+		if writeServiceError(w, err) {
+			return
+		}
+		if err != nil {
+			r.logger.Errorw("cannot export urls", "error", err)
+			writeInternalError(w)
+			return
+		}
+		start()
+	}
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		r.logger.Errorw("export interrupted", "error", err)
+		// обрыв соединения, чтобы клиент не принял неполную выгрузку за целую
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func newExportWriter(w io.Writer, format string) exportWriter {
+	switch format {
+	case "csv":
+		out := &csvExportWriter{w: csv.NewWriter(w)}
+		out.err = out.w.Write(exportCSVHeader)
+		return out
+	case "jsonl":
+		return &jsonExportWriter{w: w, encoder: json.NewEncoder(w)}
+	}
+	return &jsonExportWriter{w: w, encoder: json.NewEncoder(w), array: true}
+}
+
+// csvExportWriter - выгрузка в csv с заголовком exportCSVHeader
+type csvExportWriter struct {
+	w   *csv.Writer
+	err error
+}
+
+// Write запись ссылки
+func (c *csvExportWriter) Write(e domain.ExportEntry) error {
+	if c.err != nil {
+		return c.err
+	}
+	var expiresAt, clicks string
+	if e.ExpiresAt != nil {
+		expiresAt = e.ExpiresAt.Format(time.RFC3339)
+	}
+	if e.Clicks != nil {
+		clicks = strconv.FormatInt(*e.Clicks, 10)
+	}
+	return c.w.Write([]string{
+		e.Key, e.ShortURL, e.OriginalURL, e.CreatedAt.Format(time.RFC3339), expiresAt, strconv.FormatBool(e.IsDeleted), clicks,
+	})
+}
+
+// Close запись буфера
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return errors.Join(c.err, c.w.Error())
+}
+
+// jsonExportWriter - выгрузка в jsonl или в json массив, который пишется по одному элементу
+type jsonExportWriter struct {
+	w       io.Writer
+	encoder *json.Encoder
+	array   bool
+	n       int
+}
+
+// Write запись ссылки
+func (j *jsonExportWriter) Write(e domain.ExportEntry) error {
+	if j.array {
+		sep := ","
+		if j.n == 0 {
+			sep = "["
+		}
+		if _, err := io.WriteString(j.w, sep); err != nil {
+			return err
+		}
+	}
+	j.n++
+	return j.encoder.Encode(e)
+}
+
+// Close закрытие массива
+func (j *jsonExportWriter) Close() error {
+	if !j.array {
+		return nil
+	}
+	end := "]\n"
+	if j.n == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
@@ -7,25 +7,72 @@ import (
 	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sashaaro/url-shortener/internal"
 	"github.com/sashaaro/url-shortener/internal/adapters"
 	"github.com/sashaaro/url-shortener/internal/domain"
 	"github.com/sashaaro/url-shortener/internal/utils"
 	"go.uber.org/zap"
+	"html/template"
 	"io"
-	"net"
 	"net/http"
 	"net/url"
-	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // HTTPHandlers основные хендлеры
 type HTTPHandlers struct {
-	service *domain.ShortenerService
-	logger  zap.SugaredLogger
-	pool    *pgxpool.Pool
+	service     *domain.ShortenerService
+	logger      zap.SugaredLogger
+	pool        *pgxpool.Pool
+	rateLimiter domain.RateLimiter
+	rateLimits  domain.RateLimits
+	apiKeys     *domain.APIKeyService
+	auth        *adapters.Authenticator
+	users       *domain.UserService
+	oidc        *adapters.OIDCProvider
+	workspaces  *domain.WorkspaceService
+	admin       *domain.AdminService
+	audit       *domain.AuditService
+	domains     *domain.DomainService
+	// redirectMaxAge - срок кеширования постоянных редиректов
+	redirectMaxAge time.Duration
+	templates      *template.Template
+}
+
+// ServeMuxOption - опция хендлеров
+type ServeMuxOption func(h *HTTPHandlers)
+
+// WithRateLimiter - ограничение частоты запросов
+func WithRateLimiter(limiter domain.RateLimiter, limits domain.RateLimits) ServeMuxOption {
+	return func(h *HTTPHandlers) {
+		h.rateLimiter = limiter
+		h.rateLimits = limits
+	}
+}
+
+// WithAPIKeyService - управление api ключами
+func WithAPIKeyService(apiKeys *domain.APIKeyService) ServeMuxOption {
+	return func(h *HTTPHandlers) {
+		h.apiKeys = apiKeys
+	}
+}
+
+// WithAuthenticator - проверка токенов, по умолчанию HS256 с JWT_SECRET
+func WithAuthenticator(auth *adapters.Authenticator) ServeMuxOption {
+	return func(h *HTTPHandlers) {
+		h.auth = auth
+	}
+}
+
+// WithUserService - регистрация и вход по email и паролю
+func WithUserService(users *domain.UserService) ServeMuxOption {
+	return func(h *HTTPHandlers) {
+		h.users = users
+	}
 }
 
 // NewHTTPHandlers конструктор
@@ -35,58 +82,48 @@ func NewHTTPHandlers(
 	pool *pgxpool.Pool,
 ) *HTTPHandlers {
 	return &HTTPHandlers{
-		service: service,
-		logger:  logger,
-		pool:    pool,
+		service:        service,
+		logger:         logger,
+		pool:           pool,
+		redirectMaxAge: DefaultRedirectCacheMaxAge,
 	}
 }
 
 func (r *HTTPHandlers) createShortHandler(writer http.ResponseWriter, request *http.Request) {
 	b, err := io.ReadAll(request.Body)
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		writeProblem(writer, domain.CodeInvalidRequest, "cannot read request body")
 		return
 	}
 
 	originURL, err := url.Parse(string(b))
-	if err != nil || !strings.HasPrefix(originURL.Scheme, "http") {
-		http.Error(writer, "invalid url", http.StatusBadRequest)
+	if err != nil {
+		writeProblem(writer, domain.CodeInvalidURL, "invalid url")
 		return
 	}
-	key, err := r.service.CreateShort(request.Context(), *originURL, adapters.MustUserIDFromReq(request))
+	var key domain.HashKey
+	owner, err := ownerFromReq(request)
+	if err == nil {
+		key, err = r.service.CreateShort(request.Context(), *originURL, owner)
+	}
+	// конфликт отдаёт существующую ссылку, как и успешное создание
 	var dupErr *domain.ErrURLAlreadyExists
 	if errors.As(err, &dupErr) {
 		writer.WriteHeader(http.StatusConflict)
-		_, _ = writer.Write([]byte(adapters.CreatePublicURL(dupErr.HashKey)))
+		_, _ = writer.Write([]byte(adapters.CreatePublicURL(domain.LinkDomainFromCtx(request.Context()), dupErr.HashKey)))
+		return
+	}
+	if writeServiceError(writer, err) {
 		return
 	}
 	if err != nil {
 		r.logger.Debug("cannot batch add urls", zap.Error(err))
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeInternalError(writer)
 		return
 	}
 
 	writer.WriteHeader(http.StatusCreated)
-	_, _ = writer.Write([]byte(adapters.CreatePublicURL(key)))
-}
-
-func (r *HTTPHandlers) getOriginLinkHandler(writer http.ResponseWriter, request *http.Request) {
-	hashkey := chi.URLParam(request, "hash")
-	originURL, err := r.service.GetOriginLink(request.Context(), hashkey)
-	if errors.Is(err, domain.ErrURLDeleted) {
-		writer.WriteHeader(http.StatusGone)
-		return
-	}
-	if err != nil {
-		r.logger.Debug("cannot get url by hash", zap.Error(err))
-		writer.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	if originURL == nil {
-		http.Error(writer, "short url not found", http.StatusNotFound)
-		return
-	}
-	http.Redirect(writer, request, originURL.String(), http.StatusTemporaryRedirect)
+	_, _ = writer.Write([]byte(adapters.CreatePublicURL(domain.LinkDomainFromCtx(request.Context()), key)))
 }
 
 // ShortenRequest - запрос на укорочение ссылоки
@@ -104,37 +141,45 @@ func (r *HTTPHandlers) shorten(w http.ResponseWriter, request *http.Request) {
 	err := json.NewDecoder(request.Body).Decode(&req)
 	if err != nil {
 		r.logger.Debug("cannot decode request JSON body", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	originURL, err := url.Parse(req.URL)
 	if err != nil {
-		http.Error(w, "Invalid url", http.StatusBadRequest)
+		writeProblem(w, domain.CodeInvalidURL, "invalid url")
 		return
 	}
 
-	key, err := r.service.CreateShort(request.Context(), *originURL, adapters.MustUserIDFromReq(request))
+	var key domain.HashKey
+	owner, err := ownerFromReq(request)
+	if err == nil {
+		key, err = r.service.CreateShort(request.Context(), *originURL, owner)
+	}
+	// конфликт отдаёт существующую ссылку, как и успешное создание
 	var dupErr *domain.ErrURLAlreadyExists
 	if errors.As(err, &dupErr) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusConflict)
-		err = json.NewEncoder(w).Encode(ShortenResponse{Result: adapters.CreatePublicURL(dupErr.HashKey)})
+		err = json.NewEncoder(w).Encode(ShortenResponse{Result: adapters.CreatePublicURL(domain.LinkDomainFromCtx(request.Context()), dupErr.HashKey)})
 		if err != nil {
 			r.logger.Debug("cannot encode response JSON", zap.Error(err))
 		}
 		return
 	}
+	if writeServiceError(w, err) {
+		return
+	}
 
 	if err != nil {
 		r.logger.Debug("cannot add url", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+		writeInternalError(w)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	err = json.NewEncoder(w).Encode(ShortenResponse{Result: adapters.CreatePublicURL(key)})
+	err = json.NewEncoder(w).Encode(ShortenResponse{Result: adapters.CreatePublicURL(domain.LinkDomainFromCtx(request.Context()), key)})
 	if err != nil {
 		r.logger.Debug("cannot encode response JSON", zap.Error(err))
 	}
@@ -146,69 +191,72 @@ type ShortenBatchItem struct {
 	OriginalURL   string `json:"original_url"`
 }
 
-// ShortenItemRes - ответ на укорочение нескольких ссылок
+// ShortenItemRes - результат укорочения ссылки из пакета, ShortURL - созданная или уже существующая ссылка
 type ShortenItemRes struct {
-	CorrelationID string `json:"correlation_id"`
-	ShortURL      string `json:"short_url"`
+	CorrelationID string             `json:"correlation_id"`
+	ShortURL      string             `json:"short_url,omitempty"`
+	Status        domain.BatchStatus `json:"status"`
+	Error         string             `json:"error,omitempty"`
 }
 
+// batchShorten создание ссылок с результатом для каждой в порядке запроса.
+// Если все ссылки созданы - 201, иначе 200 с результатами.
+// С atomic=true ссылки создаются только все вместе, при отказе - 409 если есть существующие ссылки, иначе 400.
 func (r *HTTPHandlers) batchShorten(w http.ResponseWriter, request *http.Request) {
 	var req []ShortenBatchItem
 	err := json.NewDecoder(request.Body).Decode(&req)
 	if err != nil {
 		r.logger.Debug("cannot decode request JSON body", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
 		return
 	}
 	if len(req) == 0 {
 		w.WriteHeader(http.StatusCreated)
 		return
 	}
+	atomic, err := strconv.ParseBool(cmp.Or(request.URL.Query().Get("atomic"), "false"))
+	if err != nil {
+		writeFieldError(w, "atomic", "must be boolean")
+		return
+	}
 
-	slices.SortFunc(req, func(a, b ShortenBatchItem) int {
-		return cmp.Compare(a.CorrelationID, b.CorrelationID)
-	})
-
-	originURLs := make([]domain.BatchItem, 0, len(req))
+	rawURLs := make([]string, 0, len(req))
 	for _, item := range req {
-		var u *url.URL
-		u, err = url.Parse(item.OriginalURL)
-		if err != nil {
-			http.Error(w, "Invalid url", http.StatusBadRequest)
-			return
-		}
-		originURLs = append(originURLs, domain.BatchItem{
-			URL: *u,
-		})
+		rawURLs = append(rawURLs, item.OriginalURL)
 	}
-
-	originURLs, err = r.service.BatchAdd(request.Context(), originURLs, adapters.MustUserIDFromReq(request))
-	var dupErr *domain.ErrURLAlreadyExists
-	if errors.As(err, &dupErr) {
-		w.WriteHeader(http.StatusConflict)
+	var results []domain.BatchResult
+	owner, err := ownerFromReq(request)
+	if err == nil {
+		results, err = r.service.BatchAdd(request.Context(), rawURLs, owner, atomic)
+	}
+	if writeServiceError(w, err) {
 		return
 	}
 	if err != nil {
 		r.logger.Debug("cannot batch add urls", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+		writeInternalError(w)
 		return
 	}
 
-	resp := make([]ShortenItemRes, 0, len(originURLs))
-	for i, item := range originURLs {
-		resp = append(resp, ShortenItemRes{
-			CorrelationID: req[i].CorrelationID,
-			ShortURL:      adapters.CreatePublicURL(item.HashKey),
-		})
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-
-	err = json.NewEncoder(w).Encode(resp)
-	if err != nil {
-		r.logger.Debug("cannot encode response JSON", zap.Error(err))
+	resp := make([]ShortenItemRes, 0, len(results))
+	status := http.StatusCreated
+	for i, res := range results {
+		item := ShortenItemRes{CorrelationID: req[i].CorrelationID, Status: res.Status, Error: res.Error}
+		if res.Key != "" {
+			item.ShortURL = adapters.CreatePublicURL(domain.LinkDomainFromCtx(request.Context()), res.Key)
+		}
+		switch {
+		case res.Status == domain.BatchCreated:
+		case !atomic:
+			status = http.StatusOK
+		case res.Status == domain.BatchExists:
+			status = http.StatusConflict
+		case status != http.StatusConflict:
+			status = http.StatusBadRequest
+		}
+		resp = append(resp, item)
 	}
+	r.writeJSON(w, status, resp)
 }
 
 func (r *HTTPHandlers) ping(w http.ResponseWriter, request *http.Request) {
@@ -216,21 +264,68 @@ func (r *HTTPHandlers) ping(w http.ResponseWriter, request *http.Request) {
 	if err == nil {
 		w.WriteHeader(http.StatusOK)
 	} else {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeInternalError(w)
 	}
 }
 
+// linkQueryFromReq параметры списка ссылок: limit, cursor, order=asc|desc, domain, q, tag (может повторяться), text
+func linkQueryFromReq(request *http.Request) (domain.LinkQuery, error) {
+	query := request.URL.Query()
+	q := domain.LinkQuery{Domain: query.Get("domain"), Search: query.Get("q"), Tags: query["tag"], Text: query.Get("text")}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return q, &domain.ValidationError{Field: "limit", Message: "must be a positive integer"}
+		}
+		q.Limit = limit
+	}
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := domain.ParseLinkCursor(v)
+		if err != nil {
+			return q, err
+		}
+		q.After = cursor
+	}
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		q.Ascending = true
+	default:
+		return q, &domain.ValidationError{Field: "order", Message: "must be asc or desc"}
+	}
+	return q, nil
+}
+
+// xNextCursor - курсор следующей страницы списка
+var xNextCursor = http.CanonicalHeaderKey("X-Next-Cursor")
+
 func (r *HTTPHandlers) getMyUrls(w http.ResponseWriter, request *http.Request) {
-	list, err := r.service.GetByUser(request.Context(), adapters.MustUserIDFromReq(request))
+	owner, err := ownerFromReq(request)
+	var q domain.LinkQuery
+	if err == nil {
+		q, err = linkQueryFromReq(request)
+	}
+	var page *domain.LinkPage
+	if err == nil && owner.WorkspaceID != uuid.Nil {
+		page, err = r.service.GetByWorkspace(request.Context(), owner.WorkspaceID, owner.UserID, q)
+	} else if err == nil {
+		page, err = r.service.GetByUser(request.Context(), owner.UserID, q)
+	}
+	if writeServiceError(w, err) {
+		return
+	}
 	if err != nil {
 		r.logger.Debug("cannot get urls", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+		writeInternalError(w)
 		return
 	}
 
+	if page.NextCursor != "" {
+		w.Header().Set(xNextCursor, page.NextCursor)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(list)
+	err = json.NewEncoder(w).Encode(page.URLs)
 	if err != nil {
 		r.logger.Debug("cannot encode response JSON", zap.Error(err))
 	}
@@ -241,7 +336,7 @@ func (r *HTTPHandlers) deleteUrls(w http.ResponseWriter, request *http.Request)
 	err := json.NewDecoder(request.Body).Decode(&keys)
 	if err != nil {
 		r.logger.Debug("cannot decode request JSON body", zap.Error(err))
-		w.WriteHeader(http.StatusBadRequest)
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
 		return
 	}
 	keys = utils.Filter(keys, func(key string) bool {
@@ -251,8 +346,7 @@ func (r *HTTPHandlers) deleteUrls(w http.ResponseWriter, request *http.Request)
 		_, err = r.service.DeleteByUser(request.Context(), keys, adapters.MustUserIDFromReq(request))
 		if err != nil {
 			r.logger.Error("cannot delete urls", zap.Error(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte("Cannot delete urls"))
+			writeInternalError(w)
 			return
 		}
 	}
@@ -265,8 +359,7 @@ func (r *HTTPHandlers) stats(w http.ResponseWriter, request *http.Request) {
 
 	if err != nil {
 		r.logger.Error("cannot get stats", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
-		_, _ = w.Write([]byte("Cannot get stats"))
+		writeInternalError(w)
 		return
 	}
 
@@ -276,30 +369,120 @@ func (r *HTTPHandlers) stats(w http.ResponseWriter, request *http.Request) {
 }
 
 // CreateServeMux - создание основных хендлеров
-func CreateServeMux(service *domain.ShortenerService, logger zap.SugaredLogger, pool *pgxpool.Pool) *chi.Mux {
+func CreateServeMux(service *domain.ShortenerService, logger zap.SugaredLogger, pool *pgxpool.Pool, opts ...ServeMuxOption) *chi.Mux {
+	trustedProxies, err := utils.ParseCIDRs(internal.Config.TrustedProxies)
+	if err != nil {
+		panic(err)
+	}
+
 	r := chi.NewRouter()
+	r.NotFound(notFound)
+	r.MethodNotAllowed(methodNotAllowed)
+	r.Use(RequestIDMiddleware)
+	r.Use(ClientIPMiddleware(adapters.NewClientIPResolver(trustedProxies, internal.Config.ClientIPHeader)))
 	r.Use(middleware.Logger)
 	handlers := NewHTTPHandlers(service, logger, pool)
+	for _, opt := range opts {
+		opt(handlers)
+	}
+	r.Use(LinkDomainMiddleware(handlers.domains))
+	if handlers.apiKeys == nil {
+		handlers.apiKeys = domain.NewAPIKeyService(adapters.NewMemAPIKeyRepository())
+	}
+	if handlers.auth == nil {
+		keyring := adapters.NewKeyring(adapters.NewHMACKey(adapters.DefaultKeyID, []byte(internal.Config.JwtSecret)))
+		handlers.auth = adapters.NewAuthenticator(keyring, handlers.apiKeys, internal.Config.JwtRefreshTTL)
+	}
+	if handlers.users == nil {
+		handlers.users = domain.NewUserService(adapters.NewMemUserRepository(), service)
+	}
+	if handlers.templates == nil {
+		handlers.templates = template.Must(LoadTemplates(""))
+	}
+	auth := handlers.auth
+	validate := ValidateRequest()
+
+	createLimit := RateLimitMiddleware(logger, handlers.rateLimiter, rateLimitCreate, handlers.rateLimits.Create, nil)
+	batchLimit := RateLimitMiddleware(logger, handlers.rateLimiter, rateLimitBatch, handlers.rateLimits.Batch, batchCost)
+	redirectLimit := RateLimitMiddleware(logger, handlers.rateLimiter, rateLimitRedirect, handlers.rateLimits.Redirect, nil)
+	deleteLimit := RateLimitMiddleware(logger, handlers.rateLimiter, rateLimitDelete, handlers.rateLimits.Delete, nil)
 
-	statsHandler := WithAuth(false, gzipHandle(WithLogging(logger, handlers.stats)))
+	statsHandler := WithAuth(auth, false, RequireScope(domain.ScopeStats, gzipHandle(validate(WithLogging(logger, handlers.stats)))))
 	if internal.Config.TrustedSubnet != "" {
-		_, subnet, err := net.ParseCIDR(internal.Config.TrustedSubnet)
+		subnets, err := utils.ParseCIDRs(strings.Split(internal.Config.TrustedSubnet, ","))
 		if err != nil {
 			panic(err)
 		}
 
-		statsHandler = TrustedClientMiddleware(logger, subnet)(statsHandler)
+		statsHandler = TrustedClientMiddleware(logger, subnets)(statsHandler)
 	}
 
-	r.Post("/", WithAuth(false, gzipHandle(WithLogging(logger, handlers.createShortHandler))))
-	r.Get("/{hash}", WithAuth(false, gzipHandle(WithLogging(logger, handlers.getOriginLinkHandler))))
-	r.Post("/api/shorten", WithAuth(false, gzipHandle(WithLogging(logger, handlers.shorten))))
-	r.Post("/api/shorten/batch", WithAuth(false, gzipHandle(WithLogging(logger, handlers.batchShorten))))
-	r.Get("/api/user/urls", WithAuth(true, gzipHandle(WithLogging(logger, handlers.getMyUrls))))
-	r.Delete("/api/user/urls", WithAuth(false, gzipHandle(WithLogging(logger, handlers.deleteUrls))))
+	r.Post("/", WithAuth(auth, false, RequireScope(domain.ScopeCreate, gzipHandle(createLimit(validate(WithLogging(logger, handlers.createShortHandler)))))))
+	redirectHandler := WithOptionalAuth(auth, gzipHandle(redirectLimit(validate(WithLogging(logger, handlers.getOriginLinkHandler)))))
+	r.Get("/{hash}", redirectHandler)
+	r.Head("/{hash}", redirectHandler)
+	// qr код кешируется навсегда, поэтому ответ не должен содержать токенов
+	r.Get("/{hash}/qr", gzipHandle(redirectLimit(validate(WithLogging(logger, handlers.getQRCode)))))
+	r.Post("/api/shorten", WithAuth(auth, false, RequireScope(domain.ScopeCreate, gzipHandle(createLimit(validate(WithLogging(logger, handlers.shorten)))))))
+	r.Post("/api/shorten/batch", WithAuth(auth, false, RequireScope(domain.ScopeCreate, gzipHandle(batchLimit(validate(WithLogging(logger, handlers.batchShorten)))))))
+	r.Post("/api/shorten/import", WithAuth(auth, true, RequireScope(domain.ScopeCreate, gzipHandle(createLimit(validate(WithLogging(logger, handlers.importUrls)))))))
+	r.Get("/api/shorten/import/{id}", WithAuth(auth, true, RequireScope(domain.ScopeRead, validate(WithLogging(logger, handlers.getImportJob)))))
+	r.Get("/api/user/urls", WithAuth(auth, true, RequireScope(domain.ScopeRead, gzipHandle(validate(WithLogging(logger, handlers.getMyUrls))))))
+	r.Get("/api/user/urls/export", WithAuth(auth, true, RequireScope(domain.ScopeRead, gzipHandle(validate(WithLogging(logger, handlers.exportUrls))))))
+	r.Delete("/api/user/urls", WithAuth(auth, false, RequireScope(domain.ScopeDelete, gzipHandle(deleteLimit(validate(WithLogging(logger, handlers.deleteUrls)))))))
+	r.Put("/api/user/urls/{key}/redirect", WithAuth(auth, true, RequireScope(domain.ScopeCreate, validate(WithLogging(logger, handlers.setLinkRedirect)))))
+	r.Put("/api/user/urls/{key}/preview", WithAuth(auth, true, RequireScope(domain.ScopeCreate, validate(WithLogging(logger, handlers.setLinkPreview)))))
+	r.Put("/api/user/urls/{key}/rules", WithAuth(auth, true, RequireScope(domain.ScopeCreate, validate(WithLogging(logger, handlers.setLinkRules)))))
+	r.Put("/api/user/urls/{key}/variants", WithAuth(auth, true, RequireScope(domain.ScopeCreate, validate(WithLogging(logger, handlers.setLinkVariants)))))
+	r.Put("/api/user/urls/{key}/meta", WithAuth(auth, true, RequireScope(domain.ScopeCreate, validate(WithLogging(logger, handlers.setLinkMeta)))))
+	r.Post("/api/user/tags/rename", WithAuth(auth, true, RequireScope(domain.ScopeCreate, validate(WithLogging(logger, handlers.renameTag)))))
+	r.Put("/api/user/urls/{key}/params", WithAuth(auth, true, RequireScope(domain.ScopeCreate, validate(WithLogging(logger, handlers.setLinkParams)))))
+	r.Get("/api/user/default-params", WithAuth(auth, true, RequireScope(domain.ScopeRead, validate(WithLogging(logger, handlers.getDefaultParams)))))
+	r.Put("/api/user/default-params", WithAuth(auth, true, RequireScope(domain.ScopeCreate, validate(WithLogging(logger, handlers.setDefaultParams)))))
+	r.Get("/api/user/urls/{key}/stats", WithAuth(auth, true, RequireScope(domain.ScopeRead, validate(WithLogging(logger, handlers.getLinkStats)))))
 	r.Get("/api/internal/stats", statsHandler)
 
+	r.Post("/api/auth/refresh", validate(WithLogging(logger, handlers.refreshTokens)))
+	r.Post("/api/auth/signup", WithAuth(auth, false, withoutAPIKey(validate(WithLogging(logger, handlers.signup)))))
+	r.Post("/api/auth/login", WithAuth(auth, false, withoutAPIKey(validate(WithLogging(logger, handlers.login)))))
+	r.Post("/api/auth/logout", validate(WithLogging(logger, handlers.logout)))
+	if handlers.oidc != nil {
+		r.Get("/api/auth/oidc/login", validate(WithLogging(logger, handlers.oidcLogin)))
+		r.Get("/api/auth/oidc/callback", WithAuth(auth, false, withoutAPIKey(validate(WithLogging(logger, handlers.oidcCallback)))))
+	}
+	if handlers.workspaces != nil {
+		r.Post("/api/workspaces", WithAuth(auth, true, withoutAPIKey(validate(WithLogging(logger, handlers.createWorkspace)))))
+		r.Get("/api/workspaces", WithAuth(auth, true, RequireScope(domain.ScopeRead, validate(WithLogging(logger, handlers.listWorkspaces)))))
+		r.Get("/api/workspaces/{id}/members", WithAuth(auth, true, RequireScope(domain.ScopeRead, validate(WithLogging(logger, handlers.listWorkspaceMembers)))))
+		r.Put("/api/workspaces/{id}/members/{userID}", WithAuth(auth, true, withoutAPIKey(validate(WithLogging(logger, handlers.setWorkspaceMember)))))
+		r.Delete("/api/workspaces/{id}/members/{userID}", WithAuth(auth, true, withoutAPIKey(validate(WithLogging(logger, handlers.removeWorkspaceMember)))))
+	}
+	if handlers.admin != nil {
+		r.Get("/api/admin/links/{key}", WithAuth(auth, true, RequireAdmin(auth, validate(WithLogging(logger, handlers.adminGetLink)))))
+		r.Post("/api/admin/links/{key}/disable", WithAuth(auth, true, RequireAdmin(auth, validate(WithLogging(logger, handlers.adminDisableLink)))))
+		r.Post("/api/admin/links/{key}/enable", WithAuth(auth, true, RequireAdmin(auth, validate(WithLogging(logger, handlers.adminEnableLink)))))
+		r.Delete("/api/admin/links", WithAuth(auth, true, RequireAdmin(auth, validate(WithLogging(logger, handlers.adminDeleteByDomain)))))
+		r.Get("/api/admin/users/{id}/links", WithAuth(auth, true, RequireAdmin(auth, validate(WithLogging(logger, handlers.adminListUserLinks)))))
+		r.Put("/api/admin/users/{id}/ban", WithAuth(auth, true, RequireAdmin(auth, validate(WithLogging(logger, handlers.adminBanUser)))))
+		r.Delete("/api/admin/users/{id}/ban", WithAuth(auth, true, RequireAdmin(auth, validate(WithLogging(logger, handlers.adminUnbanUser)))))
+	}
+	if handlers.domains != nil {
+		r.Get("/api/user/domains", WithAuth(auth, true, RequireScope(domain.ScopeRead, validate(WithLogging(logger, handlers.listMyDomains)))))
+		r.Put("/api/admin/domains/{domain}/users/{id}", WithAuth(auth, true, RequireAdmin(auth, validate(WithLogging(logger, handlers.adminGrantDomain)))))
+		r.Delete("/api/admin/domains/{domain}/users/{id}", WithAuth(auth, true, RequireAdmin(auth, validate(WithLogging(logger, handlers.adminRevokeDomain)))))
+	}
+	if handlers.audit != nil {
+		r.Get("/api/admin/audit", WithAuth(auth, true, RequireAdmin(auth, gzipHandle(validate(WithLogging(logger, handlers.adminAuditLog))))))
+	}
+	r.Post("/api/user/claim", WithAuth(auth, true, withoutAPIKey(validate(WithLogging(logger, handlers.claimLinks)))))
+
+	r.Post("/api/user/api-keys", WithAuth(auth, true, withoutAPIKey(validate(WithLogging(logger, handlers.createAPIKey)))))
+	r.Get("/api/user/api-keys", WithAuth(auth, true, withoutAPIKey(validate(WithLogging(logger, handlers.listAPIKeys)))))
+	r.Patch("/api/user/api-keys/{id}", WithAuth(auth, true, withoutAPIKey(validate(WithLogging(logger, handlers.renameAPIKey)))))
+	r.Delete("/api/user/api-keys/{id}", WithAuth(auth, true, withoutAPIKey(validate(WithLogging(logger, handlers.revokeAPIKey)))))
+
 	r.Get("/ping", handlers.ping)
+	r.Get("/api/openapi.json", handlers.openAPI)
 	r.Mount("/debug", middleware.Profiler())
 
 	return r
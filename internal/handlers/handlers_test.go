@@ -41,6 +41,8 @@ func TestIteration2(t *testing.T) {
 		urlRepo = adapters.NewPgURLRepository(pool)
 	}
 	internal.Config.TrustedSubnet = "192.168.146.0/24"
+	internal.Config.TrustedProxies = []string{"127.0.0.1", "::1"}
+	internal.Config.ClientIPHeader = "X-Real-IP"
 
 	testServer := httptest.NewServer(CreateServeMux(domain.NewShortenerService(urlRepo, adapters.GenBase64ShortURLToken), logger, nil))
 	defer testServer.Close()
@@ -94,6 +96,8 @@ func TestIteration2(t *testing.T) {
 		defer resp.Body.Close()
 		require.Equal(t, http.StatusCreated, resp.StatusCode)
 		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		require.NotEmpty(t, resp.Header.Get("Authorization"))
+		authorization := resp.Header.Get("Authorization")
 
 		var shortenRes ShortenResponse
 		err = json.NewDecoder(resp.Body).Decode(&shortenRes)
@@ -106,8 +110,7 @@ func TestIteration2(t *testing.T) {
 		defer resp.Body.Close()
 		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
 		require.Equal(t, "https://yandex.ru", resp.Header.Get("Location"))
-		require.NotEmpty(t, resp.Header.Get("Authorization"))
-		authorization := resp.Header.Get("Authorization")
+		require.Empty(t, resp.Header.Get("Authorization"), "redirects do not issue tokens")
 
 		var buf bytes.Buffer
 		g := gzip.NewWriter(&buf)
@@ -165,12 +168,58 @@ func TestIteration2(t *testing.T) {
 		require.Equal(t, http.StatusAccepted, resp.StatusCode)
 	})
 
+	t.Run("batch per item results", func(t *testing.T) {
+		batch := func(query string, body string) (int, []ShortenItemRes) {
+			resp, err := httpClient.Post(testServer.URL+"/api/shorten/batch"+query, "application/json", strings.NewReader(body))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			urls := make([]ShortenItemRes, 0)
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&urls))
+			return resp.StatusCode, urls
+		}
+		body := `[
+{"correlation_id": "b", "original_url": "https://example.com/batch/1"},
+{"correlation_id": "a", "original_url": "javascript:alert(1)"},
+{"correlation_id": "c", "original_url": "https://example.com/batch/1"}
+]`
+
+		code, urls := batch("?atomic=true", body)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Len(t, urls, 3)
+		require.Equal(t, []domain.BatchStatus{domain.BatchSkipped, domain.BatchInvalid, domain.BatchInvalid},
+			[]domain.BatchStatus{urls[0].Status, urls[1].Status, urls[2].Status})
+		require.Empty(t, urls[0].ShortURL)
+
+		code, urls = batch("", body)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, []string{"b", "a", "c"}, []string{urls[0].CorrelationID, urls[1].CorrelationID, urls[2].CorrelationID}, "request order")
+		require.Equal(t, domain.BatchCreated, urls[0].Status)
+		require.Equal(t, domain.BatchInvalid, urls[1].Status)
+		require.NotEmpty(t, urls[1].Error)
+		require.Equal(t, domain.BatchExists, urls[2].Status)
+		require.Equal(t, urls[0].ShortURL, urls[2].ShortURL)
+
+		code, urls = batch("?atomic=true", `[{"correlation_id": "1", "original_url": "https://example.com/batch/2"}]`)
+		require.Equal(t, http.StatusCreated, code)
+		require.Equal(t, domain.BatchCreated, urls[0].Status)
+	})
+
 	t.Run("bad request", func(t *testing.T) {
 		resp, err := httpClient.Post(testServer.URL, "text/plain", strings.NewReader(`wrong url format`))
 		require.NoError(t, err)
 		defer resp.Body.Close()
 		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
 
+		resp, err = httpClient.Post(testServer.URL+"/api/shorten", "application/json", strings.NewReader(`{"url": "javascript:alert(1)"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, err = httpClient.Post(testServer.URL, "text/plain", strings.NewReader(`http://127.0.0.1:8080/admin`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
 		resp, err = httpClient.Get(testServer.URL + "/NoExistShortUrl")
 		require.NoError(t, err)
 		defer resp.Body.Close()
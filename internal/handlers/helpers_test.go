@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer - сервер с обработчиками сервиса и клиент, который не следует редиректам,
+// чтобы тесты видели ответ самой короткой ссылки
+type testServer struct {
+	*httptest.Server
+	t      *testing.T
+	auth   *adapters.Authenticator
+	client *http.Client
+}
+
+// newTestAuthenticator выдаёт токены, подписанные секретом из конфига
+func newTestAuthenticator(apiKeys *domain.APIKeyService, opts ...adapters.AuthenticatorOption) *adapters.Authenticator {
+	keyring := adapters.NewKeyring(adapters.NewHMACKey(adapters.DefaultKeyID, []byte(internal.Config.JwtSecret)))
+	return adapters.NewAuthenticator(keyring, apiKeys, 0, opts...)
+}
+
+// newTestServer поднимает сервер до конца теста; auth проверяет токены, выданные token
+func newTestServer(t *testing.T, service *domain.ShortenerService, auth *adapters.Authenticator, opts ...ServeMuxOption) *testServer {
+	s := &testServer{
+		Server: httptest.NewServer(CreateServeMux(service, adapters.CreateLogger(), nil, append([]ServeMuxOption{WithAuthenticator(auth)}, opts...)...)),
+		t:      t,
+		auth:   auth,
+		client: &http.Client{CheckRedirect: noRedirect},
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+func noRedirect(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// token JWT пользователя для заголовка Authorization
+func (s *testServer) token(userID uuid.UUID) string {
+	return utils.Must(s.auth.BuildJWTString(userID))
+}
+
+// do отправляет запрос от имени token (пустой - анонимно); headers - пары имя, значение
+func (s *testServer) do(token string, method string, target string, body string, headers ...string) (*http.Response, string) {
+	return s.doWith(s.client, token, method, target, body, headers...)
+}
+
+// doWith то же, что do, но через другой клиент, например с собственными cookie
+func (s *testServer) doWith(client *http.Client, token string, method string, target string, body string, headers ...string) (*http.Response, string) {
+	req := utils.Must(http.NewRequest(method, s.URL+target, strings.NewReader(body)))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := client.Do(req)
+	require.NoError(s.t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(s.t, err)
+	return resp, string(b)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxImportLineSize - максимальная длина строки jsonl
+const maxImportLineSize = 1 << 20
+
+// xImportJobID - идентификатор задачи импорта для продолжения загрузки
+var xImportJobID = http.CanonicalHeaderKey("X-Import-Job-Id")
+
+// ImportLine - строка jsonl файла импорта
+type ImportLine struct {
+	Key    string   `json:"key"`
+	URL    string   `json:"url"`
+	Expiry string   `json:"expiry"`
+	Tags   []string `json:"tags"`
+}
+
+// ImportResultLine - строка ответа импорта: результат строки файла, последняя строка - состояние задачи
+type ImportResultLine struct {
+	Row      int64               `json:"row,omitempty"`
+	Key      string              `json:"key,omitempty"`
+	ShortURL string              `json:"short_url,omitempty"`
+	Status   domain.ImportStatus `json:"status,omitempty"`
+	Error    string              `json:"error,omitempty"`
+	Job      *domain.ImportJob   `json:"job,omitempty"`
+}
+
+// importUrls потоковый импорт ссылок из csv или jsonl, результаты строк отдаются в jsonl по мере сохранения.
+// Прерванный импорт продолжается повторной отправкой того же файла с job_id.
+func (r *HTTPHandlers) importUrls(w http.ResponseWriter, request *http.Request) {
+	var next domain.ImportSource
+	var err error
+	switch importFormat(request) {
+	case "csv":
+		next, err = csvImportSource(request.Body)
+	case "jsonl":
+		next = jsonlImportSource(request.Body)
+	default:
+		writeFieldError(w, "format", "must be csv or jsonl")
+		return
+	}
+	if err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	var jobID uuid.UUID
+	if raw := request.URL.Query().Get("job_id"); raw != "" {
+		if jobID, err = uuid.Parse(raw); err != nil {
+			writeFieldError(w, "job_id", "invalid uuid")
+			return
+		}
+	}
+	var job *domain.ImportJob
+	owner, err := ownerFromReq(request)
+	if err == nil {
+		job, err = r.service.StartImport(request.Context(), owner, jobID)
+	}
+	if r.writeImportError(w, err) {
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// файл дочитывается уже после начала ответа
+	_ = rc.EnableFullDuplex()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set(xImportJobID, job.ID.String())
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	encoder := json.NewEncoder(w)
+	err = r.service.Import(request.Context(), job, next, func(results []domain.ImportResult) error {
+		for _, res := range results {
+			line := ImportResultLine{Row: res.Row, Key: res.Key, Status: res.Status, Error: res.Error}
+			if res.Status == domain.ImportCreated {
+				line.ShortURL = adapters.CreatePublicURL(domain.LinkDomainFromCtx(request.Context()), res.Key)
+			}
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+		}
+		_ = rc.Flush()
+		return nil
+	})
+	summary := ImportResultLine{Job: job}
+	if err != nil {
+		r.logger.Errorw("import interrupted", "job_id", job.ID, "error", err)
+		summary.Error = "import interrupted, send the file again with job_id to resume"
+	}
+	_ = encoder.Encode(summary)
+}
+
+// getImportJob состояние задачи импорта
+func (r *HTTPHandlers) getImportJob(w http.ResponseWriter, request *http.Request) {
+	jobID, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		writeFieldError(w, "id", "invalid uuid")
+		return
+	}
+	job, err := r.service.GetImportJob(request.Context(), jobID, adapters.MustUserIDFromReq(request))
+	if r.writeImportError(w, err) {
+		return
+	}
+	r.writeJSON(w, http.StatusOK, job)
+}
+
+// writeImportError ответ на ошибку запуска импорта, false если ошибки нет
+func (r *HTTPHandlers) writeImportError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case writeServiceError(w, err):
+	default:
+		r.logger.Errorw("cannot start import", "error", err)
+		writeInternalError(w)
+	}
+	return true
+}
+
+// importFormat формат файла из параметра format или Content-Type
+func importFormat(request *http.Request) string {
+	if format := request.URL.Query().Get("format"); format != "" {
+		return strings.ToLower(format)
+	}
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return "csv"
+	case "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+		return "jsonl"
+	}
+	return ""
+}
+
+// csvImportSource строки csv с заголовком из колонок key, url, expiry, tags в любом порядке, обязательна только url.
+// Теги в колонке tags разделяются запятой.
+func csvImportSource(body io.Reader) (domain.ImportSource, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read csv header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, errors.New("csv header must contain url column")
+	}
+
+	return func() (domain.ImportRow, error) {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return domain.ImportRow{}, io.EOF
+		}
+		parseErr := &csv.ParseError{}
+		if errors.As(err, &parseErr) {
+			return domain.ImportRow{Err: err}, nil
+		}
+		if err != nil {
+			return domain.ImportRow{}, err
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		row := domain.ImportRow{Key: field("key"), URL: field("url"), Expiry: field("expiry")}
+		if tags := field("tags"); tags != "" {
+			row.Tags = strings.Split(tags, ",")
+		}
+		return row, nil
+	}, nil
+}
+
+// jsonlImportSource строки jsonl в формате ImportLine, пустые строки пропускаются
+func jsonlImportSource(body io.Reader) domain.ImportSource {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+	return func() (domain.ImportRow, error) {
+		for scanner.Scan() {
+			b := scanner.Bytes()
+			if len(strings.TrimSpace(string(b))) == 0 {
+				continue
+			}
+			var line ImportLine
+			if err := json.Unmarshal(b, &line); err != nil {
+				return domain.ImportRow{Err: &domain.ValidationError{Field: "line", Message: err.Error()}}, nil
+			}
+			return domain.ImportRow{Key: line.Key, URL: line.URL, Expiry: line.Expiry, Tags: line.Tags}, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return domain.ImportRow{}, err
+		}
+		return domain.ImportRow{}, io.EOF
+	}
+}
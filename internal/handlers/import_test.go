@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportUrls(t *testing.T) {
+	logger := adapters.CreateLogger()
+	service := domain.NewShortenerService(
+		adapters.NewMemURLRepository(),
+		adapters.GenBase64ShortURLToken,
+		domain.WithImportJobRepository(adapters.NewMemImportJobRepository()),
+	)
+	keyring := adapters.NewKeyring(adapters.NewHMACKey(adapters.DefaultKeyID, []byte(internal.Config.JwtSecret)))
+	auth := adapters.NewAuthenticator(keyring, nil, 0)
+
+	testServer := httptest.NewServer(CreateServeMux(service, logger, nil, WithAuthenticator(auth)))
+	defer testServer.Close()
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	token := utils.Must(auth.BuildJWTString(uuid.New()))
+	importFile := func(query string, contentType string, body string) (string, []ImportResultLine) {
+		req := utils.Must(http.NewRequest(http.MethodPost, testServer.URL+"/api/shorten/import"+query, strings.NewReader(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", contentType)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var lines []ImportResultLine
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var line ImportResultLine
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			lines = append(lines, line)
+		}
+		require.NoError(t, scanner.Err())
+		require.NotEmpty(t, lines)
+		return resp.Header.Get(xImportJobID), lines
+	}
+
+	t.Run("requires auth", func(t *testing.T) {
+		resp, err := client.Post(testServer.URL+"/api/shorten/import", "text/csv", strings.NewReader("url\nhttps://example.com\n"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		expiry := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		body := "url,key,expiry,tags\n" +
+			"https://example.com/import/1,imported-1," + expiry + ",\"News, promo\"\n" +
+			"https://example.com/import/2,,,\n" +
+			"not a url,,,\n" +
+			"https://example.com/import/3,imported-1,,\n" +
+			"https://example.com/import/4,bad key!,,\n" +
+			"https://example.com/import/5,,2000-01-01T00:00:00Z,\n" +
+			"https://example.com/import/6,ping,,\n"
+		jobID, lines := importFile("", "text/csv", body)
+		require.NotEmpty(t, jobID)
+		require.Len(t, lines, 8)
+
+		statuses := make([]domain.ImportStatus, 0, 7)
+		for i, line := range lines[:7] {
+			require.EqualValues(t, i+1, line.Row)
+			statuses = append(statuses, line.Status)
+		}
+		require.Equal(t, []domain.ImportStatus{
+			domain.ImportCreated, domain.ImportCreated, domain.ImportInvalid,
+			domain.ImportConflict, domain.ImportInvalid, domain.ImportInvalid,
+			domain.ImportInvalid,
+		}, statuses)
+		require.Contains(t, lines[6].Error, "reserved", "the static route shadows the key")
+		require.Equal(t, "imported-1", lines[0].Key)
+		require.NotEmpty(t, lines[1].ShortURL)
+
+		summary := lines[7].Job
+		require.NotNil(t, summary)
+		require.Equal(t, jobID, summary.ID.String())
+		require.Equal(t, domain.ImportJobCompleted, summary.Status)
+		require.EqualValues(t, 7, summary.Processed)
+		require.EqualValues(t, 2, summary.Created)
+		require.EqualValues(t, 1, summary.Conflicts)
+		require.EqualValues(t, 4, summary.Invalid)
+
+		resp, err := client.Get(testServer.URL + "/imported-1")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		require.Equal(t, "https://example.com/import/1", resp.Header.Get("Location"))
+	})
+
+	t.Run("jsonl resume", func(t *testing.T) {
+		rows := []string{
+			`{"url": "https://example.org/resume/1"}`,
+			`{"url": "https://example.org/resume/2", "tags": ["a"]}`,
+			`{broken`,
+		}
+		jobID, lines := importFile("?format=jsonl", "application/octet-stream", strings.Join(rows, "\n"))
+		require.Len(t, lines, 4)
+		require.Equal(t, domain.ImportInvalid, lines[2].Status)
+
+		rows = append(rows, `{"url": "https://example.org/resume/3", "key": "resumed"}`, "", `{"url": "https://example.org/resume/4"}`)
+		resumedID, lines := importFile("?format=jsonl&job_id="+jobID, "application/x-ndjson", strings.Join(rows, "\n"))
+		require.Equal(t, jobID, resumedID)
+		require.Len(t, lines, 3, "only rows after processed are imported")
+		require.EqualValues(t, 4, lines[0].Row)
+		require.Equal(t, "resumed", lines[0].Key)
+		require.EqualValues(t, 5, lines[1].Row)
+		require.EqualValues(t, 5, lines[2].Job.Processed)
+		require.EqualValues(t, 4, lines[2].Job.Created)
+
+		req := utils.Must(http.NewRequest(http.MethodGet, testServer.URL+"/api/shorten/import/"+jobID, nil))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var job domain.ImportJob
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+		require.EqualValues(t, 5, job.Processed)
+	})
+
+	t.Run("foreign job", func(t *testing.T) {
+		req := utils.Must(http.NewRequest(http.MethodPost, testServer.URL+"/api/shorten/import?format=jsonl&job_id="+uuid.NewString(), strings.NewReader("")))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// RenameTagResponse - ответ на переименование тега
+type RenameTagResponse struct {
+	// Updated - сколько ссылок изменилось
+	Updated int64 `json:"updated"`
+}
+
+func (r *HTTPHandlers) setLinkMeta(w http.ResponseWriter, request *http.Request) {
+	var meta domain.LinkMeta
+	if err := json.NewDecoder(request.Body).Decode(&meta); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	link, err := r.service.SetMeta(request.Context(), chi.URLParam(request, "key"), adapters.MustUserIDFromReq(request), meta)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot set link meta", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, link)
+}
+
+// renameTag переименование или объединение тега в личных ссылках или в ссылках пространства из workspace_id
+func (r *HTTPHandlers) renameTag(w http.ResponseWriter, request *http.Request) {
+	owner, err := ownerFromReq(request)
+	if writeServiceError(w, err) {
+		return
+	}
+	var rename domain.TagRename
+	if err = json.NewDecoder(request.Body).Decode(&rename); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	updated, err := r.service.RenameTag(request.Context(), owner, rename)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot rename tag", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, RenameTagResponse{Updated: updated})
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkMeta(t *testing.T) {
+	auditRepo := adapters.NewMemAuditRepository()
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken,
+		domain.WithAuditService(domain.NewAuditService(auditRepo, adapters.AuditActorFromCtx)))
+	srv := newTestServer(t, service, newTestAuthenticator(nil))
+
+	userID := uuid.New()
+	token := srv.token(userID)
+	list := func(query string) []domain.URLEntry {
+		resp, body := srv.do(token, http.MethodGet, "/api/user/urls?"+query, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		var entries []domain.URLEntry
+		require.NoError(t, json.Unmarshal([]byte(body), &entries))
+		return entries
+	}
+	create := func(raw string) domain.HashKey {
+		return utils.Must(service.CreateShort(context.Background(), *utils.Must(url.Parse(raw)), domain.Owner{UserID: userID}))
+	}
+
+	spring := create("https://example.com/sale/spring")
+	docs := create("https://docs.example.org/pricing")
+	create("https://example.net/")
+
+	resp, body := srv.do(token, http.MethodPut, "/api/user/urls/"+spring+"/meta", `{"title": " Spring sale ", "note": "Banner in the April newsletter", "tags": ["Promo", "mail"]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	var link domain.Link
+	require.NoError(t, json.Unmarshal([]byte(body), &link))
+	require.Equal(t, "Spring sale", link.Title)
+	require.Equal(t, []string{"promo", "mail"}, link.Tags)
+
+	resp, body = srv.do(token, http.MethodPut, "/api/user/urls/"+docs+"/meta", `{"title": "Pricing docs", "tags": ["docs", "promo"]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+
+	t.Run("list includes meta", func(t *testing.T) {
+		entries := list("order=asc")
+		require.Len(t, entries, 3)
+		require.Equal(t, "Spring sale", entries[0].Title)
+		require.Equal(t, "Banner in the April newsletter", entries[0].Note)
+		require.Equal(t, []string{"docs", "promo"}, entries[1].Tags)
+		require.Empty(t, entries[2].Tags)
+	})
+
+	t.Run("tag filter", func(t *testing.T) {
+		require.Len(t, list("tag=promo"), 2)
+		entries := list("tag=promo&tag=Mail")
+		require.Len(t, entries, 1)
+		require.Equal(t, "Spring sale", entries[0].Title)
+		require.Empty(t, list("tag=unknown"))
+	})
+
+	t.Run("full-text search", func(t *testing.T) {
+		entries := list("text=" + url.QueryEscape("april NEWSLETTER"))
+		require.Len(t, entries, 1)
+		require.Equal(t, "Spring sale", entries[0].Title)
+
+		entries = list("text=pricing")
+		require.Len(t, entries, 1)
+		require.Equal(t, "Pricing docs", entries[0].Title)
+
+		require.Len(t, list("text=example"), 3, "words of the original url")
+		require.Len(t, list("text=example&tag=docs"), 1)
+		require.Empty(t, list("text="+url.QueryEscape("spring autumn")))
+	})
+
+	t.Run("rename tag", func(t *testing.T) {
+		resp, body := srv.do(token, http.MethodPost, "/api/user/tags/rename", `{"from": "promo", "to": "campaign"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		require.JSONEq(t, `{"updated": 2}`, body)
+		require.Empty(t, list("tag=promo"))
+		require.Len(t, list("tag=campaign"), 2)
+
+		// каждая изменённая ссылка попадает в журнал
+		events, err := auditRepo.Query(context.Background(), domain.AuditFilter{Action: domain.AuditLinkUpdate, Key: docs, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, []string{"docs", "promo"}, events[0].Before.Tags)
+		require.Equal(t, []string{"docs", "campaign"}, events[0].After.Tags)
+
+		// тег, который уже есть у ссылки, объединяется с переименованным
+		resp, body = srv.do(token, http.MethodPost, "/api/user/tags/rename", `{"from": "mail", "to": "campaign"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		require.JSONEq(t, `{"updated": 1}`, body)
+		entries := list("tag=campaign&order=asc")
+		require.Equal(t, []string{"campaign"}, entries[0].Tags)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		resp, body := srv.do(token, http.MethodPost, "/api/user/tags/rename", `{"from": "docs", "to": "DOCS"}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		require.Contains(t, body, `"to"`)
+
+		resp, _ = srv.do(token, http.MethodPut, "/api/user/urls/"+spring+"/meta", `{"tags": ["`+strings.Repeat("x", domain.MaxTagLength+1)+`"]}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("only owner", func(t *testing.T) {
+		resp, _ := srv.do(srv.token(uuid.New()), http.MethodPut, "/api/user/urls/"+spring+"/meta", `{"title": "x"}`)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
@@ -3,10 +3,10 @@ package handlers
 import (
 	"compress/gzip"
 	"fmt"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/sashaaro/url-shortener/internal"
 	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
 	"github.com/sashaaro/url-shortener/internal/utils"
 	"go.uber.org/zap"
 	"io"
@@ -17,12 +17,6 @@ import (
 	"time"
 )
 
-// Claims - payload jwt токена
-type Claims struct {
-	jwt.RegisteredClaims
-	UserID uuid.UUID `json:"user_id,omitempty"`
-}
-
 // WithLogging - добавление лога запроса
 func WithLogging(logger zap.SugaredLogger, h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -44,6 +38,7 @@ func WithLogging(logger zap.SugaredLogger, h http.HandlerFunc) http.HandlerFunc
 		logger.Infoln(
 			"uri", r.RequestURI,
 			"method", r.Method,
+			"ip", adapters.ClientIPFromCtx(r.Context()),
 			"status", responseData.status,
 			"duration", duration,
 			"size", responseData.size,
@@ -77,15 +72,21 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.responseData.status = statusCode // захватываем код статуса
 }
 
+// Unwrap исходный ResponseWriter для http.ResponseController
+func (r *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 func gzipHandle(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if strings.Contains(r.Header.Get("Content-Encoding"), "gzip") {
 			uncompressed, err := newCompressReader(r.Body)
 			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
+				writeProblem(w, domain.CodeInvalidRequest, "invalid gzip body")
 				return
 			}
 			r.Body = uncompressed
+			r.Header.Del("Content-Encoding")
 			//nolint:errcheck
 			defer uncompressed.Close()
 		}
@@ -119,6 +120,21 @@ func (w gzipWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
 
+// Flush отправка сжатых данных клиенту для потоковых ответов
+func (w gzipWriter) Flush() {
+	if gz, ok := w.Writer.(*gzip.Writer); ok {
+		_ = gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap исходный ResponseWriter для http.ResponseController
+func (w gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 type compressReader struct {
 	r  io.ReadCloser
 	zr *gzip.Reader
@@ -149,122 +165,193 @@ func (c *compressReader) Close() error {
 	return c.zr.Close()
 }
 
-// JwtTTL - время жизни токена по умолчанию
-const JwtTTL = 15 * time.Minute
-
-// BuildJWTString - создание токена
-func BuildJWTString(secretKey string, userID uuid.UUID) (string, error) {
-	// создаём новый токен с алгоритмом подписи HS256 и утверждениями — Claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			// когда создан токен
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(JwtTTL)),
-		},
-		// собственное утверждение
-		UserID: userID,
-	})
-
-	// создаём строку токена
-	tokenString, err := token.SignedString([]byte(secretKey))
-	if err != nil {
-		return "", err
-	}
-
-	// возвращаем строку токена
-	return tokenString, nil
-}
-
-func fetchUserIDFromToken(secretKey string, tokenStr string) (uuid.UUID, error) {
-	claims := &Claims{}
-	_, err := jwt.ParseWithClaims(tokenStr, claims,
-		func(t *jwt.Token) (interface{}, error) {
-			return []byte(secretKey), nil
-		})
-	if err != nil {
-		return uuid.Nil, err
-	}
-
-	return claims.UserID, nil
-}
+var xAPIKey = http.CanonicalHeaderKey("X-API-Key")
 
-// WithAuth провекра jwt и добавление пользователя в context
-func WithAuth(authRequired bool, h http.HandlerFunc) http.HandlerFunc {
+// WithAuth провекра jwt или api ключа и добавление пользователя в context.
+// Если access токена нет или он истёк, пользователь восстанавливается по refresh токену из cookie.
+func WithAuth(auth *adapters.Authenticator, authRequired bool, h http.HandlerFunc) http.HandlerFunc {
 	hostname := utils.Must(url.Parse(internal.Config.BaseURL)).Hostname()
 	return func(w http.ResponseWriter, r *http.Request) {
-		authCookie, _ := r.Cookie("access_token")
-
-		var userID uuid.UUID
-		var accessToken string
-
-		if authCookie != nil && authCookie.Value != "" {
-			accessToken = authCookie.Value
-		}
-
-		authHeader := r.Header.Get("Authorization")
-		if accessToken == "" && authHeader != "" {
-			accessToken = strings.TrimPrefix(authHeader, "Bearer ")
-		}
+		var identity adapters.Identity
+		accessToken := accessTokenFromReq(r)
 
 		if accessToken != "" {
 			var err error
-			userID, err = fetchUserIDFromToken(internal.Config.JwtSecret, accessToken)
+			identity, err = auth.Authenticate(r.Context(), accessToken)
 			if err != nil {
-				w.WriteHeader(http.StatusUnauthorized)
-				_, _ = w.Write([]byte("invalid access token"))
-				return
+				userID, refreshErr := refreshFromCookie(auth, r)
+				if refreshErr != nil {
+					writeProblem(w, domain.CodeUnauthorized, "invalid access token")
+					return
+				}
+				identity = adapters.Identity{UserID: userID, Method: adapters.AuthMethodJWT}
+				accessToken = ""
 			}
+		} else if userID, err := refreshFromCookie(auth, r); err == nil {
+			identity = adapters.Identity{UserID: userID, Method: adapters.AuthMethodJWT}
 		}
-		if userID == uuid.Nil {
+
+		if identity.UserID == uuid.Nil {
 			if authRequired {
-				w.WriteHeader(http.StatusUnauthorized)
-				_, _ = w.Write([]byte("Unauthorized"))
+				writeProblem(w, domain.CodeUnauthorized, "access token required")
 				return
 			}
-			userID = uuid.New()
+			identity = adapters.Identity{UserID: uuid.New(), Method: adapters.AuthMethodAnonymous}
 		}
 
-		if accessToken == "" {
-			var err error
-			accessToken, err = BuildJWTString(internal.Config.JwtSecret, userID)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
+		// api ключ не должен попадать в cookie и заголовки ответа
+		if identity.Method != adapters.AuthMethodAPIKey {
+			if accessToken == "" {
+				var refreshToken string
+				var err error
+				accessToken, refreshToken, err = buildTokens(auth, identity.UserID)
+				if err != nil {
+					writeInternalError(w)
+					return
+				}
+				setRefreshCookie(w, hostname, refreshToken, auth.RefreshTTL())
 			}
+			setAccessToken(w, hostname, accessToken)
 		}
 
-		w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-		http.SetCookie(w, &http.Cookie{
-			Name:     "access_token",
-			Value:    accessToken,
-			Path:     "/",
-			Domain:   hostname,
-			Expires:  time.Now().Add(JwtTTL),
-			Secure:   true,
-			HttpOnly: true,
-		})
+		h.ServeHTTP(w, r.WithContext(adapters.IdentityToCtx(r.Context(), identity)))
+	}
+}
 
-		h.ServeHTTP(w, r.WithContext(adapters.UserIDToCxt(r.Context(), userID)))
+// WithOptionalAuth пользователь из access токена, api ключа или refresh cookie запроса без выпуска новых токенов.
+// Ответ не получает cookie и заголовков авторизации и может кешироваться, запрос без действующих токенов
+// обрабатывается без пользователя.
+func WithOptionalAuth(auth *adapters.Authenticator, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var identity adapters.Identity
+		if accessToken := accessTokenFromReq(r); accessToken != "" {
+			identity, _ = auth.Authenticate(r.Context(), accessToken)
+		}
+		if identity.UserID == uuid.Nil {
+			if userID, err := refreshFromCookie(auth, r); err == nil {
+				identity = adapters.Identity{UserID: userID, Method: adapters.AuthMethodJWT}
+			}
+		}
+		if identity.UserID != uuid.Nil {
+			r = r.WithContext(adapters.IdentityToCtx(r.Context(), identity))
+		}
+		h.ServeHTTP(w, r)
+	}
+}
+
+const (
+	accessTokenCookie  = "access_token"
+	refreshTokenCookie = "refresh_token"
+)
+
+func accessTokenFromReq(r *http.Request) string {
+	if apiKey := r.Header.Get(xAPIKey); apiKey != "" {
+		return apiKey
+	}
+	if authCookie, _ := r.Cookie(accessTokenCookie); authCookie != nil && authCookie.Value != "" {
+		return authCookie.Value
+	}
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+func refreshFromCookie(auth *adapters.Authenticator, r *http.Request) (uuid.UUID, error) {
+	cookie, err := r.Cookie(refreshTokenCookie)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return auth.Refresh(r.Context(), cookie.Value)
+}
+
+func buildTokens(auth *adapters.Authenticator, userID uuid.UUID) (string, string, error) {
+	accessToken, err := auth.BuildJWTString(userID)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err := auth.BuildRefreshString(userID)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+func setAccessToken(w http.ResponseWriter, hostname string, accessToken string) {
+	w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	http.SetCookie(w, &http.Cookie{
+		Name:     accessTokenCookie,
+		Value:    accessToken,
+		Path:     "/",
+		Domain:   hostname,
+		Expires:  time.Now().Add(adapters.JwtTTL),
+		Secure:   true,
+		HttpOnly: true,
+	})
+}
+
+func setRefreshCookie(w http.ResponseWriter, hostname string, refreshToken string, ttl time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Value:    refreshToken,
+		Path:     "/",
+		Domain:   hostname,
+		Expires:  time.Now().Add(ttl),
+		Secure:   true,
+		HttpOnly: true,
+	})
+}
+
+// RequireScope проверка прав api ключа, для пользователей с jwt ограничений нет
+func RequireScope(scope string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, _ := adapters.IdentityFromCtx(r.Context())
+		if !identity.HasScope(scope) {
+			writeProblem(w, domain.CodeInsufficientScope, "api key has no "+scope+" scope")
+			return
+		}
+		h.ServeHTTP(w, r)
 	}
 }
 
 var xRealIP = http.CanonicalHeaderKey("X-Real-IP")
 
+var xRequestID = http.CanonicalHeaderKey("X-Request-Id")
+
+// RequestIDMiddleware идентификатор запроса из X-Request-Id или новый, добавляется в context и ответ
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := adapters.RequestIDOrNew(r.Header.Get(xRequestID))
+		w.Header().Set(xRequestID, requestID)
+		next.ServeHTTP(w, r.WithContext(adapters.RequestIDToCtx(r.Context(), requestID)))
+	})
+}
+
+// ClientIPMiddleware определение ip клиента с учётом доверенных прокси и добавление его в context
+func ClientIPMiddleware(resolver *adapters.ClientIPResolver) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolver.Resolve(r.RemoteAddr, r.Header)
+			next.ServeHTTP(w, r.WithContext(adapters.ClientIPToCtx(r.Context(), ip)))
+		})
+	}
+}
+
 // TrustedClientMiddleware проверка c TRUSTED_SUBNET
-func TrustedClientMiddleware(logger zap.SugaredLogger, trustedSubnet *net.IPNet) func(next http.HandlerFunc) http.HandlerFunc {
+func TrustedClientMiddleware(logger zap.SugaredLogger, trustedSubnets []*net.IPNet) func(next http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(writer http.ResponseWriter, request *http.Request) {
-			if trustedSubnet == nil {
+			if len(trustedSubnets) == 0 {
 				next.ServeHTTP(writer, request)
 				return
 			}
-			realIP := request.Header.Get(xRealIP)
-			logger.Info("request from client")
-			if realIP == "" || !trustedSubnet.Contains(net.ParseIP(realIP)) {
-				logger.Warn("access denied")
-				http.Error(writer, "Access denied", http.StatusForbidden)
-			} else {
-				next.ServeHTTP(writer, request)
+			clientIP := adapters.ClientIPFromCtx(request.Context())
+			logger.Infow("request from client", "ip", clientIP)
+			for _, subnet := range trustedSubnets {
+				if clientIP != nil && subnet.Contains(clientIP) {
+					next.ServeHTTP(writer, request)
+					return
+				}
 			}
+			logger.Warnw("access denied", "ip", clientIP)
+			writeProblem(writer, domain.CodeForbidden, "access denied")
 		}
 	}
 }
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+const oidcStateCookie = "oidc_state"
+
+const oidcCookiePath = "/api/auth/oidc"
+
+// WithOIDCProvider - вход через OpenID Connect провайдер
+func WithOIDCProvider(provider *adapters.OIDCProvider) ServeMuxOption {
+	return func(h *HTTPHandlers) {
+		h.oidc = provider
+	}
+}
+
+// oidcLogin перенаправление на провайдер, состояние входа сохраняется в подписанной cookie
+func (r *HTTPHandlers) oidcLogin(w http.ResponseWriter, request *http.Request) {
+	redirect := safeRedirect(request.URL.Query().Get("redirect"))
+	claim := request.URL.Query().Get("claim_links") == "true"
+
+	authURL, stateToken, err := r.oidc.AuthCodeURL(redirect, claim)
+	if err != nil {
+		r.logger.Error("cannot start oidc login", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    stateToken,
+		Path:     oidcCookiePath,
+		MaxAge:   int(adapters.OIDCStateTTL.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		// cookie должна прийти при возврате от провайдера
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, request, authURL, http.StatusFound)
+}
+
+// oidcCallback возврат от провайдера: обмен кода, вход пользователя и выдача наших токенов
+func (r *HTTPHandlers) oidcCallback(w http.ResponseWriter, request *http.Request) {
+	q := request.URL.Query()
+	if errCode := q.Get("error"); errCode != "" {
+		writeProblem(w, domain.CodeUnauthorized, "oidc login failed: "+errCode+" "+q.Get("error_description"))
+		return
+	}
+	cookie, err := request.Cookie(oidcStateCookie)
+	if err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, adapters.ErrInvalidOIDCState.Error())
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     oidcCookiePath,
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+	})
+
+	login, err := r.oidc.Exchange(request.Context(), cookie.Value, q.Get("state"), q.Get("code"))
+	if errors.Is(err, adapters.ErrInvalidOIDCState) {
+		writeProblem(w, domain.CodeInvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		r.logger.Info("oidc login failed", zap.Error(err))
+		writeProblem(w, domain.CodeUnauthorized, "oidc login failed")
+		return
+	}
+
+	user, err := r.users.LoginExternal(request.Context(), login.User)
+	if err != nil {
+		r.logger.Error("cannot login external user", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+
+	if _, err = r.claimAnonymous(request, user.ID, login.ClaimLinks); err != nil {
+		r.logger.Error("cannot claim links", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	if _, err = r.setTokens(w, user.ID); err != nil {
+		r.logger.Error("cannot build tokens", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	http.Redirect(w, request, safeRedirect(login.Redirect), http.StatusFound)
+}
+
+// safeRedirect только относительные пути этого сервиса, иначе открытый редирект
+func safeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return "/"
+	}
+	return redirect
+}
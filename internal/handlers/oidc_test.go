@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sashaaro/url-shortener/internal"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// stubOIDCProvider - локальный OIDC провайдер: discovery, JWKS и token endpoint
+type stubOIDCProvider struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+	subject  string
+	email    string
+
+	mx    sync.Mutex
+	codes map[string]stubAuthRequest
+}
+
+type stubAuthRequest struct {
+	nonce     string
+	challenge string
+}
+
+func newStubOIDCProvider(t *testing.T, clientID string) *stubOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &stubOIDCProvider{key: key, clientID: clientID, subject: "subject-1", email: "sso@example.com", codes: map[string]stubAuthRequest{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "stub",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		p.mx.Lock()
+		req, ok := p.codes[r.PostForm.Get("code")]
+		delete(p.codes, r.PostForm.Get("code"))
+		p.mx.Unlock()
+
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != req.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "stub",
+			"token_type":   "Bearer",
+			"id_token":     p.idToken(t, req.nonce),
+		})
+	})
+	p.Server = httptest.NewServer(mux)
+	return p
+}
+
+// authorize имитация входа пользователя у провайдера, возвращает адрес callback с кодом
+func (p *stubOIDCProvider) authorize(t *testing.T, authURL string) string {
+	u := utils.Must(url.Parse(authURL))
+	q := u.Query()
+	require.Equal(t, p.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	require.Equal(t, p.clientID, q.Get("client_id"))
+	require.Equal(t, "S256", q.Get("code_challenge_method"))
+	require.Contains(t, q.Get("scope"), "openid")
+
+	code := "code-" + q.Get("state")[:8]
+	p.mx.Lock()
+	p.codes[code] = stubAuthRequest{nonce: q.Get("nonce"), challenge: q.Get("code_challenge")}
+	p.mx.Unlock()
+	return q.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+}
+
+func (p *stubOIDCProvider) idToken(t *testing.T, nonce string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.URL,
+		"sub":            p.subject,
+		"aud":            p.clientID,
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          p.email,
+		"email_verified": true,
+	})
+	token.Header["kid"] = "stub"
+	s, err := token.SignedString(p.key)
+	require.NoError(t, err)
+	return s
+}
+
+func TestOIDCLogin(t *testing.T) {
+	stub := newStubOIDCProvider(t, "shortener")
+	defer stub.Close()
+
+	logger := adapters.CreateLogger()
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	users := domain.NewUserService(adapters.NewMemUserRepository(), service)
+	keyring := adapters.NewKeyring(adapters.NewHMACKey(adapters.DefaultKeyID, []byte(internal.Config.JwtSecret)))
+	auth := adapters.NewAuthenticator(keyring, nil, 0)
+
+	provider, err := adapters.NewOIDCProvider(context.Background(), adapters.OIDCConfig{
+		Issuer:      stub.URL,
+		ClientID:    "shortener",
+		RedirectURL: "http://shortener.local/api/auth/oidc/callback",
+	}, keyring)
+	require.NoError(t, err)
+
+	testServer := httptest.NewServer(CreateServeMux(service, logger, nil,
+		WithAuthenticator(auth), WithUserService(users), WithOIDCProvider(provider)))
+	defer testServer.Close()
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	// последняя cookie с именем, как её применит браузер
+	cookie := func(resp *http.Response, name string) *http.Cookie {
+		var found *http.Cookie
+		for _, c := range resp.Cookies() {
+			if c.Name == name {
+				found = c
+			}
+		}
+		return found
+	}
+
+	// анонимный пользователь создаёт ссылку
+	resp, err := http.Post(testServer.URL, "text/plain", strings.NewReader("https://github.com"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	anonymousCookie := cookie(resp, accessTokenCookie)
+	require.NotNil(t, anonymousCookie)
+
+	// login - редирект к провайдеру, состояние в cookie
+	start := func(query string) (string, *http.Cookie) {
+		resp, err := client.Get(testServer.URL + "/api/auth/oidc/login" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		state := cookie(resp, oidcStateCookie)
+		require.NotNil(t, state)
+		return resp.Header.Get("Location"), state
+	}
+	callback := func(callbackURL string, cookies ...*http.Cookie) *http.Response {
+		u := utils.Must(url.Parse(callbackURL))
+		req := utils.Must(http.NewRequest(http.MethodGet, testServer.URL+u.Path+"?"+u.RawQuery, nil))
+		for _, c := range cookies {
+			req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	var userAccessToken string
+	t.Run("authorization code flow claims anonymous links", func(t *testing.T) {
+		authURL, state := start("?redirect=/dashboard&claim_links=true")
+		resp := callback(stub.authorize(t, authURL), state, anonymousCookie)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		require.Equal(t, "/dashboard", resp.Header.Get("Location"))
+
+		access := cookie(resp, accessTokenCookie)
+		require.NotNil(t, access)
+		userAccessToken = access.Value
+
+		identity, err := auth.Authenticate(context.Background(), userAccessToken)
+		require.NoError(t, err)
+		user, err := users.GetByID(context.Background(), identity.UserID)
+		require.NoError(t, err)
+		require.Equal(t, "sso@example.com", user.Email)
+
+		page, err := service.GetByUser(context.Background(), identity.UserID, domain.LinkQuery{})
+		require.NoError(t, err)
+		require.Len(t, page.URLs, 1)
+	})
+
+	t.Run("same subject maps to the same user", func(t *testing.T) {
+		authURL, state := start("?redirect=https://evil.example")
+		resp := callback(stub.authorize(t, authURL), state)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		require.Equal(t, "/", resp.Header.Get("Location"), "external redirects are not allowed")
+
+		first, err := auth.Authenticate(context.Background(), userAccessToken)
+		require.NoError(t, err)
+		second, err := auth.Authenticate(context.Background(), cookie(resp, accessTokenCookie).Value)
+		require.NoError(t, err)
+		require.Equal(t, first.UserID, second.UserID)
+	})
+
+	t.Run("unverified account with the same email is not linked", func(t *testing.T) {
+		ctx := context.Background()
+		// email регистрации по паролю не подтверждён, вход владельца email не даёт доступа к этой учётной записи
+		squatter, err := users.Signup(ctx, "victim@example.com", "password123")
+		require.NoError(t, err)
+		victim, err := users.LoginExternal(ctx, domain.ExternalUser{Issuer: stub.URL, Subject: "victim", Email: "victim@example.com", EmailVerified: true})
+		require.NoError(t, err)
+		require.NotEqual(t, squatter.ID, victim.ID)
+		require.Empty(t, victim.Email)
+
+		// подтверждённый email другого провайдера привязывается к тому же пользователю
+		sso, err := users.LoginExternal(ctx, domain.ExternalUser{Issuer: "https://other.example", Subject: "1", Email: "SSO@example.com", EmailVerified: true})
+		require.NoError(t, err)
+		identity, err := auth.Authenticate(ctx, userAccessToken)
+		require.NoError(t, err)
+		require.Equal(t, identity.UserID, sso.ID)
+	})
+
+	t.Run("state mismatch", func(t *testing.T) {
+		authURL, _ := start("")
+		_, otherState := start("")
+		resp := callback(stub.authorize(t, authURL), otherState)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = callback(stub.authorize(t, authURL))
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "no state cookie")
+	})
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		authURL, state := start("")
+		callbackURL := stub.authorize(t, authURL)
+		stub.mx.Lock()
+		for code := range stub.codes {
+			stub.codes[code] = stubAuthRequest{challenge: "other"}
+		}
+		stub.mx.Unlock()
+		resp := callback(callbackURL, state)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("id token for another client", func(t *testing.T) {
+		other, err := adapters.NewOIDCProvider(context.Background(), adapters.OIDCConfig{
+			Issuer:   stub.URL,
+			ClientID: "another",
+		}, keyring)
+		require.NoError(t, err)
+		_, err = other.VerifyIDToken(context.Background(), stub.idToken(t, "nonce"), "nonce")
+		require.Error(t, err)
+
+		_, err = provider.VerifyIDToken(context.Background(), stub.idToken(t, "nonce"), "other nonce")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,601 @@
+package handlers
+
+import (
+	"bytes"
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openAPIJSON - описание http api, по нему проверяются запросы
+//
+//go:embed openapi.json
+var openAPIJSON []byte
+
+// maxValidatedBodySize - максимальный размер json тела, которое проверяется по схеме
+const maxValidatedBodySize = 10 << 20
+
+// openAPIMethods - методы, операции которых описываются в документе
+var openAPIMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// openAPIDocument - часть OpenAPI 3 документа, которая нужна для проверки запросов
+type openAPIDocument struct {
+	Paths      map[string]*openAPIPathItem `json:"paths"`
+	Components struct {
+		Parameters map[string]*openAPIParameter `json:"parameters"`
+		Schemas    map[string]*openAPISchema    `json:"schemas"`
+	} `json:"components"`
+}
+
+// openAPIPathItem - операции одного пути
+type openAPIPathItem struct {
+	Get    *openAPIOperation `json:"get"`
+	Head   *openAPIOperation `json:"head"`
+	Post   *openAPIOperation `json:"post"`
+	Put    *openAPIOperation `json:"put"`
+	Patch  *openAPIOperation `json:"patch"`
+	Delete *openAPIOperation `json:"delete"`
+}
+
+// operation операция пути по методу, nil если метод не описан
+func (p *openAPIPathItem) operation(method string) *openAPIOperation {
+	switch method {
+	case http.MethodGet:
+		return p.Get
+	case http.MethodHead:
+		return p.Head
+	case http.MethodPost:
+		return p.Post
+	case http.MethodPut:
+		return p.Put
+	case http.MethodPatch:
+		return p.Patch
+	case http.MethodDelete:
+		return p.Delete
+	}
+	return nil
+}
+
+// openAPIOperation - параметры и тело операции
+type openAPIOperation struct {
+	OperationID string              `json:"operationId"`
+	Parameters  []*openAPIParameter `json:"parameters"`
+	RequestBody *struct {
+		Required bool `json:"required"`
+		Content  map[string]struct {
+			Schema *openAPISchema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+// openAPIParameter - параметр пути или строки запроса
+type openAPIParameter struct {
+	Ref      string         `json:"$ref"`
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+// openAPISchema - поддерживаемое подмножество JSON Schema
+type openAPISchema struct {
+	Ref                  string                       `json:"$ref"`
+	Type                 string                       `json:"type"`
+	Format               string                       `json:"format"`
+	Enum                 []any                        `json:"enum"`
+	Nullable             bool                         `json:"nullable"`
+	Required             []string                     `json:"required"`
+	Properties           map[string]*openAPISchema    `json:"properties"`
+	AdditionalProperties *openAPIAdditionalProperties `json:"additionalProperties"`
+	MinProperties        *int                         `json:"minProperties"`
+	MaxProperties        *int                         `json:"maxProperties"`
+	Items                *openAPISchema               `json:"items"`
+	AllOf                []*openAPISchema             `json:"allOf"`
+	Minimum              *float64                     `json:"minimum"`
+	Maximum              *float64                     `json:"maximum"`
+	MinLength            *int                         `json:"minLength"`
+	MaxLength            *int                         `json:"maxLength"`
+	Pattern              string                       `json:"pattern"`
+	MinItems             *int                         `json:"minItems"`
+	MaxItems             *int                         `json:"maxItems"`
+
+	pattern *regexp.Regexp
+}
+
+// openAPIKeywords - ключевые слова схемы, которые проверяет валидатор, и аннотации, которые на проверку не влияют
+var openAPIKeywords = []string{
+	"$ref", "type", "format", "enum", "nullable", "required", "properties", "additionalProperties", "minProperties",
+	"maxProperties", "items", "allOf", "minimum", "maximum", "minLength", "maxLength", "pattern", "minItems", "maxItems",
+	"title", "description", "default", "example", "deprecated", "readOnly", "writeOnly",
+}
+
+// UnmarshalJSON разбор схемы, ключевое слово, которое валидатор не поддерживает, - ошибка,
+// чтобы схема в документе не обещала проверку, которой нет
+func (s *openAPISchema) UnmarshalJSON(b []byte) error {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(b, &keywords); err != nil {
+		return err
+	}
+	for keyword := range keywords {
+		if !slices.Contains(openAPIKeywords, keyword) {
+			return fmt.Errorf("unsupported schema keyword %q", keyword)
+		}
+	}
+
+	type schema openAPISchema
+	if err := json.Unmarshal(b, (*schema)(s)); err != nil {
+		return err
+	}
+	if s.Pattern != "" {
+		var err error
+		if s.pattern, err = regexp.Compile(s.Pattern); err != nil {
+			return fmt.Errorf("invalid schema pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// openAPIAdditionalProperties - additionalProperties схемы объекта: false или схема свойств, которых нет в properties
+type openAPIAdditionalProperties struct {
+	Forbidden bool
+	Schema    *openAPISchema
+}
+
+// UnmarshalJSON разбор булева значения или схемы
+func (a *openAPIAdditionalProperties) UnmarshalJSON(b []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(b, &allowed); err == nil {
+		a.Forbidden = !allowed
+		return nil
+	}
+	return json.Unmarshal(b, &a.Schema)
+}
+
+// parseOpenAPI разбор документа с проверкой, что все ссылки на компоненты существуют
+func parseOpenAPI(b []byte) (*openAPIDocument, error) {
+	doc := &openAPIDocument{}
+	if err := json.Unmarshal(b, doc); err != nil {
+		return nil, err
+	}
+	var errs []error
+	for path, item := range doc.Paths {
+		for _, method := range openAPIMethods {
+			op := item.operation(method)
+			if op == nil {
+				continue
+			}
+			for i, param := range op.Parameters {
+				if param.Ref != "" {
+					resolved, ok := doc.Components.Parameters[strings.TrimPrefix(param.Ref, "#/components/parameters/")]
+					if !ok {
+						errs = append(errs, fmt.Errorf("%s %s: unknown parameter %s", method, path, param.Ref))
+						continue
+					}
+					op.Parameters[i] = resolved
+				}
+			}
+		}
+	}
+	for name, schema := range doc.Components.Schemas {
+		errs = append(errs, doc.checkRefs("#/components/schemas/"+name, schema))
+	}
+	for path, item := range doc.Paths {
+		for _, method := range openAPIMethods {
+			if op := item.operation(method); op != nil {
+				for _, param := range op.Parameters {
+					errs = append(errs, doc.checkRefs(method+" "+path, param.Schema))
+				}
+				if op.RequestBody != nil {
+					for _, content := range op.RequestBody.Content {
+						errs = append(errs, doc.checkRefs(method+" "+path, content.Schema))
+					}
+				}
+			}
+		}
+	}
+	return doc, errors.Join(errs...)
+}
+
+// checkRefs проверка ссылок схемы на компоненты
+func (d *openAPIDocument) checkRefs(where string, s *openAPISchema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if d.resolve(s) == nil {
+			return fmt.Errorf("%s: unknown schema %s", where, s.Ref)
+		}
+		return nil
+	}
+	var errs []error
+	for _, p := range s.Properties {
+		errs = append(errs, d.checkRefs(where, p))
+	}
+	for _, p := range s.AllOf {
+		errs = append(errs, d.checkRefs(where, p))
+	}
+	if s.AdditionalProperties != nil {
+		errs = append(errs, d.checkRefs(where, s.AdditionalProperties.Schema))
+	}
+	errs = append(errs, d.checkRefs(where, s.Items))
+	return errors.Join(errs...)
+}
+
+// resolve схема по ссылке $ref или сама схема
+func (d *openAPIDocument) resolve(s *openAPISchema) *openAPISchema {
+	if s == nil || s.Ref == "" {
+		return s
+	}
+	return d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+}
+
+// find путь документа и его параметры для пути запроса.
+// Шаблон {name} совпадает с одним сегментом, при нескольких совпадениях выбирается путь с большим числом постоянных сегментов.
+func (d *openAPIDocument) find(path string) (*openAPIPathItem, map[string]string) {
+	segments := strings.Split(path, "/")
+	var found *openAPIPathItem
+	var params map[string]string
+	best := -1
+	for template, item := range d.Paths {
+		parts := strings.Split(template, "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		literal := 0
+		matched := map[string]string{}
+		for i, part := range parts {
+			if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+				if segments[i] == "" {
+					literal = -1
+					break
+				}
+				matched[part[1:len(part)-1]] = segments[i]
+				continue
+			}
+			if part != segments[i] {
+				literal = -1
+				break
+			}
+			literal++
+		}
+		if literal > best {
+			found, params, best = item, matched, literal
+		}
+	}
+	return found, params
+}
+
+// ValidateRequest - проверка параметров и json тела запроса по OpenAPI документу, некорректные запросы получают 400.
+// Запросы к путям и методам, которых нет в документе, пропускаются без проверки.
+// Проверка ставится в маршрут после авторизации и ограничения частоты запросов, чтобы тело читалось только у допущенных запросов.
+func ValidateRequest() func(next http.HandlerFunc) http.HandlerFunc {
+	doc, err := parseOpenAPI(openAPIJSON)
+	if err != nil {
+		panic(fmt.Errorf("invalid openapi.json: %w", err))
+	}
+	return doc.middleware
+}
+
+func (d *openAPIDocument) middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		item, pathParams := d.find(r.URL.Path)
+		if item == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		op := item.operation(r.Method)
+		if op == nil && r.Method == http.MethodHead {
+			op = item.Get
+		}
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := d.validateParameters(op, r, pathParams); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		code, err := d.validateBody(op, r)
+		if code == domain.CodeValidationFailed {
+			writeServiceError(w, err)
+			return
+		}
+		if err != nil {
+			writeProblem(w, code, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// validateParameters проверка параметров пути и строки запроса,
+// массив в строке запроса передаётся повтором параметра (style form, explode)
+func (d *openAPIDocument) validateParameters(op *openAPIOperation, r *http.Request, pathParams map[string]string) error {
+	query := r.URL.Query()
+	for _, param := range op.Parameters {
+		var raw []string
+		switch param.In {
+		case "path":
+			if v, ok := pathParams[param.Name]; ok {
+				raw = []string{v}
+			}
+		case "query":
+			raw = query[param.Name]
+		default:
+			continue
+		}
+		if raw == nil {
+			if param.Required {
+				return &domain.ValidationError{Field: param.Name, Message: "is required"}
+			}
+			continue
+		}
+		schema := d.resolve(param.Schema)
+		var value any
+		var err error
+		if schema != nil && schema.Type == "array" {
+			items := make([]any, 0, len(raw))
+			for _, v := range raw {
+				var item any
+				if item, err = parameterValue(v, d.resolve(schema.Items)); err != nil {
+					break
+				}
+				items = append(items, item)
+			}
+			value = items
+		} else {
+			value, err = parameterValue(raw[0], schema)
+		}
+		if err != nil {
+			return &domain.ValidationError{Field: param.Name, Message: err.Error()}
+		}
+		if err = d.validate(value, schema, param.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parameterValue значение параметра в типе его схемы
+func parameterValue(raw string, schema *openAPISchema) (any, error) {
+	if schema == nil {
+		return raw, nil
+	}
+	switch schema.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("must be %s", schema.Type)
+		}
+		return json.Number(raw), nil
+	case "boolean":
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("must be boolean")
+		}
+		return v, nil
+	}
+	return raw, nil
+}
+
+// validateBody проверка json тела запроса, тело заменяется прочитанным и распакованным.
+// Несоответствие схеме возвращается как domain.ValidationError с кодом CodeValidationFailed.
+func (d *openAPIDocument) validateBody(op *openAPIOperation, r *http.Request) (domain.ErrorCode, error) {
+	if op.RequestBody == nil {
+		return "", nil
+	}
+	content, ok := op.RequestBody.Content["application/json"]
+	if !ok {
+		return "", nil
+	}
+	// тела другого описанного типа, например потоковый импорт, не читаются
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "" && mediaType != "application/json" {
+		if _, ok := op.RequestBody.Content[mediaType]; ok {
+			return "", nil
+		}
+	}
+
+	body := io.Reader(r.Body)
+	if strings.Contains(r.Header.Get("Content-Encoding"), "gzip") {
+		uncompressed, err := newCompressReader(r.Body)
+		if err != nil {
+			return domain.CodeInvalidRequest, errors.New("invalid gzip body")
+		}
+		//nolint:errcheck
+		defer uncompressed.Close()
+		body = uncompressed
+	}
+	b, err := io.ReadAll(io.LimitReader(body, maxValidatedBodySize+1))
+	if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+		return domain.CodePayloadTooLarge, errors.New("request body is too large")
+	}
+	if err != nil {
+		return domain.CodeInvalidRequest, errors.New("cannot read request body")
+	}
+	if len(b) > maxValidatedBodySize {
+		return domain.CodePayloadTooLarge, errors.New("request body is too large")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	r.ContentLength = int64(len(b))
+	r.Header.Del("Content-Encoding")
+
+	if len(bytes.TrimSpace(b)) == 0 {
+		if op.RequestBody.Required {
+			return domain.CodeInvalidRequest, errors.New("request body is required")
+		}
+		return "", nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var value any
+	if err = decoder.Decode(&value); err != nil {
+		return domain.CodeInvalidRequest, fmt.Errorf("invalid json body: %w", err)
+	}
+	if decoder.More() {
+		return domain.CodeInvalidRequest, errors.New("invalid json body: unexpected data after json value")
+	}
+	if err = d.validate(value, d.resolve(content.Schema), ""); err != nil {
+		return domain.CodeValidationFailed, err
+	}
+	return "", nil
+}
+
+// validate проверка значения по схеме, path - путь до значения, пустой для корня тела
+func (d *openAPIDocument) validate(value any, s *openAPISchema, path string) error {
+	s = d.resolve(s)
+	if s == nil {
+		return nil
+	}
+	fail := func(format string, args ...any) error {
+		return &domain.ValidationError{Field: cmp.Or(path, "body"), Message: fmt.Sprintf(format, args...)}
+	}
+	if value == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return fail("must not be null")
+	}
+	for _, sub := range s.AllOf {
+		if err := d.validate(value, sub, path); err != nil {
+			return err
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fail("must be object")
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fail("must have property %s", name)
+			}
+		}
+		if s.MinProperties != nil && len(obj) < *s.MinProperties {
+			return fail("must have at least %d properties", *s.MinProperties)
+		}
+		if s.MaxProperties != nil && len(obj) > *s.MaxProperties {
+			return fail("must have at most %d properties", *s.MaxProperties)
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok && s.AdditionalProperties != nil {
+				if s.AdditionalProperties.Forbidden {
+					return fail("must not have property %s", name)
+				}
+				prop = s.AdditionalProperties.Schema
+			}
+			if prop != nil {
+				if err := d.validate(obj[name], prop, joinPath(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return fail("must be array")
+		}
+		if s.MinItems != nil && len(arr) < *s.MinItems {
+			return fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(arr) > *s.MaxItems {
+			return fail("must have at most %d items", *s.MaxItems)
+		}
+		for i, item := range arr {
+			if err := d.validate(item, s.Items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fail("must be string")
+		}
+		if s.MinLength != nil && len([]rune(str)) < *s.MinLength {
+			return fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && len([]rune(str)) > *s.MaxLength {
+			return fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			return fail("must match %s", s.Pattern)
+		}
+		if err := validateFormat(str, s.Format); err != nil {
+			return fail("%s", err)
+		}
+	case "integer", "number":
+		num, ok := value.(json.Number)
+		if !ok {
+			return fail("must be %s", s.Type)
+		}
+		f, err := num.Float64()
+		if err != nil || s.Type == "integer" && f != math.Trunc(f) {
+			return fail("must be %s", s.Type)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fail("must be at most %v", *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("must be boolean")
+		}
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(v any) bool { return fmt.Sprint(v) == fmt.Sprint(value) }) {
+		return fail("must be one of %v", s.Enum)
+	}
+	return nil
+}
+
+// validateFormat проверка формата строки
+func validateFormat(s string, format string) error {
+	switch format {
+	case "uuid":
+		if _, err := uuid.Parse(s); err != nil {
+			return errors.New("must be uuid")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return errors.New("must be RFC 3339 time")
+		}
+	}
+	return nil
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// openAPI описание http api
+func (r *HTTPHandlers) openAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(openAPIJSON)
+}
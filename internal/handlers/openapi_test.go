@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIMatchesRouter(t *testing.T) {
+	doc, err := parseOpenAPI(openAPIJSON)
+	require.NoError(t, err)
+
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	// необязательные маршруты регистрируются только с настроенными сервисами
+	withAll := func(h *HTTPHandlers) {
+		h.oidc = &adapters.OIDCProvider{}
+		h.workspaces = &domain.WorkspaceService{}
+		h.admin = &domain.AdminService{}
+		h.audit = &domain.AuditService{}
+		h.domains = &domain.DomainService{}
+	}
+	mux := CreateServeMux(service, adapters.CreateLogger(), nil, withAll)
+
+	var routes []string
+	err = chi.Walk(mux, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// профилировщик не входит в api
+		if !strings.HasPrefix(route, "/debug/") {
+			routes = append(routes, method+" "+route)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	var documented []string
+	for path, item := range doc.Paths {
+		for _, method := range openAPIMethods {
+			if item.operation(method) != nil {
+				documented = append(documented, method+" "+path)
+			}
+		}
+	}
+	sort.Strings(routes)
+	sort.Strings(documented)
+	require.Equal(t, routes, documented, "openapi.json must describe every route of the router")
+}
+
+func TestValidateRequest(t *testing.T) {
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	testServer := httptest.NewServer(CreateServeMux(service, adapters.CreateLogger(), nil))
+	defer testServer.Close()
+
+	authorization := ""
+	do := func(method string, target string, contentType string, body string) int {
+		req := utils.Must(http.NewRequest(method, testServer.URL+target, strings.NewReader(body)))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("spec is served", func(t *testing.T) {
+		resp, err := http.Get(testServer.URL + "/api/openapi.json")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var doc map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+		require.Equal(t, "3.0.3", doc["openapi"])
+	})
+
+	t.Run("auth is checked before the body", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/api/user/urls?limit=0", "", ``))
+		require.Equal(t, http.StatusUnauthorized, do(http.MethodPut, "/api/user/default-params", "application/json", `{"query": 1}`))
+	})
+
+	resp, err := http.Post(testServer.URL+"/api/shorten", "application/json", strings.NewReader(`{"url": "https://example.com/openapi/auth"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	authorization = resp.Header.Get("Authorization")
+	require.NotEmpty(t, authorization)
+
+	t.Run("bad requests", func(t *testing.T) {
+		for name, tc := range map[string][3]string{
+			"malformed json":        {http.MethodPost, "/api/shorten", `{"url":`},
+			"missing property":      {http.MethodPost, "/api/shorten", `{}`},
+			"wrong type":            {http.MethodPost, "/api/shorten", `{"url": 1}`},
+			"empty body":            {http.MethodPost, "/api/shorten", ``},
+			"batch is not an array": {http.MethodPost, "/api/shorten/batch", `{"original_url": "https://example.com"}`},
+			"batch item type":       {http.MethodPost, "/api/shorten/batch", `[{"correlation_id": 1, "original_url": "https://example.com"}]`},
+			"boolean query":         {http.MethodPost, "/api/shorten/batch?atomic=maybe", `[]`},
+			"uuid query":            {http.MethodPost, "/api/shorten?workspace_id=nope", `{"url": "https://example.com"}`},
+			"integer query":         {http.MethodGet, "/api/user/urls?limit=0", ``},
+			"enum query":            {http.MethodGet, "/api/user/urls/export?format=xml", ``},
+			"array query item":      {http.MethodGet, "/api/user/urls?tag=ok&tag=" + strings.Repeat("x", 65), ``},
+			"uuid path":             {http.MethodGet, "/api/shorten/import/nope", ``},
+			"additional property":   {http.MethodPut, "/api/user/default-params", `{"query": {"utm_source": 1}}`},
+			"too many properties":   {http.MethodPut, "/api/user/default-params", `{"query": {"` + strings.Join(strings.Split("abcdefghijklmnopqrstu", ""), `": "v", "`) + `": "v"}}`},
+		} {
+			require.Equal(t, http.StatusBadRequest, do(tc[0], tc[1], "application/json", tc[2]), name)
+		}
+	})
+
+	t.Run("valid requests reach handlers", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/shorten", "", `{"url": "https://example.com/openapi"}`))
+		require.Equal(t, http.StatusCreated, do(http.MethodPost, "/", "text/plain", `https://example.com/openapi/plain`))
+		require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/unknown-key", "", ``))
+
+		var buf bytes.Buffer
+		g := gzip.NewWriter(&buf)
+		_, err := g.Write([]byte(`{"url": "https://example.com/openapi/gzip"}`))
+		require.NoError(t, err)
+		require.NoError(t, g.Close())
+		req := utils.Must(http.NewRequest(http.MethodPost, testServer.URL+"/api/shorten", &buf))
+		req.Header.Set("Content-Encoding", "gzip")
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+}
+
+func TestOpenAPISchemaKeywords(t *testing.T) {
+	parse := func(schema string) (*openAPIDocument, error) {
+		return parseOpenAPI([]byte(`{"paths": {}, "components": {"schemas": {"S": ` + schema + `}}}`))
+	}
+
+	t.Run("unsupported keyword", func(t *testing.T) {
+		_, err := parse(`{"type": "object", "properties": {"n": {"type": "integer", "multipleOf": 2}}}`)
+		require.ErrorContains(t, err, `unsupported schema keyword "multipleOf"`)
+		_, err = parse(`{"type": "string", "pattern": "("}`)
+		require.Error(t, err)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		doc, err := parse(`{
+			"type": "object",
+			"maxProperties": 2,
+			"properties": {"id": {"type": "string", "pattern": "^[a-z]+$", "description": "id"}},
+			"additionalProperties": {"type": "integer"}
+		}`)
+		require.NoError(t, err)
+		schema := &openAPISchema{Ref: "#/components/schemas/S"}
+		validate := func(body string) error {
+			var value any
+			decoder := json.NewDecoder(strings.NewReader(body))
+			decoder.UseNumber()
+			require.NoError(t, decoder.Decode(&value))
+			return doc.validate(value, schema, "")
+		}
+
+		require.NoError(t, validate(`{"id": "abc", "n": 1}`))
+		require.ErrorContains(t, validate(`{"id": "ABC"}`), "must match")
+		require.ErrorContains(t, validate(`{"n": "1"}`), "must be integer")
+		require.ErrorContains(t, validate(`{"id": "a", "n": 1, "m": 2}`), "at most 2 properties")
+
+		doc, err = parse(`{"type": "object", "properties": {"id": {"type": "string"}}, "additionalProperties": false}`)
+		require.NoError(t, err)
+		require.NoError(t, validate(`{"id": "a"}`))
+		require.ErrorContains(t, validate(`{"id": "a", "n": 1}`), "must not have property n")
+	})
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+func (r *HTTPHandlers) setLinkParams(w http.ResponseWriter, request *http.Request) {
+	var params domain.LinkParams
+	if err := json.NewDecoder(request.Body).Decode(&params); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	link, err := r.service.SetParams(request.Context(), chi.URLParam(request, "key"), adapters.MustUserIDFromReq(request), params)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot set link params", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, link)
+}
+
+// getDefaultParams параметры, которые получает каждая новая ссылка пользователя
+func (r *HTTPHandlers) getDefaultParams(w http.ResponseWriter, request *http.Request) {
+	params, err := r.service.DefaultParams(request.Context(), adapters.MustUserIDFromReq(request))
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot get default params", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, params)
+}
+
+func (r *HTTPHandlers) setDefaultParams(w http.ResponseWriter, request *http.Request) {
+	var params domain.LinkParams
+	if err := json.NewDecoder(request.Body).Decode(&params); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	params, err := r.service.SetDefaultParams(request.Context(), adapters.MustUserIDFromReq(request), params)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot set default params", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, params)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkParams(t *testing.T) {
+	auditRepo := adapters.NewMemAuditRepository()
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken,
+		domain.WithDefaultParamsRepository(adapters.NewMemDefaultParamsRepository()),
+		domain.WithAuditService(domain.NewAuditService(auditRepo, adapters.AuditActorFromCtx)))
+	srv := newTestServer(t, service, newTestAuthenticator(nil))
+
+	userID := uuid.New()
+	token := srv.token(userID)
+
+	key := utils.Must(service.CreateShort(context.Background(), *utils.Must(url.Parse("https://example.com/?utm_source=site")), domain.Owner{UserID: userID}))
+	// постоянный редирект кешируется, пока в параметрах нет данных посетителя
+	resp, _ := srv.do(token, http.MethodPut, "/api/user/urls/"+key+"/redirect", `{"redirect_type": 301}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	t.Run("link params", func(t *testing.T) {
+		resp, body := srv.do(token, http.MethodPut, "/api/user/urls/"+key+"/params", `{"query": {"utm_source": "{referrer_host}", "utm_campaign": "{key}"}}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		var link domain.Link
+		require.NoError(t, json.Unmarshal([]byte(body), &link))
+		require.Equal(t, map[string]string{"utm_source": "{referrer_host}", "utm_campaign": "{key}"}, link.Params.Query)
+
+		resp, _ = srv.do(token, http.MethodGet, "/"+key, "", "Referer", "https://news.example.org/post")
+		require.Equal(t, "https://example.com/?utm_source=news.example.org&utm_campaign="+key, resp.Header.Get("Location"))
+		require.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+		resp, _ = srv.do(token, http.MethodGet, "/"+key, "")
+		require.Equal(t, "https://example.com/?utm_source=site&utm_campaign="+key, resp.Header.Get("Location"))
+	})
+
+	t.Run("keep policy", func(t *testing.T) {
+		resp, body := srv.do(token, http.MethodPut, "/api/user/urls/"+key+"/params", `{"query": {"utm_source": "short", "utm_medium": "link"}, "merge": "keep"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		resp, _ = srv.do(token, http.MethodGet, "/"+key, "")
+		require.Equal(t, "https://example.com/?utm_source=site&utm_medium=link", resp.Header.Get("Location"))
+		require.Contains(t, resp.Header.Get("Cache-Control"), "max-age=")
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		resp, body := srv.do(token, http.MethodPut, "/api/user/urls/"+key+"/params", `{"query": {"utm_source": "{referer}"}}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, body, `"query.utm_source"`)
+	})
+
+	t.Run("only owner", func(t *testing.T) {
+		resp, _ := srv.do(srv.token(uuid.New()), http.MethodPut, "/api/user/urls/"+key+"/params", `{}`)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("defaults for new links", func(t *testing.T) {
+		resp, body := srv.do(token, http.MethodGet, "/api/user/default-params", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		require.JSONEq(t, `{}`, body)
+
+		resp, body = srv.do(token, http.MethodPut, "/api/user/default-params", `{"query": {"utm_source": "shortener"}, "merge": "keep"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+
+		resp, body = srv.do(token, http.MethodPost, "/api/shorten", `{"url": "https://example.org/new"}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, body)
+		var shorten ShortenResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &shorten))
+		newKey := shorten.Result[strings.LastIndex(shorten.Result, "/")+1:]
+		resp, _ = srv.do(token, http.MethodGet, "/"+newKey, "")
+		require.Equal(t, "https://example.org/new?utm_source=shortener", resp.Header.Get("Location"))
+
+		// параметры сохраняются вместе со ссылкой и попадают в журнал
+		events, err := auditRepo.Query(context.Background(), domain.AuditFilter{Action: domain.AuditLinkCreate, Key: newKey, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, domain.LinkParams{Query: map[string]string{"utm_source": "shortener"}, Merge: domain.MergeKeep}, events[0].After.Params)
+
+		// уже созданные ссылки не меняются
+		resp, _ = srv.do(token, http.MethodGet, "/"+key, "")
+		require.Equal(t, "https://example.com/?utm_source=site&utm_medium=link", resp.Header.Get("Location"))
+	})
+
+	t.Run("defaults unavailable", func(t *testing.T) {
+		service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+		resp, _ := newTestServer(t, service, srv.auth).do(token, http.MethodGet, "/api/user/default-params", "")
+		require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreview(t *testing.T) {
+	logger := adapters.CreateLogger()
+	policy := domain.DefaultURLPolicy()
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken, domain.WithURLPolicy(policy))
+	keyring := adapters.NewKeyring(adapters.NewHMACKey(adapters.DefaultKeyID, []byte(internal.Config.JwtSecret)))
+	auth := adapters.NewAuthenticator(keyring, nil, 0)
+
+	testServer := httptest.NewServer(CreateServeMux(service, logger, nil, WithAuthenticator(auth)))
+	defer testServer.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	userID := uuid.New()
+	token := utils.Must(auth.BuildJWTString(userID))
+	do := func(method string, target string, body string) (*http.Response, string) {
+		req := utils.Must(http.NewRequest(method, testServer.URL+target, strings.NewReader(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp, string(utils.Must(io.ReadAll(resp.Body)))
+	}
+	create := func(raw string) domain.HashKey {
+		return utils.Must(service.CreateShort(context.Background(), *utils.Must(url.Parse(raw)), domain.Owner{UserID: userID}))
+	}
+
+	key := create("https://example.com/preview")
+	resp, _ := do(http.MethodPut, "/api/user/urls/"+key+"/preview", `{"title": "Docs <b>", "description": "Project documentation"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	t.Run("suffix and query parameter", func(t *testing.T) {
+		for _, target := range []string{"/" + key + "+", "/" + key + "?preview=1"} {
+			resp, body := do(http.MethodGet, target, "")
+			require.Equal(t, http.StatusOK, resp.StatusCode, target)
+			require.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+			require.Contains(t, body, `href="https://example.com/preview"`)
+			require.Contains(t, body, "Docs &lt;b&gt;", "title is escaped")
+			require.Contains(t, body, "Project documentation")
+			require.NotContains(t, body, "Warning")
+		}
+	})
+
+	t.Run("preview parameter is not passed through", func(t *testing.T) {
+		resp, _ := do(http.MethodPut, "/api/user/urls/"+key+"/redirect", `{"pass_query": true}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		_, body := do(http.MethodGet, "/"+key+"?preview=1&ref=x", "")
+		require.Contains(t, body, `href="https://example.com/preview?ref=x"`)
+
+		// остальные параметры не перекодируются и не переставляются
+		_, body = do(http.MethodGet, "/"+key+"?z=1;2&preview=1&a=%7e&flag", "")
+		require.Contains(t, body, `href="https://example.com/preview?z=1;2&amp;a=%7e&amp;flag"`)
+	})
+
+	t.Run("forced interstitial", func(t *testing.T) {
+		resp, _ := do(http.MethodGet, "/"+key, "")
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+		resp, _ = do(http.MethodPut, "/api/user/urls/"+key+"/preview", `{"interstitial": true}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, body := do(http.MethodGet, "/"+key, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, `href="https://example.com/preview"`)
+	})
+
+	t.Run("flagged by policy", func(t *testing.T) {
+		flagged := create("https://flagged.example.org/page")
+		policy.DeniedHosts = append(policy.DeniedHosts, ".flagged.example.org")
+		resp, body := do(http.MethodGet, "/"+flagged, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, "Warning")
+		require.Contains(t, body, "host flagged.example.org is not allowed")
+	})
+
+	t.Run("too long title", func(t *testing.T) {
+		resp, _ := do(http.MethodPut, "/api/user/urls/"+key+"/preview", `{"title": "`+strings.Repeat("я", domain.MaxPreviewTitleLength+1)+`"}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestLoadTemplates(t *testing.T) {
+	embedded, err := LoadTemplates("")
+	require.NoError(t, err)
+	require.NotNil(t, embedded.Lookup(previewTemplate))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, previewTemplate), []byte(`custom {{.Destination}}`), 0o600))
+	custom, err := LoadTemplates(dir)
+	require.NoError(t, err)
+	var b strings.Builder
+	require.NoError(t, custom.ExecuteTemplate(&b, previewTemplate, previewPage{Destination: "https://example.com"}))
+	require.Equal(t, "custom https://example.com", b.String())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, previewTemplate), []byte(`{{.Broken`), 0o600))
+	_, err = LoadTemplates(dir)
+	require.Error(t, err)
+}
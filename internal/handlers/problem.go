@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"net/http"
+)
+
+// problemContentType - тип ответа с ошибкой по RFC 7807
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix - префикс type ошибки, за ним следует её код
+const problemTypePrefix = "urn:url-shortener:problem:"
+
+// problemStatuses - http статусы кодов ошибок
+var problemStatuses = map[domain.ErrorCode]int{
+	domain.CodeInvalidRequest:        http.StatusBadRequest,
+	domain.CodeValidationFailed:      http.StatusBadRequest,
+	domain.CodeInvalidURL:            http.StatusBadRequest,
+	domain.CodePayloadTooLarge:       http.StatusRequestEntityTooLarge,
+	domain.CodeUnauthorized:          http.StatusUnauthorized,
+	domain.CodeInvalidCredentials:    http.StatusUnauthorized,
+	domain.CodeForbidden:             http.StatusForbidden,
+	domain.CodeInsufficientScope:     http.StatusForbidden,
+	domain.CodeAdminRequired:         http.StatusForbidden,
+	domain.CodeUserBanned:            http.StatusForbidden,
+	domain.CodeDomainNotAllowed:      http.StatusForbidden,
+	domain.CodeNotFound:              http.StatusNotFound,
+	domain.CodeMethodNotAllowed:      http.StatusMethodNotAllowed,
+	domain.CodeLinkNotFound:          http.StatusNotFound,
+	domain.CodeLinkDeleted:           http.StatusGone,
+	domain.CodeLinkExpired:           http.StatusGone,
+	domain.CodeLinkDisabled:          http.StatusForbidden,
+	domain.CodeURLAlreadyExists:      http.StatusConflict,
+	domain.CodeUserNotFound:          http.StatusNotFound,
+	domain.CodeUserAlreadyExists:     http.StatusConflict,
+	domain.CodeIdentityAlreadyLinked: http.StatusConflict,
+	domain.CodeNotAnonymous:          http.StatusConflict,
+	domain.CodeAPIKeyNotFound:        http.StatusNotFound,
+	domain.CodeWorkspaceNotFound:     http.StatusNotFound,
+	domain.CodeMemberNotFound:        http.StatusNotFound,
+	domain.CodeLastOwner:             http.StatusConflict,
+	domain.CodeImportJobNotFound:     http.StatusNotFound,
+	domain.CodeImportInterrupted:     http.StatusInternalServerError,
+	domain.CodeFeatureUnavailable:    http.StatusNotImplemented,
+	domain.CodeRateLimited:           http.StatusTooManyRequests,
+	domain.CodeInternal:              http.StatusInternalServerError,
+}
+
+// Problem - ответ с ошибкой в формате application/problem+json, клиенты различают ошибки по Code
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Code      domain.ErrorCode    `json:"code"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+// writeProblem ответ с ошибкой, статус определяется кодом
+func writeProblem(w http.ResponseWriter, code domain.ErrorCode, detail string, fields ...domain.FieldError) {
+	status, ok := problemStatuses[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(Problem{
+		Type:      problemTypePrefix + string(code),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Code:      code,
+		RequestID: w.Header().Get(xRequestID),
+		Errors:    fields,
+	})
+}
+
+// writeInternalError ответ на внутреннюю ошибку без её подробностей, ошибку логирует вызывающий
+func writeInternalError(w http.ResponseWriter) {
+	writeProblem(w, domain.CodeInternal, "internal server error")
+}
+
+// writeServiceError ответ на ошибку сервиса с кодом из domain.ErrorCodeOf, false если ошибки нет или она внутренняя
+func writeServiceError(w http.ResponseWriter, err error) bool {
+	code := domain.ErrorCodeOf(err)
+	if code == "" || code == domain.CodeInternal {
+		return false
+	}
+	writeProblem(w, code, err.Error(), domain.FieldErrors(err)...)
+	return true
+}
+
+// notFound ответ на запрос к неизвестному пути
+func notFound(w http.ResponseWriter, _ *http.Request) {
+	writeProblem(w, domain.CodeNotFound, "route not found")
+}
+
+// methodNotAllowed ответ на запрос с неподдерживаемым методом
+func methodNotAllowed(w http.ResponseWriter, _ *http.Request) {
+	writeProblem(w, domain.CodeMethodNotAllowed, "method not allowed")
+}
+
+// writeFieldError ответ на некорректное значение одного поля или параметра запроса
+func writeFieldError(w http.ResponseWriter, field string, message string) {
+	writeProblem(w, domain.CodeValidationFailed, field+": "+message, domain.FieldError{Field: field, Message: message})
+}
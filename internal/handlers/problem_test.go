@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemResponses(t *testing.T) {
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	testServer := httptest.NewServer(CreateServeMux(service, adapters.CreateLogger(), nil))
+	defer testServer.Close()
+
+	do := func(method string, target string, body string) (*http.Response, Problem) {
+		req := utils.Must(http.NewRequest(method, testServer.URL+target, strings.NewReader(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(xRequestID, "req-42")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var p Problem
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+		return resp, p
+	}
+
+	t.Run("validation failure", func(t *testing.T) {
+		resp, p := do(http.MethodPost, "/api/shorten", `{"url": 1}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Equal(t, problemContentType, resp.Header.Get("Content-Type"))
+		require.Equal(t, domain.CodeValidationFailed, p.Code)
+		require.Equal(t, problemTypePrefix+string(domain.CodeValidationFailed), p.Type)
+		require.Equal(t, http.StatusBadRequest, p.Status)
+		require.Equal(t, "req-42", p.RequestID)
+		require.Equal(t, []domain.FieldError{{Field: "url", Message: p.Errors[0].Message}}, p.Errors)
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		resp, p := do(http.MethodPost, "/api/shorten", `{"url": "not a url"}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Equal(t, domain.CodeInvalidURL, p.Code)
+	})
+
+	t.Run("unknown link", func(t *testing.T) {
+		resp, p := do(http.MethodGet, "/unknown-key", "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Equal(t, domain.CodeLinkNotFound, p.Code)
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		resp, p := do(http.MethodGet, "/api/nope/nope", "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Equal(t, domain.CodeNotFound, p.Code)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		resp, p := do(http.MethodGet, "/api/user/api-keys", "")
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		require.Equal(t, domain.CodeUnauthorized, p.Code)
+	})
+}
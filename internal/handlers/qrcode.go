@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// qrCacheControl - код ссылки не меняется, изображение можно хранить сколько угодно
+const qrCacheControl = "public, max-age=31536000, immutable"
+
+// qrOptionsFromQuery параметры qr кода из запроса, отсутствующие - по умолчанию
+func qrOptionsFromQuery(q url.Values) (domain.QROptions, error) {
+	opts := domain.DefaultQROptions()
+	if v := q.Get("format"); v != "" {
+		opts.Format = domain.QRFormat(strings.ToLower(v))
+	}
+	if v := q.Get("level"); v != "" {
+		opts.Level = domain.QRLevel(strings.ToUpper(v))
+	}
+	var err error
+	if opts.Size, err = intQueryParam(q, "size", opts.Size); err != nil {
+		return opts, err
+	}
+	if opts.Margin, err = intQueryParam(q, "margin", opts.Margin); err != nil {
+		return opts, err
+	}
+	if v := q.Get("fg"); v != "" {
+		if opts.Foreground, err = domain.ParseQRColor("fg", v); err != nil {
+			return opts, err
+		}
+	}
+	if v := q.Get("bg"); v != "" {
+		if opts.Background, err = domain.ParseQRColor("bg", v); err != nil {
+			return opts, err
+		}
+	}
+	return opts, opts.Validate()
+}
+
+// intQueryParam целый параметр запроса, def если его нет
+func intQueryParam(q url.Values, name string, def int) (int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def, &domain.ValidationError{Field: name, Message: "must be an integer"}
+	}
+	return n, nil
+}
+
+// etagMatches заголовок If-None-Match содержит тег или *
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *HTTPHandlers) getQRCode(w http.ResponseWriter, request *http.Request) {
+	opts, err := qrOptionsFromQuery(request.URL.Query())
+	if writeServiceError(w, err) {
+		return
+	}
+	link, err := r.service.GetLink(request.Context(), chi.URLParam(request, "hash"))
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot get link", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+
+	content := adapters.CreatePublicURL(link.Domain, link.Key)
+	etag := opts.ETag(content)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", qrCacheControl)
+	if etagMatches(request.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	img, err := adapters.RenderQRCode(content, opts)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot render qr code", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	w.Header().Set("Content-Type", opts.Format.ContentType())
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(img)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetQRCode(t *testing.T) {
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	testServer := httptest.NewServer(CreateServeMux(service, adapters.CreateLogger(), nil))
+	defer testServer.Close()
+	key := utils.Must(service.CreateShort(context.Background(), *utils.Must(url.Parse("https://example.com/qr")), domain.Owner{UserID: uuid.New()}))
+
+	get := func(target string, etag string) *http.Response {
+		req := utils.Must(http.NewRequest(http.MethodGet, testServer.URL+target, nil))
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := get("/"+key+"/qr", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	require.Equal(t, qrCacheControl, resp.Header.Get("Cache-Control"))
+	require.Empty(t, resp.Header.Values("Set-Cookie"))
+	require.Empty(t, resp.Header.Get("Authorization"))
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	resp = get("/"+key+"/qr", `"other", `+etag)
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp = get("/"+key+"/qr?format=svg&size=512&level=H&margin=2&fg=1a5fb4&bg=ffffff00", etag)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "options change the etag")
+	require.Equal(t, "image/svg+xml", resp.Header.Get("Content-Type"))
+
+	for _, query := range []string{"format=gif", "size=10", "level=X", "margin=-1", "fg=red"} {
+		require.Equal(t, http.StatusBadRequest, get("/"+key+"/qr?"+query, "").StatusCode, query)
+	}
+	require.Equal(t, http.StatusNotFound, get("/unknown/qr", "").StatusCode)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// rateLimitKind - вид операции для отдельного лимита
+type rateLimitKind string
+
+const (
+	rateLimitCreate   rateLimitKind = "create"
+	rateLimitBatch    rateLimitKind = "batch"
+	rateLimitRedirect rateLimitKind = "redirect"
+	rateLimitDelete   rateLimitKind = "delete"
+)
+
+// RateLimitMiddleware ограничение частоты запросов по пользователю, для анонимных - по ip клиента
+func RateLimitMiddleware(
+	logger zap.SugaredLogger,
+	limiter domain.RateLimiter,
+	kind rateLimitKind,
+	limit domain.RateLimit,
+	cost func(r *http.Request) int,
+) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if limiter == nil || !limit.Enabled() {
+			return next
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			n := 1
+			if cost != nil {
+				n = cost(r)
+			}
+			if !limit.Fits(n) {
+				writeProblem(w, domain.CodePayloadTooLarge, fmt.Sprintf("request costs %d, at most %d is allowed", n, limit.Burst))
+				return
+			}
+			retryAfter, err := limiter.Allow(r.Context(), string(kind)+":"+rateLimitSubject(r), limit, n)
+			if err != nil {
+				// не блокируем запросы из-за недоступности хранилища лимитов
+				logger.Errorw("cannot check rate limit", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+				writeProblem(w, domain.CodeRateLimited, "too many requests")
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+	}
+}
+
+// rateLimitSubject ключ лимита: пользователь с токеном или ip клиента
+func rateLimitSubject(r *http.Request) string {
+	if adapters.AuthMethodFromCtx(r.Context()) != adapters.AuthMethodAnonymous {
+		if userID, err := adapters.UserIDFromCtx(r.Context()); err == nil {
+			return "user:" + userID.String()
+		}
+	}
+	return "ip:" + adapters.ClientIPFromCtx(r.Context()).String()
+}
+
+// batchCost стоимость пакетного запроса - количество ссылок в нём.
+// Читается не больше maxValidatedBodySize, слишком большое тело дальше будет отклонено с 413.
+func batchCost(r *http.Request) int {
+	body := http.MaxBytesReader(nil, r.Body, maxValidatedBodySize)
+	b, err := io.ReadAll(body)
+	if err != nil {
+		// повторное чтение вернёт ту же ошибку
+		r.Body = body
+		return 1
+	}
+	r.Body = io.NopCloser(bytes.NewReader(b))
+
+	var items []json.RawMessage
+	if err = json.Unmarshal(b, &items); err != nil || len(items) == 0 {
+		return 1
+	}
+	return len(items)
+}
+
+// retryAfterSeconds округление времени до повтора вверх до секунд
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	logger := adapters.CreateLogger()
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	limits := domain.RateLimits{
+		Create: domain.RateLimit{Rate: 0.001, Burst: 2},
+		Batch:  domain.RateLimit{Rate: 0.001, Burst: 3},
+	}
+	testServer := httptest.NewServer(CreateServeMux(service, logger, nil, WithRateLimiter(adapters.NewMemRateLimiter(), limits)))
+	defer testServer.Close()
+
+	t.Run("create is limited by client ip for anonymous users", func(t *testing.T) {
+		for i, want := range []int{http.StatusCreated, http.StatusCreated, http.StatusTooManyRequests} {
+			resp, err := http.Post(testServer.URL, "text/plain", strings.NewReader("https://github.com/"+string(rune('a'+i))))
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, want, resp.StatusCode)
+			if want == http.StatusTooManyRequests {
+				require.NotEmpty(t, resp.Header.Get("Retry-After"))
+			}
+		}
+	})
+
+	t.Run("batch is weighted by item count", func(t *testing.T) {
+		body := `[
+{"correlation_id": "1", "original_url": "https://yandex.ru"},
+{"correlation_id": "2", "original_url": "https://rambler.ru"}
+]`
+		resp, err := http.Post(testServer.URL+"/api/shorten/batch", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp, err = http.Post(testServer.URL+"/api/shorten/batch", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	})
+	t.Run("batch above burst is rejected without retry", func(t *testing.T) {
+		body := `[
+{"correlation_id": "1", "original_url": "https://yandex.ru/1"},
+{"correlation_id": "2", "original_url": "https://yandex.ru/2"},
+{"correlation_id": "3", "original_url": "https://yandex.ru/3"},
+{"correlation_id": "4", "original_url": "https://yandex.ru/4"}
+]`
+		resp, err := http.Post(testServer.URL+"/api/shorten/batch", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		require.Empty(t, resp.Header.Get("Retry-After"))
+	})
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRedirectCacheMaxAge - сколько браузеры и CDN хранят постоянный редирект.
+// Владелец может изменить или удалить ссылку, поэтому срок ограничен.
+const DefaultRedirectCacheMaxAge = time.Hour
+
+// WithRedirectCacheMaxAge - срок кеширования постоянных редиректов, 0 - редиректы не кешируются
+func WithRedirectCacheMaxAge(maxAge time.Duration) ServeMuxOption {
+	return func(h *HTTPHandlers) {
+		h.redirectMaxAge = maxAge
+	}
+}
+
+// Предпросмотр ссылки: суффикс после ключа или параметр запроса
+const (
+	previewSuffix     = "+"
+	previewQueryParam = "preview"
+)
+
+// previewPage - данные шаблона страницы предпросмотра
+type previewPage struct {
+	ShortURL    string
+	Destination string
+	Title       string
+	Description string
+	CreatedAt   time.Time
+	// Warning - причина, по которой ссылку отклоняет политика безопасности
+	Warning string
+}
+
+func (r *HTTPHandlers) getOriginLinkHandler(writer http.ResponseWriter, request *http.Request) {
+	key, preview := strings.CutSuffix(chi.URLParam(request, "hash"), previewSuffix)
+	rawQuery := request.URL.RawQuery
+	query := request.URL.Query()
+	if query.Has(previewQueryParam) {
+		preview = preview || query.Get(previewQueryParam) == "1"
+		// параметр предпросмотра не передаётся в оригинал, остальные параметры передаются как есть
+		query.Del(previewQueryParam)
+		rawQuery = domain.RemoveRawQueryParam(rawQuery, previewQueryParam)
+	}
+
+	redirect, err := r.service.Resolve(request.Context(), key, domain.Visit{
+		UserAgent:      request.UserAgent(),
+		AcceptLanguage: request.Header.Get("Accept-Language"),
+		Referrer:       request.Referer(),
+		IP:             adapters.ClientIPFromCtx(request.Context()),
+		Query:          query,
+		Time:           time.Now(),
+		Variant:        stickyVariant(request, key),
+	})
+	if writeServiceError(writer, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Debug("cannot get url by hash", zap.Error(err))
+		writeInternalError(writer)
+		return
+	}
+	location := redirect.Location(rawQuery)
+	if redirect.Variant != "" {
+		setStickyVariant(writer, request, redirect)
+	}
+	if preview || redirect.Interstitial() {
+		r.renderPreview(writer, redirect, location)
+		return
+	}
+	// HEAD и предпросмотр не считаются переходом
+	if request.Method == http.MethodGet {
+		if err = r.service.RecordClick(request.Context(), redirect); err != nil {
+			r.logger.Error("cannot record click", zap.Error(err))
+		}
+	}
+	setRedirectCacheHeaders(writer.Header(), redirect, r.redirectMaxAge, time.Now())
+	http.Redirect(writer, request, location, int(redirect.Type))
+}
+
+// renderPreview страница с адресом назначения и кнопкой перехода вместо редиректа
+func (r *HTTPHandlers) renderPreview(w http.ResponseWriter, redirect *domain.Redirect, location string) {
+	var buf bytes.Buffer
+	err := r.templates.ExecuteTemplate(&buf, previewTemplate, previewPage{
+		ShortURL:    adapters.CreatePublicURL(redirect.Domain, redirect.Key),
+		Destination: location,
+		Title:       redirect.Preview.Title,
+		Description: redirect.Preview.Description,
+		CreatedAt:   redirect.CreatedAt,
+		Warning:     redirect.Warning,
+	})
+	if err != nil {
+		r.logger.Error("cannot render preview page", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	h := w.Header()
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	h.Set("X-Frame-Options", "DENY")
+	h.Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// setRedirectCacheHeaders Cache-Control и Expires редиректа. Постоянный редирект кешируется не дольше maxAge
+// и не дольше срока действия ссылки, временный и зависящий от посетителя каждый раз перепроверяется.
+// Ответ с cookie не сохраняется ни в каких кешах.
+func setRedirectCacheHeaders(h http.Header, redirect *domain.Redirect, maxAge time.Duration, now time.Time) {
+	if len(h.Values("Set-Cookie")) > 0 {
+		h.Set("Cache-Control", "private, no-store")
+		h.Set("Expires", now.UTC().Format(http.TimeFormat))
+		return
+	}
+	ttl := maxAge
+	if redirect.ExpiresAt != nil {
+		ttl = min(ttl, redirect.ExpiresAt.Sub(now))
+	}
+	ttl = ttl.Truncate(time.Second)
+	if !redirect.Type.Permanent() || redirect.Routed || ttl <= 0 {
+		h.Set("Cache-Control", "no-cache")
+		h.Set("Expires", now.UTC().Format(http.TimeFormat))
+		return
+	}
+	h.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(ttl.Seconds())))
+	h.Set("Expires", now.Add(ttl).UTC().Format(http.TimeFormat))
+}
+
+func (r *HTTPHandlers) setLinkRedirect(w http.ResponseWriter, request *http.Request) {
+	var settings domain.RedirectSettings
+	if err := json.NewDecoder(request.Body).Decode(&settings); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	link, err := r.service.SetRedirect(request.Context(), chi.URLParam(request, "key"), adapters.MustUserIDFromReq(request), settings)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot set link redirect", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, link)
+}
+
+// linkRulesRequest - правила маршрутизации ссылки, пустой список удаляет правила
+type linkRulesRequest struct {
+	Rules []domain.RouteRule `json:"rules"`
+}
+
+func (r *HTTPHandlers) setLinkRules(w http.ResponseWriter, request *http.Request) {
+	var req linkRulesRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	link, err := r.service.SetRules(request.Context(), chi.URLParam(request, "key"), adapters.MustUserIDFromReq(request), req.Rules)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot set link rules", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, link)
+}
+
+func (r *HTTPHandlers) setLinkPreview(w http.ResponseWriter, request *http.Request) {
+	var preview domain.LinkPreview
+	if err := json.NewDecoder(request.Body).Decode(&preview); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	link, err := r.service.SetPreview(request.Context(), chi.URLParam(request, "key"), adapters.MustUserIDFromReq(request), preview)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot set link preview", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, link)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirect(t *testing.T) {
+	logger := adapters.CreateLogger()
+	repo := adapters.NewMemURLRepository()
+	service := domain.NewShortenerService(repo, adapters.GenBase64ShortURLToken, domain.WithRedirectDefaults(domain.RedirectFound, false))
+	keyring := adapters.NewKeyring(adapters.NewHMACKey(adapters.DefaultKeyID, []byte(internal.Config.JwtSecret)))
+	auth := adapters.NewAuthenticator(keyring, nil, 0)
+
+	testServer := httptest.NewServer(CreateServeMux(service, logger, nil, WithAuthenticator(auth), WithRedirectCacheMaxAge(time.Hour)))
+	defer testServer.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	userID := uuid.New()
+	token := utils.Must(auth.BuildJWTString(userID))
+	do := func(method string, target string, body string) *http.Response {
+		req := utils.Must(http.NewRequest(method, testServer.URL+target, strings.NewReader(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp
+	}
+	key := utils.Must(service.CreateShort(context.Background(), *utils.Must(url.Parse("https://example.com/page?a=1")), domain.Owner{UserID: userID}))
+
+	t.Run("server default", func(t *testing.T) {
+		resp := do(http.MethodGet, "/"+key+"?utm_source=x", "")
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		require.Equal(t, "https://example.com/page?a=1", resp.Header.Get("Location"))
+		require.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+	})
+
+	t.Run("per link type and query passthrough", func(t *testing.T) {
+		resp := do(http.MethodPut, "/api/user/urls/"+key+"/redirect", `{"redirect_type": 308, "pass_query": true}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = do(http.MethodGet, "/"+key+"?utm_source=x", "")
+		require.Equal(t, http.StatusPermanentRedirect, resp.StatusCode)
+		require.Equal(t, "https://example.com/page?a=1&utm_source=x", resp.Header.Get("Location"))
+		require.Equal(t, "public, max-age=3600", resp.Header.Get("Cache-Control"))
+		expires, err := http.ParseTime(resp.Header.Get("Expires"))
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(time.Hour), expires, 5*time.Second)
+		require.Empty(t, resp.Header.Values("Set-Cookie"))
+		require.Empty(t, resp.Header.Get("Authorization"))
+	})
+
+	t.Run("anonymous visitor gets no tokens", func(t *testing.T) {
+		resp, err := client.Get(testServer.URL + "/" + key)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusPermanentRedirect, resp.StatusCode)
+		require.Equal(t, "public, max-age=3600", resp.Header.Get("Cache-Control"))
+		require.Empty(t, resp.Header.Values("Set-Cookie"))
+		require.Empty(t, resp.Header.Get("Authorization"))
+	})
+
+	t.Run("head", func(t *testing.T) {
+		resp := do(http.MethodHead, "/"+key, "")
+		require.Equal(t, http.StatusPermanentRedirect, resp.StatusCode)
+		require.Equal(t, "https://example.com/page?a=1", resp.Header.Get("Location"))
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		resp := do(http.MethodPut, "/api/user/urls/"+key+"/redirect", `{"redirect_type": 303}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("only owner can change", func(t *testing.T) {
+		other := utils.Must(auth.BuildJWTString(uuid.New()))
+		req := utils.Must(http.NewRequest(http.MethodPut, testServer.URL+"/api/user/urls/"+key+"/redirect", strings.NewReader(`{}`)))
+		req.Header.Set("Authorization", "Bearer "+other)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
+
+func TestSetRedirectCacheHeaders(t *testing.T) {
+	now := time.Date(2024, 10, 19, 12, 0, 0, 0, time.UTC)
+	soon := now.Add(10 * time.Minute)
+	past := now.Add(-time.Minute)
+
+	for name, tc := range map[string]struct {
+		redirect     domain.Redirect
+		maxAge       time.Duration
+		cacheControl string
+		expires      time.Time
+	}{
+		"temporary":             {domain.Redirect{Type: domain.RedirectTemporary}, time.Hour, "no-cache", now},
+		"permanent":             {domain.Redirect{Type: domain.RedirectMovedPermanently}, time.Hour, "public, max-age=3600", now.Add(time.Hour)},
+		"permanent expiring":    {domain.Redirect{Type: domain.RedirectPermanent, ExpiresAt: &soon}, time.Hour, "public, max-age=600", soon},
+		"permanent expired":     {domain.Redirect{Type: domain.RedirectPermanent, ExpiresAt: &past}, time.Hour, "no-cache", now},
+		"caching disabled":      {domain.Redirect{Type: domain.RedirectPermanent}, 0, "no-cache", now},
+		"temporary with expiry": {domain.Redirect{Type: domain.RedirectFound, ExpiresAt: &soon}, time.Hour, "no-cache", now},
+	} {
+		h := http.Header{}
+		setRedirectCacheHeaders(h, &tc.redirect, tc.maxAge, now)
+		require.Equal(t, tc.cacheControl, h.Get("Cache-Control"), name)
+		require.Equal(t, tc.expires.Format(http.TimeFormat), h.Get("Expires"), name)
+	}
+
+	h := http.Header{}
+	h.Add("Set-Cookie", "variant_key=a")
+	setRedirectCacheHeaders(h, &domain.Redirect{Type: domain.RedirectPermanent}, time.Hour, now)
+	require.Equal(t, "private, no-store", h.Get("Cache-Control"))
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// staticGeoIP - все адреса из одной страны
+type staticGeoIP string
+
+func (g staticGeoIP) Country(net.IP) (string, error) {
+	return string(g), nil
+}
+
+func TestLinkRules(t *testing.T) {
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken, domain.WithGeoIP(staticGeoIP("CH")))
+	srv := newTestServer(t, service, newTestAuthenticator(nil))
+
+	userID := uuid.New()
+	token := srv.token(userID)
+
+	key := utils.Must(service.CreateShort(context.Background(), *utils.Must(url.Parse("https://example.com/")), domain.Owner{UserID: userID}))
+	resp, body := srv.do(token, http.MethodPut, "/api/user/urls/"+key+"/rules", `{"rules": [
+		{"url": "https://apps.apple.com/app/id1", "devices": ["ios"]},
+		{"url": "https://play.google.com/store/apps/details?id=app", "devices": ["android"]},
+		{"url": "https://example.com/de", "languages": ["de"]},
+		{"url": "https://example.com/ch", "countries": ["ch"], "query": {"geo": ""}}
+	]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	var link domain.Link
+	require.NoError(t, json.Unmarshal([]byte(body), &link))
+	require.Len(t, link.Rules, 4)
+	require.Equal(t, []string{"ios"}, link.Rules[0].Devices)
+	require.Equal(t, []string{"CH"}, link.Rules[3].Countries)
+
+	t.Run("first matching rule wins", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			target  string
+			headers []string
+			want    string
+		}{
+			{name: "ios", target: "/" + key, headers: []string{"User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)", "Accept-Language", "de"}, want: "https://apps.apple.com/app/id1"},
+			{name: "android", target: "/" + key, headers: []string{"User-Agent", "Mozilla/5.0 (Linux; Android 14) Mobile"}, want: "https://play.google.com/store/apps/details?id=app"},
+			{name: "german browser", target: "/" + key, headers: []string{"User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)", "Accept-Language", "de-DE,de;q=0.9"}, want: "https://example.com/de"},
+			{name: "country and query", target: "/" + key + "?geo=1", headers: []string{"Accept-Language", "fr"}, want: "https://example.com/ch"},
+			{name: "fallback", target: "/" + key, headers: []string{"Accept-Language", "en-US,de;q=0.5"}, want: "https://example.com/"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				resp, _ := srv.do(token, http.MethodGet, tt.target, "", tt.headers...)
+				require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+				require.Equal(t, tt.want, resp.Header.Get("Location"))
+			})
+		}
+	})
+
+	t.Run("routed permanent redirect is not cached", func(t *testing.T) {
+		resp, _ := srv.do(token, http.MethodPut, "/api/user/urls/"+key+"/redirect", `{"redirect_type": 301}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = srv.do(token, http.MethodGet, "/"+key, "")
+		require.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+		require.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		resp, body := srv.do(token, http.MethodPut, "/api/user/urls/"+key+"/rules", `{"rules": [{"url": "https://example.com/x"}, {"url": "", "countries": ["DE"]}]}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, body, `"rules[0]"`)
+		require.Contains(t, body, `"rules[1].url"`)
+
+		resp, _ = srv.do(token, http.MethodPut, "/api/user/urls/"+key+"/rules", `{"rules": [{"url": "https://example.com/x", "devices": ["tv"], "countries": ["DEU"]}]}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("only owner", func(t *testing.T) {
+		resp, _ := srv.do(srv.token(uuid.New()), http.MethodPut, "/api/user/urls/"+key+"/rules", `{"rules": []}`)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("empty list removes rules", func(t *testing.T) {
+		resp, body := srv.do(token, http.MethodPut, "/api/user/urls/"+key+"/rules", `{"rules": []}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NotContains(t, body, `"rules"`)
+		resp, _ = srv.do(token, http.MethodGet, "/"+key, "", "User-Agent", "Mozilla/5.0 (iPhone)")
+		require.Equal(t, "https://example.com/", resp.Header.Get("Location"))
+		require.Contains(t, resp.Header.Get("Cache-Control"), "max-age=")
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// embeddedTemplates - шаблоны html страниц по умолчанию
+//
+//go:embed templates/*.html
+var embeddedTemplates embed.FS
+
+// previewTemplate - страница предпросмотра ссылки
+const previewTemplate = "preview.html"
+
+// LoadTemplates шаблоны страниц: встроенные, файл с тем же именем из dir заменяет встроенный, пустой dir - только встроенные
+func LoadTemplates(dir string) (*template.Template, error) {
+	names, err := fs.Glob(embeddedTemplates, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+	t := template.New("")
+	for _, name := range names {
+		name = filepath.Base(name)
+		src, err := readTemplate(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = t.New(name).Parse(string(src)); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// readTemplate текст шаблона из dir, если он там есть, иначе встроенный
+func readTemplate(dir string, name string) ([]byte, error) {
+	if dir != "" {
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if !errors.Is(err, fs.ErrNotExist) {
+			return src, err
+		}
+	}
+	return embeddedTemplates.ReadFile("templates/" + name)
+}
+
+// WithTemplates - шаблоны html страниц, по умолчанию встроенные
+func WithTemplates(t *template.Template) ServeMuxOption {
+	return func(h *HTTPHandlers) {
+		h.templates = t
+	}
+}
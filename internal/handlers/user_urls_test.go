@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserUrlsPagination(t *testing.T) {
+	logger := adapters.CreateLogger()
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	keyring := adapters.NewKeyring(adapters.NewHMACKey(adapters.DefaultKeyID, []byte(internal.Config.JwtSecret)))
+	auth := adapters.NewAuthenticator(keyring, nil, 0)
+
+	testServer := httptest.NewServer(CreateServeMux(service, logger, nil, WithAuthenticator(auth)))
+	defer testServer.Close()
+
+	token := utils.Must(auth.BuildJWTString(uuid.New()))
+	do := func(method string, target string, body string) *http.Response {
+		req := utils.Must(http.NewRequest(method, testServer.URL+target, strings.NewReader(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+	list := func(query string) ([]string, string) {
+		resp := do(http.MethodGet, "/api/user/urls"+query, "")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var entries []domain.URLEntry
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+		urls := make([]string, 0, len(entries))
+		for _, e := range entries {
+			require.False(t, e.CreatedAt.IsZero())
+			urls = append(urls, e.OriginalURL)
+		}
+		return urls, resp.Header.Get(xNextCursor)
+	}
+
+	created := []string{"https://example.com/1", "https://github.com/2", "https://docs.example.com/3", "https://example.org/4", "https://example.com/5"}
+	for _, u := range created {
+		resp := do(http.MethodPost, "/", u)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	t.Run("cursor", func(t *testing.T) {
+		var all []string
+		cursor := ""
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 5)
+			urls, next := list("?limit=2&cursor=" + cursor)
+			all = append(all, urls...)
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		require.ElementsMatch(t, created, all)
+		require.Len(t, all, len(created), "no duplicates between pages")
+	})
+
+	t.Run("order, domain and search", func(t *testing.T) {
+		urls, next := list("?domain=example.com&order=asc")
+		require.Empty(t, next)
+		require.Len(t, urls, 3)
+		require.NotContains(t, urls, "https://example.org/4")
+
+		urls, _ = list("?q=GITHUB")
+		require.Equal(t, []string{"https://github.com/2"}, urls)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		for _, query := range []string{"?limit=-1", "?cursor=broken", "?order=random", "?domain=-"} {
+			resp := do(http.MethodGet, "/api/user/urls"+query, "")
+			resp.Body.Close()
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		}
+	})
+}
+
+func TestExportUrls(t *testing.T) {
+	logger := adapters.CreateLogger()
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	keyring := adapters.NewKeyring(adapters.NewHMACKey(adapters.DefaultKeyID, []byte(internal.Config.JwtSecret)))
+	auth := adapters.NewAuthenticator(keyring, nil, 0)
+
+	testServer := httptest.NewServer(CreateServeMux(service, logger, nil, WithAuthenticator(auth)))
+	defer testServer.Close()
+
+	token := utils.Must(auth.BuildJWTString(uuid.New()))
+	created := []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"}
+	for _, u := range created {
+		req := utils.Must(http.NewRequest(http.MethodPost, testServer.URL+"/", strings.NewReader(u)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	export := func(format string) (*http.Response, string) {
+		req := utils.Must(http.NewRequest(http.MethodGet, testServer.URL+"/api/user/urls/export?format="+format, nil))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	t.Run("json", func(t *testing.T) {
+		resp, body := export("json")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.True(t, resp.Uncompressed, "gzip is applied")
+		var entries []domain.ExportEntry
+		require.NoError(t, json.Unmarshal([]byte(body), &entries))
+		require.Len(t, entries, len(created))
+		for i, e := range entries {
+			require.Equal(t, created[i], e.OriginalURL, "oldest first")
+			require.Equal(t, adapters.CreatePublicURL(e.Domain, e.Key), e.ShortURL)
+		}
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		resp, body := export("jsonl")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		require.Len(t, lines, len(created))
+		var e domain.ExportEntry
+		require.NoError(t, json.Unmarshal([]byte(lines[2]), &e))
+		require.Equal(t, created[2], e.OriginalURL)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		resp, body := export("csv")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+		records := utils.Must(csv.NewReader(strings.NewReader(body)).ReadAll())
+		require.Len(t, records, len(created)+1)
+		require.Equal(t, exportCSVHeader, records[0])
+		require.Equal(t, created[0], records[1][2])
+		require.Equal(t, "false", records[1][5])
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		resp, _ := export("xml")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// Cookie, в которой запоминается вариант A/B теста посетителя, своя для каждой ссылки
+// и отправляется только с запросами к этой ссылке
+const (
+	variantCookiePrefix = "variant_"
+	variantCookieMaxAge = 90 * 24 * time.Hour
+)
+
+// stickyVariant вариант ссылки, который посетитель получил раньше
+func stickyVariant(request *http.Request, key domain.HashKey) string {
+	c, err := request.Cookie(variantCookiePrefix + key)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// setStickyVariant запоминание выбранного варианта, чтобы посетитель и дальше попадал на него
+func setStickyVariant(w http.ResponseWriter, request *http.Request, redirect *domain.Redirect) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     variantCookiePrefix + redirect.Key,
+		Value:    redirect.Variant,
+		Path:     "/" + redirect.Key,
+		MaxAge:   int(variantCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   request.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// linkVariantsRequest - варианты A/B теста ссылки, пустой список отключает тест
+type linkVariantsRequest struct {
+	Variants []domain.Variant `json:"variants"`
+}
+
+func (r *HTTPHandlers) setLinkVariants(w http.ResponseWriter, request *http.Request) {
+	var req linkVariantsRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	link, err := r.service.SetVariants(request.Context(), chi.URLParam(request, "key"), adapters.MustUserIDFromReq(request), req.Variants)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot set link variants", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, link)
+}
+
+// getLinkStats переходы по вариантам A/B теста ссылки
+func (r *HTTPHandlers) getLinkStats(w http.ResponseWriter, request *http.Request) {
+	stats, err := r.service.LinkStats(request.Context(), chi.URLParam(request, "key"), adapters.MustUserIDFromReq(request))
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot get link stats", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/sashaaro/url-shortener/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkVariants(t *testing.T) {
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken)
+	srv := newTestServer(t, service, newTestAuthenticator(nil))
+	newClient := func() *http.Client {
+		return &http.Client{Jar: utils.Must(cookiejar.New(nil)), CheckRedirect: noRedirect}
+	}
+	client := newClient()
+
+	userID := uuid.New()
+	token := srv.token(userID)
+
+	key := utils.Must(service.CreateShort(context.Background(), *utils.Must(url.Parse("https://example.com/")), domain.Owner{UserID: userID}))
+	resp, body := srv.doWith(client, token, http.MethodPut, "/api/user/urls/"+key+"/variants", `{"variants": [
+		{"url": "https://example.com/landing-a", "weight": 70},
+		{"id": "b", "url": "https://example.com/landing-b", "weight": 30}
+	]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	var link domain.Link
+	require.NoError(t, json.Unmarshal([]byte(body), &link))
+	require.Equal(t, []domain.Variant{
+		{ID: "a", URL: "https://example.com/landing-a", Weight: 70},
+		{ID: "b", URL: "https://example.com/landing-b", Weight: 30},
+	}, link.Variants)
+
+	var location string
+	t.Run("sticky variant", func(t *testing.T) {
+		resp, _ := srv.doWith(client, token, http.MethodGet, "/"+key, "")
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		require.Equal(t, "private, no-store", resp.Header.Get("Cache-Control"))
+		require.Len(t, resp.Cookies(), 1)
+		require.Equal(t, "/"+key, resp.Cookies()[0].Path, "the cookie is sent only to this link")
+		location = resp.Header.Get("Location")
+		require.Contains(t, []string{"https://example.com/landing-a", "https://example.com/landing-b"}, location)
+		for i := 0; i < 10; i++ {
+			resp, _ := srv.doWith(client, token, http.MethodGet, "/"+key, "")
+			require.Equal(t, location, resp.Header.Get("Location"))
+		}
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		seen := map[string]int64{location: 11}
+		for i := 0; i < 39; i++ {
+			resp, _ := srv.doWith(newClient(), token, http.MethodGet, "/"+key, "")
+			seen[resp.Header.Get("Location")]++
+		}
+		require.Len(t, seen, 2, "both variants are used")
+
+		resp, _ = srv.doWith(client, token, http.MethodHead, "/"+key, "")
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+		resp, body := srv.doWith(client, token, http.MethodGet, "/api/user/urls/"+key+"/stats", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var stats domain.LinkStats
+		require.NoError(t, json.Unmarshal([]byte(body), &stats))
+		require.Equal(t, int64(50), stats.Clicks, "head requests are not counted")
+		require.Len(t, stats.Variants, 2)
+		require.Equal(t, seen["https://example.com/landing-a"], stats.Variants[0].Clicks)
+		require.Equal(t, seen["https://example.com/landing-b"], stats.Variants[1].Clicks)
+	})
+
+	t.Run("clicks survive replacement", func(t *testing.T) {
+		_, body := srv.doWith(client, token, http.MethodGet, "/api/user/urls/"+key+"/stats", "")
+		var before domain.LinkStats
+		require.NoError(t, json.Unmarshal([]byte(body), &before))
+
+		resp, _ := srv.doWith(client, token, http.MethodPut, "/api/user/urls/"+key+"/variants", `{"variants": [
+			{"id": "b", "url": "https://example.com/landing-b", "weight": 1},
+			{"id": "c", "url": "https://example.com/landing-c", "weight": 1}
+		]}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		_, body = srv.doWith(client, token, http.MethodGet, "/api/user/urls/"+key+"/stats", "")
+		var after domain.LinkStats
+		require.NoError(t, json.Unmarshal([]byte(body), &after))
+		require.Equal(t, "b", after.Variants[0].ID)
+		require.Equal(t, before.Variants[1].Clicks, after.Variants[0].Clicks)
+		require.Equal(t, int64(0), after.Variants[1].Clicks)
+	})
+
+	t.Run("validation and access", func(t *testing.T) {
+		resp, _ := srv.doWith(client, token, http.MethodPut, "/api/user/urls/"+key+"/variants", `{"variants": [{"url": "https://example.com", "weight": 1}]}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, _ = srv.do(srv.token(uuid.New()), http.MethodGet, "/api/user/urls/"+key+"/stats", "")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("empty list turns the experiment off", func(t *testing.T) {
+		resp, _ := srv.doWith(client, token, http.MethodPut, "/api/user/urls/"+key+"/variants", `{"variants": []}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = srv.doWith(client, token, http.MethodGet, "/"+key, "")
+		require.Equal(t, "https://example.com/", resp.Header.Get("Location"))
+	})
+}
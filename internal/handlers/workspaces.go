@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// CreateWorkspaceRequest - запрос на создание рабочего пространства
+type CreateWorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+// SetMemberRequest - запрос на добавление участника или смену роли
+type SetMemberRequest struct {
+	Role domain.WorkspaceRole `json:"role"`
+}
+
+// WithWorkspaceService - рабочие пространства
+func WithWorkspaceService(workspaces *domain.WorkspaceService) ServeMuxOption {
+	return func(h *HTTPHandlers) {
+		h.workspaces = workspaces
+	}
+}
+
+// ownerFromReq владелец новой ссылки: текущий пользователь и пространство из ?workspace_id
+func ownerFromReq(request *http.Request) (domain.Owner, error) {
+	owner := domain.Owner{UserID: adapters.MustUserIDFromReq(request)}
+	if raw := request.URL.Query().Get("workspace_id"); raw != "" {
+		workspaceID, err := uuid.Parse(raw)
+		if err != nil {
+			return owner, &domain.ValidationError{Field: "workspace_id", Message: "invalid uuid"}
+		}
+		owner.WorkspaceID = workspaceID
+	}
+	return owner, nil
+}
+
+func (r *HTTPHandlers) createWorkspace(w http.ResponseWriter, request *http.Request) {
+	var req CreateWorkspaceRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	ws, err := r.workspaces.Create(request.Context(), req.Name, adapters.MustUserIDFromReq(request))
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot create workspace", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusCreated, ws)
+}
+
+func (r *HTTPHandlers) listWorkspaces(w http.ResponseWriter, request *http.Request) {
+	list, err := r.workspaces.List(request.Context(), adapters.MustUserIDFromReq(request))
+	if err != nil {
+		r.logger.Error("cannot list workspaces", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, list)
+}
+
+func (r *HTTPHandlers) listWorkspaceMembers(w http.ResponseWriter, request *http.Request) {
+	workspaceID, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		writeFieldError(w, "id", "invalid uuid")
+		return
+	}
+	members, err := r.workspaces.Members(request.Context(), workspaceID, adapters.MustUserIDFromReq(request))
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot list workspace members", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, members)
+}
+
+func (r *HTTPHandlers) setWorkspaceMember(w http.ResponseWriter, request *http.Request) {
+	workspaceID, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		writeFieldError(w, "id", "invalid uuid")
+		return
+	}
+	userID, err := uuid.Parse(chi.URLParam(request, "userID"))
+	if err != nil {
+		writeFieldError(w, "userID", "invalid uuid")
+		return
+	}
+	var req SetMemberRequest
+	if err = json.NewDecoder(request.Body).Decode(&req); err != nil {
+		writeProblem(w, domain.CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	member, err := r.workspaces.SetMember(request.Context(), workspaceID, adapters.MustUserIDFromReq(request), userID, req.Role)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot set workspace member", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	r.writeJSON(w, http.StatusOK, member)
+}
+
+func (r *HTTPHandlers) removeWorkspaceMember(w http.ResponseWriter, request *http.Request) {
+	workspaceID, err := uuid.Parse(chi.URLParam(request, "id"))
+	if err != nil {
+		writeFieldError(w, "id", "invalid uuid")
+		return
+	}
+	userID, err := uuid.Parse(chi.URLParam(request, "userID"))
+	if err != nil {
+		writeFieldError(w, "userID", "invalid uuid")
+		return
+	}
+
+	err = r.workspaces.RemoveMember(request.Context(), workspaceID, adapters.MustUserIDFromReq(request), userID)
+	if writeServiceError(w, err) {
+		return
+	}
+	if err != nil {
+		r.logger.Error("cannot remove workspace member", zap.Error(err))
+		writeInternalError(w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
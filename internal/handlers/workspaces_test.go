@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sashaaro/url-shortener/internal/adapters"
+	"github.com/sashaaro/url-shortener/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaces(t *testing.T) {
+	workspaceRepo := adapters.NewMemWorkspaceRepository()
+	auditRepo := adapters.NewMemAuditRepository()
+	service := domain.NewShortenerService(adapters.NewMemURLRepository(), adapters.GenBase64ShortURLToken,
+		domain.WithWorkspaceRepository(workspaceRepo))
+	srv := newTestServer(t, service, newTestAuthenticator(nil),
+		WithWorkspaceService(domain.NewWorkspaceService(workspaceRepo,
+			domain.WithWorkspaceAuditService(domain.NewAuditService(auditRepo, adapters.AuditActorFromCtx)))))
+
+	type user struct {
+		id    uuid.UUID
+		token string
+	}
+	newUser := func() user {
+		id := uuid.New()
+		return user{id: id, token: srv.token(id)}
+	}
+	owner, editor, viewer, outsider := newUser(), newUser(), newUser(), newUser()
+
+	resp, body := srv.do(owner.token, http.MethodPost, "/api/workspaces", `{"name": "marketing"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var ws domain.Workspace
+	require.NoError(t, json.Unmarshal([]byte(body), &ws))
+	wsQuery := "?workspace_id=" + ws.ID.String()
+	membersURL := "/api/workspaces/" + ws.ID.String() + "/members/"
+
+	resp, _ = srv.do(owner.token, http.MethodPut, membersURL+editor.id.String(), `{"role": "editor"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = srv.do(owner.token, http.MethodPut, membersURL+viewer.id.String(), `{"role": "viewer"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	t.Run("member changes are recorded", func(t *testing.T) {
+		events, err := auditRepo.Query(context.Background(), domain.AuditFilter{Action: domain.AuditWorkspaceMemberSet, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, ws.ID.String(), events[0].Subject)
+		require.Equal(t, owner.id, events[0].UserID)
+		require.Equal(t, map[string]string{"user_id": viewer.id.String(), "role": "viewer", "previous_role": ""}, events[0].Details)
+	})
+
+	t.Run("roles", func(t *testing.T) {
+		resp, _ := srv.do(viewer.token, http.MethodPut, membersURL+outsider.id.String(), `{"role": "viewer"}`)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode, "only owners manage members")
+
+		resp, _ = srv.do(owner.token, http.MethodPut, membersURL+outsider.id.String(), `{"role": "admin"}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, _ = srv.do(owner.token, http.MethodPut, membersURL+owner.id.String(), `{"role": "editor"}`)
+		require.Equal(t, http.StatusConflict, resp.StatusCode, "last owner cannot be demoted")
+
+		resp, body := srv.do(viewer.token, http.MethodGet, "/api/workspaces", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var list []domain.WorkspaceMembership
+		require.NoError(t, json.Unmarshal([]byte(body), &list))
+		require.Len(t, list, 1)
+		require.Equal(t, domain.RoleViewer, list[0].Role)
+
+		resp, _ = srv.do(outsider.token, http.MethodGet, "/api/workspaces/"+ws.ID.String()+"/members", "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	var shortURL string
+	t.Run("create in workspace", func(t *testing.T) {
+		resp, body := srv.do(editor.token, http.MethodPost, "/api/shorten"+wsQuery, `{"url": "https://example.com/shared"}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var res ShortenResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &res))
+		shortURL = res.Result
+
+		resp, _ = srv.do(viewer.token, http.MethodPost, "/api/shorten"+wsQuery, `{"url": "https://example.com/viewer"}`)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _ = srv.do(outsider.token, http.MethodPost, "/"+wsQuery, "https://example.com/outsider")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	listWorkspace := func(u user) []domain.URLEntry {
+		resp, body := srv.do(u.token, http.MethodGet, "/api/user/urls"+wsQuery, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var list []domain.URLEntry
+		require.NoError(t, json.Unmarshal([]byte(body), &list))
+		return list
+	}
+
+	t.Run("listing is scoped by workspace", func(t *testing.T) {
+		require.Len(t, listWorkspace(viewer), 1)
+		require.Len(t, listWorkspace(owner), 1)
+
+		resp, body := srv.do(editor.token, http.MethodGet, "/api/user/urls", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.JSONEq(t, "[]", body, "workspace links are not personal links")
+
+		resp, _ = srv.do(outsider.token, http.MethodGet, "/api/user/urls"+wsQuery, "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("delete requires editor role", func(t *testing.T) {
+		keys := `["` + path.Base(shortURL) + `"]`
+		resp, _ := srv.do(viewer.token, http.MethodDelete, "/api/user/urls", keys)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		require.Len(t, listWorkspace(owner), 1)
+
+		resp, _ = srv.do(owner.token, http.MethodDelete, "/api/user/urls", keys)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		require.Len(t, listWorkspace(owner), 0)
+	})
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddTableRateLimits, downAddTableRateLimits)
+}
+
+func upAddTableRateLimits(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "CREATE TABLE rate_limits (key text PRIMARY KEY, tokens double precision not null, updated_at timestamptz not null)")
+	return err
+}
+
+func downAddTableRateLimits(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE rate_limits")
+	return err
+}
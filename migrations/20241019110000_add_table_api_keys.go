@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddTableAPIKeys, downAddTableAPIKeys)
+}
+
+func upAddTableAPIKeys(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `CREATE TABLE api_keys (
+	id uuid PRIMARY KEY,
+	user_id uuid not null,
+	name text not null,
+	prefix text not null UNIQUE,
+	hash text not null,
+	scopes text[] not null,
+	created_at timestamptz not null,
+	revoked_at timestamptz
+)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "CREATE INDEX api_keys_user_id_idx ON api_keys (user_id)")
+	return err
+}
+
+func downAddTableAPIKeys(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE api_keys")
+	return err
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddTableUsers, downAddTableUsers)
+}
+
+func upAddTableUsers(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `CREATE TABLE users (
+	id uuid PRIMARY KEY,
+	email text not null UNIQUE,
+	password_hash text not null,
+	created_at timestamptz not null
+)`)
+	return err
+}
+
+func downAddTableUsers(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE users")
+	return err
+}
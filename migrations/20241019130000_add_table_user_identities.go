@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddTableUserIdentities, downAddTableUserIdentities)
+}
+
+func upAddTableUserIdentities(ctx context.Context, tx *sql.Tx) error {
+	// у пользователей внешних провайдеров может не быть email
+	_, err := tx.ExecContext(ctx, "ALTER TABLE users ALTER COLUMN email DROP NOT NULL")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `CREATE TABLE user_identities (
+	issuer text not null,
+	subject text not null,
+	user_id uuid not null REFERENCES users (id) ON DELETE CASCADE,
+	created_at timestamptz not null,
+	PRIMARY KEY (issuer, subject)
+)`)
+	return err
+}
+
+func downAddTableUserIdentities(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE user_identities")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "ALTER TABLE users ALTER COLUMN email SET NOT NULL")
+	return err
+}
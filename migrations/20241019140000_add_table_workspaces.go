@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddTableWorkspaces, downAddTableWorkspaces)
+}
+
+func upAddTableWorkspaces(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `CREATE TABLE workspaces (
+	id uuid PRIMARY KEY,
+	name text not null,
+	created_at timestamptz not null
+)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `CREATE TABLE workspace_members (
+	workspace_id uuid not null REFERENCES workspaces (id) ON DELETE CASCADE,
+	user_id uuid not null,
+	role text not null,
+	created_at timestamptz not null,
+	PRIMARY KEY (workspace_id, user_id)
+)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "CREATE INDEX workspace_members_user_id_idx ON workspace_members (user_id)")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "ALTER TABLE urls ADD COLUMN workspace_id uuid REFERENCES workspaces (id)")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "CREATE INDEX urls_workspace_id_idx ON urls (workspace_id)")
+	return err
+}
+
+func downAddTableWorkspaces(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN workspace_id")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DROP TABLE workspace_members")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DROP TABLE workspaces")
+	return err
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddAdminModeration, downAddAdminModeration)
+}
+
+func upAddAdminModeration(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "ALTER TABLE urls ADD COLUMN is_disabled boolean NOT NULL DEFAULT false")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `CREATE TABLE user_bans (
+	user_id uuid PRIMARY KEY,
+	reason text not null,
+	banned_by uuid not null,
+	created_at timestamptz not null
+)`)
+	return err
+}
+
+func downAddAdminModeration(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE user_bans")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN is_disabled")
+	return err
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddTableAuditLog, downAddTableAuditLog)
+}
+
+func upAddTableAuditLog(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `CREATE TABLE audit_log (
+	id bigserial PRIMARY KEY,
+	action text not null,
+	link_key text not null,
+	actor_id uuid,
+	auth_method text not null,
+	client_ip text not null,
+	request_id text not null,
+	before jsonb,
+	after jsonb,
+	created_at timestamptz not null
+)`)
+	if err != nil {
+		return err
+	}
+
+	for _, index := range []string{
+		"CREATE INDEX audit_log_actor_id_idx ON audit_log (actor_id, id)",
+		"CREATE INDEX audit_log_link_key_idx ON audit_log (link_key, id)",
+		"CREATE INDEX audit_log_created_at_idx ON audit_log (created_at)",
+	} {
+		if _, err = tx.ExecContext(ctx, index); err != nil {
+			return err
+		}
+	}
+
+	// журнал неизменяемый: изменение и удаление записей запрещены
+	_, err = tx.ExecContext(ctx, `CREATE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `CREATE TRIGGER audit_log_immutable BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_log_immutable()`)
+	return err
+}
+
+func downAddTableAuditLog(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE audit_log")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DROP FUNCTION audit_log_immutable")
+	return err
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddColumnCreatedAt, downAddColumnCreatedAt)
+}
+
+func upAddColumnCreatedAt(ctx context.Context, tx *sql.Tx) error {
+	// у существующих ссылок время создания неизвестно, им достаётся время миграции
+	_, err := tx.ExecContext(ctx, "ALTER TABLE urls ADD COLUMN created_at timestamptz NOT NULL DEFAULT now()")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "CREATE INDEX urls_user_id_created_at_idx ON urls (user_id, created_at, key) WHERE workspace_id IS NULL")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "CREATE INDEX urls_workspace_id_created_at_idx ON urls (workspace_id, created_at, key)")
+	return err
+}
+
+func downAddColumnCreatedAt(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN created_at")
+	return err
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddLinkImport, downAddLinkImport)
+}
+
+func upAddLinkImport(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "ALTER TABLE urls ADD COLUMN expires_at timestamptz")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "ALTER TABLE urls ADD COLUMN tags text[] NOT NULL DEFAULT '{}'")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `CREATE TABLE import_jobs (
+	id uuid PRIMARY KEY,
+	user_id uuid not null,
+	workspace_id uuid,
+	status text not null,
+	processed bigint not null default 0,
+	created bigint not null default 0,
+	conflicts bigint not null default 0,
+	invalid bigint not null default 0,
+	created_at timestamptz not null,
+	updated_at timestamptz not null
+)`)
+	return err
+}
+
+func downAddLinkImport(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE import_jobs")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN tags")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN expires_at")
+	return err
+}
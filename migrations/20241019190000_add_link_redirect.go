@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddLinkRedirect, downAddLinkRedirect)
+}
+
+func upAddLinkRedirect(ctx context.Context, tx *sql.Tx) error {
+	// 0 - код редиректа сервиса по умолчанию
+	_, err := tx.ExecContext(ctx, "ALTER TABLE urls ADD COLUMN redirect_type smallint NOT NULL DEFAULT 0")
+	if err != nil {
+		return err
+	}
+	// null - передача параметров запроса по настройке сервиса
+	_, err = tx.ExecContext(ctx, "ALTER TABLE urls ADD COLUMN pass_query boolean")
+	return err
+}
+
+func downAddLinkRedirect(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN pass_query")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN redirect_type")
+	return err
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddLinkPreview, downAddLinkPreview)
+}
+
+func upAddLinkPreview(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE urls
+	ADD COLUMN title text NOT NULL DEFAULT '',
+	ADD COLUMN description text NOT NULL DEFAULT '',
+	ADD COLUMN interstitial boolean NOT NULL DEFAULT false`)
+	return err
+}
+
+func downAddLinkPreview(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN title, DROP COLUMN description, DROP COLUMN interstitial")
+	return err
+}
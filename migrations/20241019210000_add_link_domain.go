@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddLinkDomain, downAddLinkDomain)
+}
+
+func upAddLinkDomain(ctx context.Context, tx *sql.Tx) error {
+	// ключи и оригиналы уникальны в пределах домена, пустой домен - основной
+	_, err := tx.ExecContext(ctx, `ALTER TABLE urls
+	ADD COLUMN domain text NOT NULL DEFAULT '',
+	DROP CONSTRAINT urls_pkey,
+	ADD PRIMARY KEY (domain, key),
+	DROP CONSTRAINT urls_url_key,
+	ADD CONSTRAINT urls_domain_url_key UNIQUE (domain, url)`)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `CREATE TABLE domain_users (
+	domain text not null,
+	user_id uuid not null,
+	granted_by uuid not null,
+	created_at timestamptz not null,
+	PRIMARY KEY (domain, user_id)
+)`)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "CREATE INDEX domain_users_user_id_idx ON domain_users (user_id)")
+	return err
+}
+
+func downAddLinkDomain(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE domain_users")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `ALTER TABLE urls
+	DROP CONSTRAINT urls_domain_url_key,
+	ADD CONSTRAINT urls_url_key UNIQUE (url),
+	DROP CONSTRAINT urls_pkey,
+	ADD PRIMARY KEY (key),
+	DROP COLUMN domain`)
+	return err
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddLinkRules, downAddLinkRules)
+}
+
+func upAddLinkRules(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE urls ADD COLUMN rules jsonb NOT NULL DEFAULT '[]'`)
+	return err
+}
+
+func downAddLinkRules(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN rules")
+	return err
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddLinkVariants, downAddLinkVariants)
+}
+
+func upAddLinkVariants(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE urls ADD COLUMN variants jsonb NOT NULL DEFAULT '[]'`)
+	if err != nil {
+		return err
+	}
+	// переходы на варианты считаются по идентификатору и переживают замену вариантов
+	_, err = tx.ExecContext(ctx, `CREATE TABLE variant_clicks (
+	domain text not null,
+	key text not null,
+	variant_id text not null,
+	clicks bigint not null default 0,
+	PRIMARY KEY (domain, key, variant_id)
+)`)
+	return err
+}
+
+func downAddLinkVariants(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE variant_clicks")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN variants")
+	return err
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddLinkParams, downAddLinkParams)
+}
+
+func upAddLinkParams(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE urls ADD COLUMN params jsonb NOT NULL DEFAULT '{}'`)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `CREATE TABLE user_default_params (
+	user_id uuid not null PRIMARY KEY,
+	params jsonb not null
+)`)
+	return err
+}
+
+func downAddLinkParams(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE user_default_params")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN params")
+	return err
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddLinkMeta, downAddLinkMeta)
+}
+
+func upAddLinkMeta(ctx context.Context, tx *sql.Tx) error {
+	// title уже занят заголовком страницы предпросмотра, название ссылки для владельцев - link_title
+	for _, stmt := range []string{
+		"ALTER TABLE urls ADD COLUMN link_title text NOT NULL DEFAULT ''",
+		"ALTER TABLE urls ADD COLUMN note text NOT NULL DEFAULT ''",
+		// знаки препинания заменяются пробелами, как в domain.SearchTerms, чтобы части адреса искались как слова
+		`ALTER TABLE urls ADD COLUMN search tsvector GENERATED ALWAYS AS (
+	to_tsvector('simple', regexp_replace(link_title || ' ' || note || ' ' || url, '[^[:alnum:]]+', ' ', 'g'))
+) STORED`,
+		"CREATE INDEX urls_search_idx ON urls USING gin (search)",
+		"CREATE INDEX urls_tags_idx ON urls USING gin (tags)",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddLinkMeta(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP INDEX urls_tags_idx")
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "ALTER TABLE urls DROP COLUMN search, DROP COLUMN note, DROP COLUMN link_title")
+	return err
+}
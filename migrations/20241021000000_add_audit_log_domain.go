@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddAuditLogDomain, downAddAuditLogDomain)
+}
+
+func upAddAuditLogDomain(ctx context.Context, tx *sql.Tx) error {
+	for _, stmt := range []string{
+		"ALTER TABLE audit_log ADD COLUMN link_domain text NOT NULL DEFAULT ''",
+		// домен прежних записей берётся из снимков ссылки, журнал неизменяемый, поэтому триггер на время выключается
+		"ALTER TABLE audit_log DISABLE TRIGGER audit_log_immutable",
+		"UPDATE audit_log SET link_domain = coalesce(after->>'domain', before->>'domain', '')",
+		"ALTER TABLE audit_log ENABLE TRIGGER audit_log_immutable",
+		"DROP INDEX audit_log_link_key_idx",
+		"CREATE INDEX audit_log_link_key_idx ON audit_log (link_domain, link_key, id)",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddAuditLogDomain(ctx context.Context, tx *sql.Tx) error {
+	for _, stmt := range []string{
+		"DROP INDEX audit_log_link_key_idx",
+		"CREATE INDEX audit_log_link_key_idx ON audit_log (link_key, id)",
+		"ALTER TABLE audit_log DROP COLUMN link_domain",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddUsersEmailVerified, downAddUsersEmailVerified)
+}
+
+func upAddUsersEmailVerified(ctx context.Context, tx *sql.Tx) error {
+	for _, stmt := range []string{
+		"ALTER TABLE users ADD COLUMN email_verified boolean NOT NULL DEFAULT false",
+		// email без пароля мог прийти только от внешнего провайдера, который его подтвердил
+		"UPDATE users SET email_verified = true WHERE email IS NOT NULL AND password_hash = ''",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddUsersEmailVerified(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "ALTER TABLE users DROP COLUMN email_verified")
+	return err
+}
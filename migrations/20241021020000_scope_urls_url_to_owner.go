@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upScopeUrlsURLToOwner, downScopeUrlsURLToOwner)
+}
+
+func upScopeUrlsURLToOwner(ctx context.Context, tx *sql.Tx) error {
+	// оригинал уникален только среди ссылок одного владельца: личных ссылок пользователя или ссылок пространства,
+	// иначе при создании ссылки возвращалась бы чужая ссылка, которой владелец может управлять
+	for _, stmt := range []string{
+		"ALTER TABLE urls DROP CONSTRAINT urls_domain_url_key",
+		"CREATE UNIQUE INDEX urls_domain_user_id_url_key ON urls (domain, user_id, url) WHERE workspace_id IS NULL",
+		"CREATE UNIQUE INDEX urls_domain_workspace_id_url_key ON urls (domain, workspace_id, url) WHERE workspace_id IS NOT NULL",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downScopeUrlsURLToOwner(ctx context.Context, tx *sql.Tx) error {
+	for _, stmt := range []string{
+		"DROP INDEX urls_domain_workspace_id_url_key",
+		"DROP INDEX urls_domain_user_id_url_key",
+		"ALTER TABLE urls ADD CONSTRAINT urls_domain_url_key UNIQUE (domain, url)",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddTableRevokedTokens, downAddTableRevokedTokens)
+}
+
+func upAddTableRevokedTokens(ctx context.Context, tx *sql.Tx) error {
+	// jti refresh токенов, отозванных при выходе, до истечения их срока
+	_, err := tx.ExecContext(ctx, `CREATE TABLE revoked_tokens (
+	id text PRIMARY KEY,
+	expires_at timestamptz not null
+)`)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at)")
+	return err
+}
+
+func downAddTableRevokedTokens(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE revoked_tokens")
+	return err
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddAuditLogSubject, downAddAuditLogSubject)
+}
+
+func upAddAuditLogSubject(ctx context.Context, tx *sql.Tx) error {
+	// журнал записывает не только ссылки, но и запреты пользователей, участников пространств и api ключи
+	for _, stmt := range []string{
+		"ALTER TABLE audit_log ADD COLUMN subject text NOT NULL DEFAULT ''",
+		"ALTER TABLE audit_log ADD COLUMN details jsonb",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddAuditLogSubject(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "ALTER TABLE audit_log DROP COLUMN subject, DROP COLUMN details")
+	return err
+}
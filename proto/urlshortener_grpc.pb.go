@@ -19,13 +19,27 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	URLShortener_CreateShort_FullMethodName   = "/urlshortener.URLShortener/CreateShort"
-	URLShortener_GetOriginLink_FullMethodName = "/urlshortener.URLShortener/GetOriginLink"
-	URLShortener_Shorten_FullMethodName       = "/urlshortener.URLShortener/Shorten"
-	URLShortener_GetUserUrls_FullMethodName   = "/urlshortener.URLShortener/GetUserUrls"
-	URLShortener_DeleteUrls_FullMethodName    = "/urlshortener.URLShortener/DeleteUrls"
-	URLShortener_GetStats_FullMethodName      = "/urlshortener.URLShortener/GetStats"
-	URLShortener_Ping_FullMethodName          = "/urlshortener.URLShortener/Ping"
+	URLShortener_CreateShort_FullMethodName      = "/urlshortener.URLShortener/CreateShort"
+	URLShortener_GetOriginLink_FullMethodName    = "/urlshortener.URLShortener/GetOriginLink"
+	URLShortener_GetQRCode_FullMethodName        = "/urlshortener.URLShortener/GetQRCode"
+	URLShortener_Shorten_FullMethodName          = "/urlshortener.URLShortener/Shorten"
+	URLShortener_ShortenBatch_FullMethodName     = "/urlshortener.URLShortener/ShortenBatch"
+	URLShortener_GetUserUrls_FullMethodName      = "/urlshortener.URLShortener/GetUserUrls"
+	URLShortener_DeleteUrls_FullMethodName       = "/urlshortener.URLShortener/DeleteUrls"
+	URLShortener_GetStats_FullMethodName         = "/urlshortener.URLShortener/GetStats"
+	URLShortener_Ping_FullMethodName             = "/urlshortener.URLShortener/Ping"
+	URLShortener_ImportUrls_FullMethodName       = "/urlshortener.URLShortener/ImportUrls"
+	URLShortener_ExportUrls_FullMethodName       = "/urlshortener.URLShortener/ExportUrls"
+	URLShortener_SetLinkRedirect_FullMethodName  = "/urlshortener.URLShortener/SetLinkRedirect"
+	URLShortener_SetLinkPreview_FullMethodName   = "/urlshortener.URLShortener/SetLinkPreview"
+	URLShortener_SetLinkRules_FullMethodName     = "/urlshortener.URLShortener/SetLinkRules"
+	URLShortener_SetLinkVariants_FullMethodName  = "/urlshortener.URLShortener/SetLinkVariants"
+	URLShortener_GetLinkStats_FullMethodName     = "/urlshortener.URLShortener/GetLinkStats"
+	URLShortener_SetLinkParams_FullMethodName    = "/urlshortener.URLShortener/SetLinkParams"
+	URLShortener_GetDefaultParams_FullMethodName = "/urlshortener.URLShortener/GetDefaultParams"
+	URLShortener_SetDefaultParams_FullMethodName = "/urlshortener.URLShortener/SetDefaultParams"
+	URLShortener_SetLinkMeta_FullMethodName      = "/urlshortener.URLShortener/SetLinkMeta"
+	URLShortener_RenameTag_FullMethodName        = "/urlshortener.URLShortener/RenameTag"
 )
 
 // URLShortenerClient is the client API for URLShortener service.
@@ -36,8 +50,12 @@ type URLShortenerClient interface {
 	CreateShort(ctx context.Context, in *CreateShortRequest, opts ...grpc.CallOption) (*CreateShortResponse, error)
 	// Получить оригинальный URL по короткому
 	GetOriginLink(ctx context.Context, in *GetOriginLinkRequest, opts ...grpc.CallOption) (*GetOriginLinkResponse, error)
+	// Получить qr код короткого URL
+	GetQRCode(ctx context.Context, in *GetQRCodeRequest, opts ...grpc.CallOption) (*QRCode, error)
 	// Укоротить URL через API
 	Shorten(ctx context.Context, in *ShortenRequest, opts ...grpc.CallOption) (*ShortenResponse, error)
+	// Укоротить несколько URL с результатом для каждого
+	ShortenBatch(ctx context.Context, in *ShortenBatchRequest, opts ...grpc.CallOption) (*ShortenBatchResponse, error)
 	// Получить все URL пользователя
 	GetUserUrls(ctx context.Context, in *GetUserUrlsRequest, opts ...grpc.CallOption) (*GetUserUrlsResponse, error)
 	// Удалить URL пользователя
@@ -46,6 +64,30 @@ type URLShortenerClient interface {
 	GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
 	// Проверка доступности сервера
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PongResponse, error)
+	// Потоковый импорт ссылок, результаты строк возвращаются по мере сохранения
+	ImportUrls(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ImportUrlsRequest, ImportUrlsResponse], error)
+	// Выгрузить все ссылки пользователя или рабочего пространства от старых к новым
+	ExportUrls(ctx context.Context, in *ExportUrlsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportUrl], error)
+	// Заменить настройки редиректа ссылки
+	SetLinkRedirect(ctx context.Context, in *SetLinkRedirectRequest, opts ...grpc.CallOption) (*SetLinkRedirectResponse, error)
+	// Заменить данные страницы предпросмотра ссылки
+	SetLinkPreview(ctx context.Context, in *SetLinkPreviewRequest, opts ...grpc.CallOption) (*SetLinkPreviewResponse, error)
+	// Заменить правила маршрутизации ссылки
+	SetLinkRules(ctx context.Context, in *SetLinkRulesRequest, opts ...grpc.CallOption) (*SetLinkRulesResponse, error)
+	// Заменить варианты A/B теста ссылки
+	SetLinkVariants(ctx context.Context, in *SetLinkVariantsRequest, opts ...grpc.CallOption) (*SetLinkVariantsResponse, error)
+	// Получить переходы по вариантам A/B теста ссылки
+	GetLinkStats(ctx context.Context, in *GetLinkStatsRequest, opts ...grpc.CallOption) (*LinkStats, error)
+	// Заменить параметры запроса, которые дописываются к адресу назначения ссылки
+	SetLinkParams(ctx context.Context, in *SetLinkParamsRequest, opts ...grpc.CallOption) (*LinkParams, error)
+	// Получить параметры, которые получает каждая новая ссылка пользователя
+	GetDefaultParams(ctx context.Context, in *GetDefaultParamsRequest, opts ...grpc.CallOption) (*LinkParams, error)
+	// Заменить параметры для новых ссылок пользователя
+	SetDefaultParams(ctx context.Context, in *SetDefaultParamsRequest, opts ...grpc.CallOption) (*LinkParams, error)
+	// Заменить название, заметку и теги ссылки
+	SetLinkMeta(ctx context.Context, in *SetLinkMetaRequest, opts ...grpc.CallOption) (*LinkMeta, error)
+	// Переименовать или объединить тег во всех ссылках пользователя или рабочего пространства
+	RenameTag(ctx context.Context, in *RenameTagRequest, opts ...grpc.CallOption) (*RenameTagResponse, error)
 }
 
 type uRLShortenerClient struct {
@@ -76,6 +118,16 @@ func (c *uRLShortenerClient) GetOriginLink(ctx context.Context, in *GetOriginLin
 	return out, nil
 }
 
+func (c *uRLShortenerClient) GetQRCode(ctx context.Context, in *GetQRCodeRequest, opts ...grpc.CallOption) (*QRCode, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QRCode)
+	err := c.cc.Invoke(ctx, URLShortener_GetQRCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *uRLShortenerClient) Shorten(ctx context.Context, in *ShortenRequest, opts ...grpc.CallOption) (*ShortenResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ShortenResponse)
@@ -86,6 +138,16 @@ func (c *uRLShortenerClient) Shorten(ctx context.Context, in *ShortenRequest, op
 	return out, nil
 }
 
+func (c *uRLShortenerClient) ShortenBatch(ctx context.Context, in *ShortenBatchRequest, opts ...grpc.CallOption) (*ShortenBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShortenBatchResponse)
+	err := c.cc.Invoke(ctx, URLShortener_ShortenBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *uRLShortenerClient) GetUserUrls(ctx context.Context, in *GetUserUrlsRequest, opts ...grpc.CallOption) (*GetUserUrlsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetUserUrlsResponse)
@@ -126,6 +188,138 @@ func (c *uRLShortenerClient) Ping(ctx context.Context, in *PingRequest, opts ...
 	return out, nil
 }
 
+func (c *uRLShortenerClient) ImportUrls(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ImportUrlsRequest, ImportUrlsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &URLShortener_ServiceDesc.Streams[0], URLShortener_ImportUrls_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportUrlsRequest, ImportUrlsResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortener_ImportUrlsClient = grpc.BidiStreamingClient[ImportUrlsRequest, ImportUrlsResponse]
+
+func (c *uRLShortenerClient) ExportUrls(ctx context.Context, in *ExportUrlsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportUrl], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &URLShortener_ServiceDesc.Streams[1], URLShortener_ExportUrls_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportUrlsRequest, ExportUrl]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortener_ExportUrlsClient = grpc.ServerStreamingClient[ExportUrl]
+
+func (c *uRLShortenerClient) SetLinkRedirect(ctx context.Context, in *SetLinkRedirectRequest, opts ...grpc.CallOption) (*SetLinkRedirectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetLinkRedirectResponse)
+	err := c.cc.Invoke(ctx, URLShortener_SetLinkRedirect_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) SetLinkPreview(ctx context.Context, in *SetLinkPreviewRequest, opts ...grpc.CallOption) (*SetLinkPreviewResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetLinkPreviewResponse)
+	err := c.cc.Invoke(ctx, URLShortener_SetLinkPreview_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) SetLinkRules(ctx context.Context, in *SetLinkRulesRequest, opts ...grpc.CallOption) (*SetLinkRulesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetLinkRulesResponse)
+	err := c.cc.Invoke(ctx, URLShortener_SetLinkRules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) SetLinkVariants(ctx context.Context, in *SetLinkVariantsRequest, opts ...grpc.CallOption) (*SetLinkVariantsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetLinkVariantsResponse)
+	err := c.cc.Invoke(ctx, URLShortener_SetLinkVariants_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) GetLinkStats(ctx context.Context, in *GetLinkStatsRequest, opts ...grpc.CallOption) (*LinkStats, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LinkStats)
+	err := c.cc.Invoke(ctx, URLShortener_GetLinkStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) SetLinkParams(ctx context.Context, in *SetLinkParamsRequest, opts ...grpc.CallOption) (*LinkParams, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LinkParams)
+	err := c.cc.Invoke(ctx, URLShortener_SetLinkParams_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) GetDefaultParams(ctx context.Context, in *GetDefaultParamsRequest, opts ...grpc.CallOption) (*LinkParams, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LinkParams)
+	err := c.cc.Invoke(ctx, URLShortener_GetDefaultParams_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) SetDefaultParams(ctx context.Context, in *SetDefaultParamsRequest, opts ...grpc.CallOption) (*LinkParams, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LinkParams)
+	err := c.cc.Invoke(ctx, URLShortener_SetDefaultParams_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) SetLinkMeta(ctx context.Context, in *SetLinkMetaRequest, opts ...grpc.CallOption) (*LinkMeta, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LinkMeta)
+	err := c.cc.Invoke(ctx, URLShortener_SetLinkMeta_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) RenameTag(ctx context.Context, in *RenameTagRequest, opts ...grpc.CallOption) (*RenameTagResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenameTagResponse)
+	err := c.cc.Invoke(ctx, URLShortener_RenameTag_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // URLShortenerServer is the server API for URLShortener service.
 // All implementations must embed UnimplementedURLShortenerServer
 // for forward compatibility.
@@ -134,8 +328,12 @@ type URLShortenerServer interface {
 	CreateShort(context.Context, *CreateShortRequest) (*CreateShortResponse, error)
 	// Получить оригинальный URL по короткому
 	GetOriginLink(context.Context, *GetOriginLinkRequest) (*GetOriginLinkResponse, error)
+	// Получить qr код короткого URL
+	GetQRCode(context.Context, *GetQRCodeRequest) (*QRCode, error)
 	// Укоротить URL через API
 	Shorten(context.Context, *ShortenRequest) (*ShortenResponse, error)
+	// Укоротить несколько URL с результатом для каждого
+	ShortenBatch(context.Context, *ShortenBatchRequest) (*ShortenBatchResponse, error)
 	// Получить все URL пользователя
 	GetUserUrls(context.Context, *GetUserUrlsRequest) (*GetUserUrlsResponse, error)
 	// Удалить URL пользователя
@@ -144,6 +342,30 @@ type URLShortenerServer interface {
 	GetStats(context.Context, *StatsRequest) (*StatsResponse, error)
 	// Проверка доступности сервера
 	Ping(context.Context, *PingRequest) (*PongResponse, error)
+	// Потоковый импорт ссылок, результаты строк возвращаются по мере сохранения
+	ImportUrls(grpc.BidiStreamingServer[ImportUrlsRequest, ImportUrlsResponse]) error
+	// Выгрузить все ссылки пользователя или рабочего пространства от старых к новым
+	ExportUrls(*ExportUrlsRequest, grpc.ServerStreamingServer[ExportUrl]) error
+	// Заменить настройки редиректа ссылки
+	SetLinkRedirect(context.Context, *SetLinkRedirectRequest) (*SetLinkRedirectResponse, error)
+	// Заменить данные страницы предпросмотра ссылки
+	SetLinkPreview(context.Context, *SetLinkPreviewRequest) (*SetLinkPreviewResponse, error)
+	// Заменить правила маршрутизации ссылки
+	SetLinkRules(context.Context, *SetLinkRulesRequest) (*SetLinkRulesResponse, error)
+	// Заменить варианты A/B теста ссылки
+	SetLinkVariants(context.Context, *SetLinkVariantsRequest) (*SetLinkVariantsResponse, error)
+	// Получить переходы по вариантам A/B теста ссылки
+	GetLinkStats(context.Context, *GetLinkStatsRequest) (*LinkStats, error)
+	// Заменить параметры запроса, которые дописываются к адресу назначения ссылки
+	SetLinkParams(context.Context, *SetLinkParamsRequest) (*LinkParams, error)
+	// Получить параметры, которые получает каждая новая ссылка пользователя
+	GetDefaultParams(context.Context, *GetDefaultParamsRequest) (*LinkParams, error)
+	// Заменить параметры для новых ссылок пользователя
+	SetDefaultParams(context.Context, *SetDefaultParamsRequest) (*LinkParams, error)
+	// Заменить название, заметку и теги ссылки
+	SetLinkMeta(context.Context, *SetLinkMetaRequest) (*LinkMeta, error)
+	// Переименовать или объединить тег во всех ссылках пользователя или рабочего пространства
+	RenameTag(context.Context, *RenameTagRequest) (*RenameTagResponse, error)
 	mustEmbedUnimplementedURLShortenerServer()
 }
 
@@ -160,9 +382,15 @@ func (UnimplementedURLShortenerServer) CreateShort(context.Context, *CreateShort
 func (UnimplementedURLShortenerServer) GetOriginLink(context.Context, *GetOriginLinkRequest) (*GetOriginLinkResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetOriginLink not implemented")
 }
+func (UnimplementedURLShortenerServer) GetQRCode(context.Context, *GetQRCodeRequest) (*QRCode, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQRCode not implemented")
+}
 func (UnimplementedURLShortenerServer) Shorten(context.Context, *ShortenRequest) (*ShortenResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Shorten not implemented")
 }
+func (UnimplementedURLShortenerServer) ShortenBatch(context.Context, *ShortenBatchRequest) (*ShortenBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShortenBatch not implemented")
+}
 func (UnimplementedURLShortenerServer) GetUserUrls(context.Context, *GetUserUrlsRequest) (*GetUserUrlsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserUrls not implemented")
 }
@@ -175,6 +403,42 @@ func (UnimplementedURLShortenerServer) GetStats(context.Context, *StatsRequest)
 func (UnimplementedURLShortenerServer) Ping(context.Context, *PingRequest) (*PongResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
+func (UnimplementedURLShortenerServer) ImportUrls(grpc.BidiStreamingServer[ImportUrlsRequest, ImportUrlsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ImportUrls not implemented")
+}
+func (UnimplementedURLShortenerServer) ExportUrls(*ExportUrlsRequest, grpc.ServerStreamingServer[ExportUrl]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportUrls not implemented")
+}
+func (UnimplementedURLShortenerServer) SetLinkRedirect(context.Context, *SetLinkRedirectRequest) (*SetLinkRedirectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLinkRedirect not implemented")
+}
+func (UnimplementedURLShortenerServer) SetLinkPreview(context.Context, *SetLinkPreviewRequest) (*SetLinkPreviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLinkPreview not implemented")
+}
+func (UnimplementedURLShortenerServer) SetLinkRules(context.Context, *SetLinkRulesRequest) (*SetLinkRulesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLinkRules not implemented")
+}
+func (UnimplementedURLShortenerServer) SetLinkVariants(context.Context, *SetLinkVariantsRequest) (*SetLinkVariantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLinkVariants not implemented")
+}
+func (UnimplementedURLShortenerServer) GetLinkStats(context.Context, *GetLinkStatsRequest) (*LinkStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLinkStats not implemented")
+}
+func (UnimplementedURLShortenerServer) SetLinkParams(context.Context, *SetLinkParamsRequest) (*LinkParams, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLinkParams not implemented")
+}
+func (UnimplementedURLShortenerServer) GetDefaultParams(context.Context, *GetDefaultParamsRequest) (*LinkParams, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDefaultParams not implemented")
+}
+func (UnimplementedURLShortenerServer) SetDefaultParams(context.Context, *SetDefaultParamsRequest) (*LinkParams, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetDefaultParams not implemented")
+}
+func (UnimplementedURLShortenerServer) SetLinkMeta(context.Context, *SetLinkMetaRequest) (*LinkMeta, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLinkMeta not implemented")
+}
+func (UnimplementedURLShortenerServer) RenameTag(context.Context, *RenameTagRequest) (*RenameTagResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameTag not implemented")
+}
 func (UnimplementedURLShortenerServer) mustEmbedUnimplementedURLShortenerServer() {}
 func (UnimplementedURLShortenerServer) testEmbeddedByValue()                      {}
 
@@ -232,6 +496,24 @@ func _URLShortener_GetOriginLink_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _URLShortener_GetQRCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQRCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).GetQRCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_GetQRCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).GetQRCode(ctx, req.(*GetQRCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _URLShortener_Shorten_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ShortenRequest)
 	if err := dec(in); err != nil {
@@ -250,6 +532,24 @@ func _URLShortener_Shorten_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _URLShortener_ShortenBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShortenBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).ShortenBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_ShortenBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).ShortenBatch(ctx, req.(*ShortenBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _URLShortener_GetUserUrls_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetUserUrlsRequest)
 	if err := dec(in); err != nil {
@@ -322,6 +622,204 @@ func _URLShortener_Ping_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _URLShortener_ImportUrls_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(URLShortenerServer).ImportUrls(&grpc.GenericServerStream[ImportUrlsRequest, ImportUrlsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortener_ImportUrlsServer = grpc.BidiStreamingServer[ImportUrlsRequest, ImportUrlsResponse]
+
+func _URLShortener_ExportUrls_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportUrlsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(URLShortenerServer).ExportUrls(m, &grpc.GenericServerStream[ExportUrlsRequest, ExportUrl]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortener_ExportUrlsServer = grpc.ServerStreamingServer[ExportUrl]
+
+func _URLShortener_SetLinkRedirect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLinkRedirectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).SetLinkRedirect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_SetLinkRedirect_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).SetLinkRedirect(ctx, req.(*SetLinkRedirectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_SetLinkPreview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLinkPreviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).SetLinkPreview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_SetLinkPreview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).SetLinkPreview(ctx, req.(*SetLinkPreviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_SetLinkRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLinkRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).SetLinkRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_SetLinkRules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).SetLinkRules(ctx, req.(*SetLinkRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_SetLinkVariants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLinkVariantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).SetLinkVariants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_SetLinkVariants_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).SetLinkVariants(ctx, req.(*SetLinkVariantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_GetLinkStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLinkStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).GetLinkStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_GetLinkStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).GetLinkStats(ctx, req.(*GetLinkStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_SetLinkParams_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLinkParamsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).SetLinkParams(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_SetLinkParams_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).SetLinkParams(ctx, req.(*SetLinkParamsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_GetDefaultParams_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDefaultParamsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).GetDefaultParams(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_GetDefaultParams_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).GetDefaultParams(ctx, req.(*GetDefaultParamsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_SetDefaultParams_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetDefaultParamsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).SetDefaultParams(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_SetDefaultParams_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).SetDefaultParams(ctx, req.(*SetDefaultParamsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_SetLinkMeta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLinkMetaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).SetLinkMeta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_SetLinkMeta_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).SetLinkMeta(ctx, req.(*SetLinkMetaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_RenameTag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameTagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).RenameTag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_RenameTag_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).RenameTag(ctx, req.(*RenameTagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // URLShortener_ServiceDesc is the grpc.ServiceDesc for URLShortener service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -337,10 +835,18 @@ var URLShortener_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetOriginLink",
 			Handler:    _URLShortener_GetOriginLink_Handler,
 		},
+		{
+			MethodName: "GetQRCode",
+			Handler:    _URLShortener_GetQRCode_Handler,
+		},
 		{
 			MethodName: "Shorten",
 			Handler:    _URLShortener_Shorten_Handler,
 		},
+		{
+			MethodName: "ShortenBatch",
+			Handler:    _URLShortener_ShortenBatch_Handler,
+		},
 		{
 			MethodName: "GetUserUrls",
 			Handler:    _URLShortener_GetUserUrls_Handler,
@@ -357,6 +863,366 @@ var URLShortener_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Ping",
 			Handler:    _URLShortener_Ping_Handler,
 		},
+		{
+			MethodName: "SetLinkRedirect",
+			Handler:    _URLShortener_SetLinkRedirect_Handler,
+		},
+		{
+			MethodName: "SetLinkPreview",
+			Handler:    _URLShortener_SetLinkPreview_Handler,
+		},
+		{
+			MethodName: "SetLinkRules",
+			Handler:    _URLShortener_SetLinkRules_Handler,
+		},
+		{
+			MethodName: "SetLinkVariants",
+			Handler:    _URLShortener_SetLinkVariants_Handler,
+		},
+		{
+			MethodName: "GetLinkStats",
+			Handler:    _URLShortener_GetLinkStats_Handler,
+		},
+		{
+			MethodName: "SetLinkParams",
+			Handler:    _URLShortener_SetLinkParams_Handler,
+		},
+		{
+			MethodName: "GetDefaultParams",
+			Handler:    _URLShortener_GetDefaultParams_Handler,
+		},
+		{
+			MethodName: "SetDefaultParams",
+			Handler:    _URLShortener_SetDefaultParams_Handler,
+		},
+		{
+			MethodName: "SetLinkMeta",
+			Handler:    _URLShortener_SetLinkMeta_Handler,
+		},
+		{
+			MethodName: "RenameTag",
+			Handler:    _URLShortener_RenameTag_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ImportUrls",
+			Handler:       _URLShortener_ImportUrls_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportUrls",
+			Handler:       _URLShortener_ExportUrls_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/urlshortener.proto",
+}
+
+const (
+	URLShortenerAdmin_GetLink_FullMethodName         = "/urlshortener.URLShortenerAdmin/GetLink"
+	URLShortenerAdmin_SetLinkDisabled_FullMethodName = "/urlshortener.URLShortenerAdmin/SetLinkDisabled"
+	URLShortenerAdmin_DeleteByDomain_FullMethodName  = "/urlshortener.URLShortenerAdmin/DeleteByDomain"
+	URLShortenerAdmin_ListUserLinks_FullMethodName   = "/urlshortener.URLShortenerAdmin/ListUserLinks"
+	URLShortenerAdmin_SetUserBan_FullMethodName      = "/urlshortener.URLShortenerAdmin/SetUserBan"
+	URLShortenerAdmin_SetUserDomain_FullMethodName   = "/urlshortener.URLShortenerAdmin/SetUserDomain"
+)
+
+// URLShortenerAdminClient is the client API for URLShortenerAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Модерация, только для администраторов
+type URLShortenerAdminClient interface {
+	// Получить любую ссылку с владельцем
+	GetLink(ctx context.Context, in *AdminGetLinkRequest, opts ...grpc.CallOption) (*AdminLink, error)
+	// Отключить или включить ссылку
+	SetLinkDisabled(ctx context.Context, in *SetLinkDisabledRequest, opts ...grpc.CallOption) (*AdminLink, error)
+	// Удалить все ссылки на домен и его поддомены
+	DeleteByDomain(ctx context.Context, in *DeleteByDomainRequest, opts ...grpc.CallOption) (*DeleteByDomainResponse, error)
+	// Получить все ссылки пользователя
+	ListUserLinks(ctx context.Context, in *ListUserLinksRequest, opts ...grpc.CallOption) (*ListUserLinksResponse, error)
+	// Запретить или разрешить пользователю создавать ссылки
+	SetUserBan(ctx context.Context, in *SetUserBanRequest, opts ...grpc.CallOption) (*SetUserBanResponse, error)
+	// Разрешить или запретить пользователю создавать ссылки на брендовом домене
+	SetUserDomain(ctx context.Context, in *SetUserDomainRequest, opts ...grpc.CallOption) (*SetUserDomainResponse, error)
+}
+
+type uRLShortenerAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewURLShortenerAdminClient(cc grpc.ClientConnInterface) URLShortenerAdminClient {
+	return &uRLShortenerAdminClient{cc}
+}
+
+func (c *uRLShortenerAdminClient) GetLink(ctx context.Context, in *AdminGetLinkRequest, opts ...grpc.CallOption) (*AdminLink, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminLink)
+	err := c.cc.Invoke(ctx, URLShortenerAdmin_GetLink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerAdminClient) SetLinkDisabled(ctx context.Context, in *SetLinkDisabledRequest, opts ...grpc.CallOption) (*AdminLink, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminLink)
+	err := c.cc.Invoke(ctx, URLShortenerAdmin_SetLinkDisabled_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerAdminClient) DeleteByDomain(ctx context.Context, in *DeleteByDomainRequest, opts ...grpc.CallOption) (*DeleteByDomainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteByDomainResponse)
+	err := c.cc.Invoke(ctx, URLShortenerAdmin_DeleteByDomain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerAdminClient) ListUserLinks(ctx context.Context, in *ListUserLinksRequest, opts ...grpc.CallOption) (*ListUserLinksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListUserLinksResponse)
+	err := c.cc.Invoke(ctx, URLShortenerAdmin_ListUserLinks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerAdminClient) SetUserBan(ctx context.Context, in *SetUserBanRequest, opts ...grpc.CallOption) (*SetUserBanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetUserBanResponse)
+	err := c.cc.Invoke(ctx, URLShortenerAdmin_SetUserBan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerAdminClient) SetUserDomain(ctx context.Context, in *SetUserDomainRequest, opts ...grpc.CallOption) (*SetUserDomainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetUserDomainResponse)
+	err := c.cc.Invoke(ctx, URLShortenerAdmin_SetUserDomain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// URLShortenerAdminServer is the server API for URLShortenerAdmin service.
+// All implementations must embed UnimplementedURLShortenerAdminServer
+// for forward compatibility.
+//
+// Модерация, только для администраторов
+type URLShortenerAdminServer interface {
+	// Получить любую ссылку с владельцем
+	GetLink(context.Context, *AdminGetLinkRequest) (*AdminLink, error)
+	// Отключить или включить ссылку
+	SetLinkDisabled(context.Context, *SetLinkDisabledRequest) (*AdminLink, error)
+	// Удалить все ссылки на домен и его поддомены
+	DeleteByDomain(context.Context, *DeleteByDomainRequest) (*DeleteByDomainResponse, error)
+	// Получить все ссылки пользователя
+	ListUserLinks(context.Context, *ListUserLinksRequest) (*ListUserLinksResponse, error)
+	// Запретить или разрешить пользователю создавать ссылки
+	SetUserBan(context.Context, *SetUserBanRequest) (*SetUserBanResponse, error)
+	// Разрешить или запретить пользователю создавать ссылки на брендовом домене
+	SetUserDomain(context.Context, *SetUserDomainRequest) (*SetUserDomainResponse, error)
+	mustEmbedUnimplementedURLShortenerAdminServer()
+}
+
+// UnimplementedURLShortenerAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedURLShortenerAdminServer struct{}
+
+func (UnimplementedURLShortenerAdminServer) GetLink(context.Context, *AdminGetLinkRequest) (*AdminLink, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLink not implemented")
+}
+func (UnimplementedURLShortenerAdminServer) SetLinkDisabled(context.Context, *SetLinkDisabledRequest) (*AdminLink, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLinkDisabled not implemented")
+}
+func (UnimplementedURLShortenerAdminServer) DeleteByDomain(context.Context, *DeleteByDomainRequest) (*DeleteByDomainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteByDomain not implemented")
+}
+func (UnimplementedURLShortenerAdminServer) ListUserLinks(context.Context, *ListUserLinksRequest) (*ListUserLinksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUserLinks not implemented")
+}
+func (UnimplementedURLShortenerAdminServer) SetUserBan(context.Context, *SetUserBanRequest) (*SetUserBanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetUserBan not implemented")
+}
+func (UnimplementedURLShortenerAdminServer) SetUserDomain(context.Context, *SetUserDomainRequest) (*SetUserDomainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetUserDomain not implemented")
+}
+func (UnimplementedURLShortenerAdminServer) mustEmbedUnimplementedURLShortenerAdminServer() {}
+func (UnimplementedURLShortenerAdminServer) testEmbeddedByValue()                           {}
+
+// UnsafeURLShortenerAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to URLShortenerAdminServer will
+// result in compilation errors.
+type UnsafeURLShortenerAdminServer interface {
+	mustEmbedUnimplementedURLShortenerAdminServer()
+}
+
+func RegisterURLShortenerAdminServer(s grpc.ServiceRegistrar, srv URLShortenerAdminServer) {
+	// If the following call pancis, it indicates UnimplementedURLShortenerAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&URLShortenerAdmin_ServiceDesc, srv)
+}
+
+func _URLShortenerAdmin_GetLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminGetLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerAdminServer).GetLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerAdmin_GetLink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerAdminServer).GetLink(ctx, req.(*AdminGetLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerAdmin_SetLinkDisabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLinkDisabledRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerAdminServer).SetLinkDisabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerAdmin_SetLinkDisabled_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerAdminServer).SetLinkDisabled(ctx, req.(*SetLinkDisabledRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerAdmin_DeleteByDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteByDomainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerAdminServer).DeleteByDomain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerAdmin_DeleteByDomain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerAdminServer).DeleteByDomain(ctx, req.(*DeleteByDomainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerAdmin_ListUserLinks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUserLinksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerAdminServer).ListUserLinks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerAdmin_ListUserLinks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerAdminServer).ListUserLinks(ctx, req.(*ListUserLinksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerAdmin_SetUserBan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetUserBanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerAdminServer).SetUserBan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerAdmin_SetUserBan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerAdminServer).SetUserBan(ctx, req.(*SetUserBanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerAdmin_SetUserDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetUserDomainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerAdminServer).SetUserDomain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerAdmin_SetUserDomain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerAdminServer).SetUserDomain(ctx, req.(*SetUserDomainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// URLShortenerAdmin_ServiceDesc is the grpc.ServiceDesc for URLShortenerAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var URLShortenerAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "urlshortener.URLShortenerAdmin",
+	HandlerType: (*URLShortenerAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLink",
+			Handler:    _URLShortenerAdmin_GetLink_Handler,
+		},
+		{
+			MethodName: "SetLinkDisabled",
+			Handler:    _URLShortenerAdmin_SetLinkDisabled_Handler,
+		},
+		{
+			MethodName: "DeleteByDomain",
+			Handler:    _URLShortenerAdmin_DeleteByDomain_Handler,
+		},
+		{
+			MethodName: "ListUserLinks",
+			Handler:    _URLShortenerAdmin_ListUserLinks_Handler,
+		},
+		{
+			MethodName: "SetUserBan",
+			Handler:    _URLShortenerAdmin_SetUserBan_Handler,
+		},
+		{
+			MethodName: "SetUserDomain",
+			Handler:    _URLShortenerAdmin_SetUserDomain_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/urlshortener.proto",